@@ -0,0 +1,198 @@
+package apierror
+
+import (
+	"../block_chain"
+	"../consensus"
+	"../finality"
+	"../keystore"
+	"../offline_tx"
+	"../ordering"
+	"../signature_scheme"
+	"../utils"
+	"../wallet"
+	"../wallet_db"
+	"errors"
+	"github.com/labstack/echo"
+	"log"
+	"net/http"
+)
+
+const (
+	CodeInvalidRequest      = "invalid_request"
+	CodeInvalidEncoding     = "invalid_encoding"
+	CodeInvalidSignature    = "invalid_signature"
+	CodeInsufficientBalance = "insufficient_balance"
+	CodeInsufficientStake   = "insufficient_stake"
+	CodeNonceUsed           = "nonce_used"
+	CodeSigningFailed       = "signing_failed"
+	CodeInvalidAddress      = "invalid_address"
+	CodeWrongNetwork        = "wrong_network"
+	CodeGenesisMismatch     = "genesis_mismatch"
+	CodeFinalizedConflict   = "finalized_conflict"
+	CodeInvalidChain        = "invalid_chain"
+	CodeStaleChain          = "stale_chain"
+	CodeWrongPassphrase     = "wrong_passphrase"
+	CodeNotFound            = "not_found"
+	CodeForbidden           = "forbidden"
+	CodeAlreadyExists       = "already_exists"
+	CodeUpstreamError       = "upstream_error"
+	CodeInternalError       = "internal_error"
+)
+
+// Error is the body every handler answers with when something goes wrong.
+type Error struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details string `json:"details,omitempty"`
+}
+
+type mapping struct {
+	target  error
+	status  int
+	code    string
+	message string
+}
+
+var mappings = []mapping{
+	{blockchain.ErrInvalidTransactionRequest, http.StatusBadRequest, CodeInvalidRequest, "invalid transaction request"},
+	{blockchain.ErrInvalidSignature, http.StatusUnprocessableEntity, CodeInvalidSignature, "transaction signature is not valid"},
+	{blockchain.ErrInsufficientBalance, http.StatusUnprocessableEntity, CodeInsufficientBalance, "not enough balance in the sender wallet"},
+	{blockchain.ErrNonceUsed, http.StatusConflict, CodeNonceUsed, "nonce is already used"},
+	{blockchain.ErrGenesisMismatch, http.StatusConflict, CodeGenesisMismatch, "node runs a different genesis block"},
+	{blockchain.ErrFinalizedConflict, http.StatusConflict, CodeFinalizedConflict, "chain conflicts with a finalized block"},
+	{blockchain.ErrCheckpointMismatch, http.StatusConflict, CodeInvalidChain, "chain conflicts with a checkpoint"},
+	{blockchain.ErrInvalidChain, http.StatusUnprocessableEntity, CodeInvalidChain, "chain is not valid"},
+	{blockchain.ErrChainNotLonger, http.StatusConflict, CodeStaleChain, "chain does not beat the current one"},
+	{blockchain.ErrInvalidTransaction, http.StatusUnprocessableEntity, CodeInvalidRequest, "transaction is not valid"},
+	{blockchain.ErrBlockNotInChain, http.StatusNotFound, CodeNotFound, "block is not in the chain"},
+	{blockchain.ErrStakingNotSupported, http.StatusBadRequest, CodeInvalidRequest, "consensus engine does not support staking"},
+	{blockchain.ErrInsufficientStake, http.StatusUnprocessableEntity, CodeInsufficientStake, "not enough stake to unstake"},
+	{consensus.ErrInvalidEvidence, http.StatusBadRequest, CodeInvalidRequest, "double-signing evidence is not valid"},
+	{consensus.ErrNoValidators, http.StatusServiceUnavailable, CodeInternalError, "no validator holds the minimum stake"},
+	{consensus.ErrInvalidVote, http.StatusBadRequest, CodeInvalidRequest, "signer vote is not valid"},
+	{consensus.ErrUnsupported, http.StatusBadRequest, CodeInvalidRequest, "consensus engine does not support this"},
+	{finality.ErrInvalidVote, http.StatusBadRequest, CodeInvalidRequest, "finality vote is not valid"},
+	{finality.ErrNotValidator, http.StatusForbidden, CodeInvalidSignature, "key is not a finality validator"},
+	{finality.ErrEquivocation, http.StatusConflict, CodeInvalidSignature, "validator already voted for another block"},
+	{ordering.ErrNoLeader, http.StatusServiceUnavailable, CodeUpstreamError, "Raft cluster has no leader"},
+	{ordering.ErrNotLeader, http.StatusServiceUnavailable, CodeUpstreamError, "node is not the Raft leader"},
+	{utils.ErrInvalidHex, http.StatusBadRequest, CodeInvalidEncoding, "value is not valid hex"},
+	{utils.ErrInvalidLength, http.StatusBadRequest, CodeInvalidEncoding, "value has invalid length"},
+	{utils.ErrInvalidPoint, http.StatusBadRequest, CodeInvalidEncoding, "value is not a point on the curve"},
+	{utils.ErrInvalidSignature, http.StatusBadRequest, CodeInvalidEncoding, "signature encoding is not valid"},
+	{signaturescheme.ErrUnknownScheme, http.StatusBadRequest, CodeInvalidRequest, "signature scheme is not supported"},
+	{signaturescheme.ErrInvalidPublicKey, http.StatusBadRequest, CodeInvalidEncoding, "public key is not valid"},
+	{signaturescheme.ErrInvalidPrivateKey, http.StatusBadRequest, CodeInvalidEncoding, "private key is not valid"},
+	{wallet.ErrSigningFailed, http.StatusInternalServerError, CodeSigningFailed, "could not sign transaction"},
+	{wallet.ErrKeyGenerationFailed, http.StatusInternalServerError, CodeInternalError, "could not generate key"},
+	{wallet.ErrInvalidAddress, http.StatusBadRequest, CodeInvalidAddress, "blockchain address is not valid"},
+	{wallet.ErrWrongNetwork, http.StatusBadRequest, CodeWrongNetwork, "blockchain address belongs to another network"},
+	{wallet.ErrInvalidMnemonic, http.StatusBadRequest, CodeInvalidRequest, "mnemonic is not valid"},
+	{wallet.ErrInvalidDerivationPath, http.StatusBadRequest, CodeInvalidRequest, "derivation path is not valid"},
+	{wallet.ErrIndexOutOfRange, http.StatusBadRequest, CodeInvalidRequest, "derivation index is out of range"},
+	{wallet.ErrInvalidMultisigPolicy, http.StatusBadRequest, CodeInvalidRequest, "multisig policy is not valid"},
+	{wallet.ErrNotEnoughSignatures, http.StatusUnprocessableEntity, CodeInvalidSignature, "multisig transaction does not have enough valid signatures"},
+	{wallet.ErrInvalidMessageSignature, http.StatusUnprocessableEntity, CodeInvalidSignature, "message signature is not valid"},
+	{offlinetx.ErrInvalidFile, http.StatusBadRequest, CodeInvalidRequest, "transaction file is not valid"},
+	{offlinetx.ErrKeyMismatch, http.StatusBadRequest, CodeInvalidRequest, "key cannot sign for the sender"},
+	{offlinetx.ErrNotSigned, http.StatusUnprocessableEntity, CodeInvalidSignature, "transaction does not have enough signatures"},
+	{keystore.ErrWrongPassphrase, http.StatusUnauthorized, CodeWrongPassphrase, "passphrase does not unlock the key"},
+	{keystore.ErrKeyNotFound, http.StatusNotFound, CodeNotFound, "key not found in keystore"},
+	{walletdb.ErrNotFound, http.StatusNotFound, CodeNotFound, "entry not found in wallet database"},
+	{walletdb.ErrAlreadyExists, http.StatusConflict, CodeAlreadyExists, "entry already exists in wallet database"},
+	{walletdb.ErrInvalidLabel, http.StatusBadRequest, CodeInvalidRequest, "label is not valid"},
+	{keystore.ErrKeyExists, http.StatusConflict, CodeAlreadyExists, "key already in keystore"},
+	{keystore.ErrInvalidKeyFile, http.StatusInternalServerError, CodeInternalError, "key file is damaged"},
+}
+
+func (e *Error) Error() string {
+	if e.Details != "" {
+		return e.Message + ": " + e.Details
+	}
+
+	return e.Message
+}
+
+func New(status int, code string, message string, details string) *Error {
+	return &Error{
+		Status:  status,
+		Code:    code,
+		Message: message,
+		Details: details,
+	}
+}
+
+func BadRequest(details string) *Error {
+	return New(http.StatusBadRequest, CodeInvalidRequest, "invalid request", details)
+}
+
+// From maps an error returned by utils, wallet or blockchain to the
+// matching API error. Unknown errors become a bare internal error, since
+// their text may reveal paths or other internals; like every server-side
+// failure they are logged instead.
+func From(err error) *Error {
+	apiErr := from(err)
+
+	if apiErr.Status >= http.StatusInternalServerError {
+		log.Println(err)
+	}
+
+	return apiErr
+}
+
+func from(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		code := CodeInvalidRequest
+		if httpErr.Code == http.StatusNotFound {
+			code = CodeNotFound
+		} else if httpErr.Code >= http.StatusInternalServerError {
+			code = CodeInternalError
+		}
+		return New(httpErr.Code, code, http.StatusText(httpErr.Code), errorDetails(httpErr.Message))
+	}
+
+	for _, m := range mappings {
+		if errors.Is(err, m.target) {
+			return New(m.status, m.code, m.message, err.Error())
+		}
+	}
+
+	return New(http.StatusInternalServerError, CodeInternalError, "internal error", "")
+}
+
+// HTTPErrorHandler replaces echo's default handler so that every failed
+// request gets the same JSON error body.
+func HTTPErrorHandler(err error, c echo.Context) {
+	apiErr := From(err)
+
+	if c.Response().Committed {
+		return
+	}
+
+	if err := c.JSON(apiErr.Status, struct {
+		Error *Error `json:"error"`
+	}{
+		Error: apiErr,
+	}); err != nil {
+		log.Println(err)
+	}
+}
+
+func errorDetails(message interface{}) string {
+	if s, ok := message.(string); ok {
+		return s
+	}
+
+	if err, ok := message.(error); ok {
+		return err.Error()
+	}
+
+	return ""
+}
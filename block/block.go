@@ -5,7 +5,6 @@ import (
 	"../transaction"
 	"encoding/json"
 	"fmt"
-	"time"
 )
 
 type Block struct {
@@ -16,47 +15,189 @@ type Block struct {
 	Data       []*transaction.Transaction
 	Nonce      int64
 	Difficulty int
+	ExtraData  string
+	// Slot, Producer, PublicKey and Signature are set by engines whose
+	// producers sign blocks; proof of work leaves them empty.
+	Slot      int64
+	Producer  string
+	PublicKey string
+	Signature string
+	Evidence  []*Evidence
+	// Vote is a proof-of-authority producer's vote on a signer.
+	Vote *Vote
 }
 
-func CreateGenesisBlock(tx []*transaction.Transaction) *Block {
-	now := time.Now().UnixMilli()
-	data := "Genesis Block"
+type blockJSON struct {
+	Index        int64                      `json:"index"`
+	Timestamp    int64                      `json:"timestamp"`
+	Nonce        int64                      `json:"nonce"`
+	PreviousHash string                     `json:"previous_hash"`
+	Transactions []*transaction.Transaction `json:"transactions"`
+	Difficulty   int                        `json:"difficulty"`
+	Hash         string                     `json:"hash"`
+	ExtraData    string                     `json:"extra_data,omitempty"`
+	Slot         int64                      `json:"slot,omitempty"`
+	Producer     string                     `json:"producer,omitempty"`
+	PublicKey    string                     `json:"public_key,omitempty"`
+	Signature    string                     `json:"signature,omitempty"`
+	Evidence     []*Evidence                `json:"evidence,omitempty"`
+	Vote         *Vote                      `json:"vote,omitempty"`
+}
+
+// Header is what a block producer signs. It commits to the transactions and
+// the evidence through their hashes, so a header on its own shows what was
+// signed.
+type Header struct {
+	Index        int64  `json:"index"`
+	Timestamp    int64  `json:"timestamp"`
+	PreviousHash string `json:"previous_hash"`
+	DataHash     string `json:"data_hash"`
+	EvidenceHash string `json:"evidence_hash"`
+	Slot         int64  `json:"slot"`
+	Producer     string `json:"producer"`
+	Vote         *Vote  `json:"vote,omitempty"`
+}
+
+// SignedHeader is a header together with the producer's key and signature
+// over its hash.
+type SignedHeader struct {
+	Header
+	PublicKey string `json:"public_key"`
+	Signature string `json:"signature"`
+}
+
+// Vote asks to authorize or to drop the signer holding PublicKey.
+type Vote struct {
+	PublicKey string `json:"public_key"`
+	Authorize bool   `json:"authorize"`
+}
+
+// Evidence shows one producer signing two different headers for the same
+// slot.
+type Evidence struct {
+	First  *SignedHeader `json:"first"`
+	Second *SignedHeader `json:"second"`
+}
+
+// NewGenesisBlock builds a genesis block whose hash depends only on its
+// arguments, so every node started with the same chain parameters agrees on
+// it.
+func NewGenesisBlock(timeStamp int64, tx []*transaction.Transaction, difficulty int, extraData string) *Block {
+	data, err := json.Marshal(tx)
+
+	if err != nil {
+		panic(err)
+	}
+
 	return &Block{
 		Index:      0,
-		TimeStamp:  now,
+		TimeStamp:  timeStamp,
 		PrevHash:   "GENESIS BLOCK",
-		Hash:       hash.CalculateHash(fmt.Sprintf("%d-%s-%s-%s-%d-%d", 0, now, "", data, 0, 0)),
+		Hash:       hash.CalculateHash(fmt.Sprintf("%d-%d-%s-%s-%d-%d-%s", 0, timeStamp, "GENESIS BLOCK", data, 0, difficulty, extraData)),
 		Data:       tx,
 		Nonce:      0,
-		Difficulty: 1,
+		Difficulty: difficulty,
+		ExtraData:  extraData,
+	}
+}
+
+func (h *Header) Hash() string {
+	var vote string
+	if h.Vote != nil {
+		vote = fmt.Sprintf("%s:%t", h.Vote.PublicKey, h.Vote.Authorize)
+	}
+
+	return hash.CalculateHash(fmt.Sprintf("%d-%d-%s-%s-%s-%d-%s-%s", h.Index, h.Timestamp, h.PreviousHash, h.DataHash, h.EvidenceHash, h.Slot, h.Producer, vote))
+}
+
+func (b *Block) Header() *Header {
+	return &Header{
+		Index:        b.Index,
+		Timestamp:    b.TimeStamp,
+		PreviousHash: b.PrevHash,
+		DataHash:     hashJSON(b.Data),
+		EvidenceHash: hashJSON(b.Evidence),
+		Slot:         b.Slot,
+		Producer:     b.Producer,
+		Vote:         b.Vote,
+	}
+}
+
+func (b *Block) SignedHeader() *SignedHeader {
+	return &SignedHeader{
+		Header:    *b.Header(),
+		PublicKey: b.PublicKey,
+		Signature: b.Signature,
 	}
 }
 
+func hashJSON(v interface{}) string {
+	m, err := json.Marshal(v)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return hash.CalculateHash(string(m))
+}
+
 func (b *Block) Print() {
 	fmt.Printf("index           %d\n", b.Index)
 	fmt.Printf("timestamp       %d\n", b.TimeStamp)
 	fmt.Printf("previous_hash   %x\n", b.PrevHash)
 	fmt.Printf("nonce           %d\n", b.Nonce)
-	fmt.Printf("difficulty      %s\n", b.Difficulty)
+	fmt.Printf("difficulty      %d\n", b.Difficulty)
+	if b.Producer != "" {
+		fmt.Printf("slot            %d\n", b.Slot)
+		fmt.Printf("producer        %s\n", b.Producer)
+	}
 	for _, t := range b.Data {
 		t.Print()
 	}
 }
 
 func (b *Block) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		Timestamp    int64                      `json:"timestamp"`
-		Nonce        int64                      `json:"nonce"`
-		PreviousHash string                     `json:"previous_hash"`
-		Transactions []*transaction.Transaction `json:"transactions"`
-		Difficulty   int                        `json:"difficulty"`
-		Hash         string                     `json:"hash"`
-	}{
+	return json.Marshal(blockJSON{
+		Index:        b.Index,
 		Timestamp:    b.TimeStamp,
 		Nonce:        b.Nonce,
 		PreviousHash: b.PrevHash,
 		Transactions: b.Data,
 		Difficulty:   b.Difficulty,
 		Hash:         b.Hash,
+		ExtraData:    b.ExtraData,
+		Slot:         b.Slot,
+		Producer:     b.Producer,
+		PublicKey:    b.PublicKey,
+		Signature:    b.Signature,
+		Evidence:     b.Evidence,
+		Vote:         b.Vote,
 	})
 }
+
+func (b *Block) UnmarshalJSON(data []byte) error {
+	v := blockJSON{}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*b = Block{
+		Index:      v.Index,
+		TimeStamp:  v.Timestamp,
+		PrevHash:   v.PreviousHash,
+		Hash:       v.Hash,
+		Data:       v.Transactions,
+		Nonce:      v.Nonce,
+		Difficulty: v.Difficulty,
+		ExtraData:  v.ExtraData,
+		Slot:       v.Slot,
+		Producer:   v.Producer,
+		PublicKey:  v.PublicKey,
+		Signature:  v.Signature,
+		Evidence:   v.Evidence,
+		Vote:       v.Vote,
+	}
+
+	return nil
+}
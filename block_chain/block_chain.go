@@ -3,169 +3,847 @@ package blockchain
 import "C"
 import (
 	"../block"
-	"../hash"
+	"../chain_params"
+	"../consensus"
+	"../events"
+	"../signature_scheme"
 	"../transaction"
 	"../utils"
-	"crypto/ecdsa"
+	"../wallet"
 	"crypto/sha256"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"strconv"
+	"log"
 	"strings"
 	"sync"
 	"time"
 )
 
-const BlockChainCore = "BLOCKCHAIN_CORE"
-const MiningReward = 0.1
+const BlockChainCore = consensus.RewardSender
 const MinerAddress = "MINER_ADDRESS"
-const ExpectedMiningProcessIntervalMillisecond = 2000
-const DifficultyAdjustmentIntervalBlockCount = 20
 
 type BlockChain struct {
 	BlockList       []*block.Block
 	TransactionPool []*transaction.Transaction
 	Difficulty      int
+	Params          *chainparams.Params
+	MinerAddress    string
+	engine          consensus.Engine
+	finalizedHeight int64
 	lock            sync.Mutex
+	events          *events.Hub
 }
 
+// TransactionRequest is signed either by one key, with SenderPublicKey and
+// Signature, or by a multisig sender, with Multisig and Signatures. Nonce
+// is signed along with the transfer and may be used once per signer.
 type TransactionRequest struct {
-	SenderBlockchainAddress    *string  `json:"senderBlockchainAddress"`
-	RecipientBlockchainAddress *string  `json:"recipientBlockchainAddress"`
-	SenderPublicKey            *string  `json:"senderPublicKey"`
-	Value                      *float32 `json:"value"`
-	Signature                  *string  `json:"signature"`
+	SenderBlockchainAddress    *string                   `json:"senderBlockchainAddress"`
+	RecipientBlockchainAddress *string                   `json:"recipientBlockchainAddress"`
+	Scheme                     *string                   `json:"scheme,omitempty"`
+	SenderPublicKey            *string                   `json:"senderPublicKey,omitempty"`
+	Value                      *float32                  `json:"value"`
+	Nonce                      *uint64                   `json:"nonce"`
+	Signature                  *string                   `json:"signature,omitempty"`
+	Multisig                   *wallet.MultisigPolicy    `json:"multisig,omitempty"`
+	Signatures                 []wallet.PartialSignature `json:"signatures,omitempty"`
 }
 
-func (tr TransactionRequest) Validate() bool {
+func (tr TransactionRequest) Validate() error {
 	if tr.SenderBlockchainAddress == nil ||
 		tr.RecipientBlockchainAddress == nil ||
-		tr.SenderPublicKey == nil ||
 		tr.Value == nil ||
-		tr.Signature == nil {
-		return false
+		tr.Nonce == nil {
+		return fmt.Errorf("%w: senderBlockchainAddress, recipientBlockchainAddress, value and nonce are required", ErrInvalidTransactionRequest)
 	}
 
-	return true
+	if tr.Multisig != nil {
+		if tr.Scheme != nil || tr.SenderPublicKey != nil || tr.Signature != nil {
+			return fmt.Errorf("%w: a multisig request takes signatures, not scheme, senderPublicKey and signature", ErrInvalidTransactionRequest)
+		}
+
+		if len(tr.Signatures) == 0 {
+			return fmt.Errorf("%w: signatures are required with multisig", ErrInvalidTransactionRequest)
+		}
+	} else if tr.SenderPublicKey == nil || tr.Signature == nil {
+		return fmt.Errorf("%w: senderPublicKey and signature are required", ErrInvalidTransactionRequest)
+	}
+
+	if *tr.Value <= 0 {
+		return fmt.Errorf("%w: value must be positive", ErrInvalidTransactionRequest)
+	}
+
+	if *tr.Nonce == 0 {
+		return fmt.Errorf("%w: nonce must be positive", ErrInvalidTransactionRequest)
+	}
+
+	return nil
 }
 
-func New() *BlockChain {
-	return &BlockChain{}
+// New starts a proof-of-work chain at the genesis block of params.
+func New(params *chainparams.Params) *BlockChain {
+	return NewWithEngine(params, consensus.NewProofOfWork(params))
 }
 
+// NewWithEngine starts a chain at the genesis block of params that engine
+// produces and validates blocks for.
+func NewWithEngine(params *chainparams.Params, engine consensus.Engine) *BlockChain {
+	return &BlockChain{
+		BlockList:    []*block.Block{params.GenesisBlock()},
+		Params:       params,
+		MinerAddress: MinerAddress,
+		engine:       engine,
+		events:       events.NewHub(),
+	}
+}
+
+func (c *BlockChain) Engine() consensus.Engine {
+	return c.engine
+}
+
+func (c *BlockChain) Events() *events.Hub {
+	return c.events
+}
+
+type ConfirmedTransaction struct {
+	Transaction *transaction.Transaction `json:"transaction"`
+	BlockIndex  int64                    `json:"blockIndex"`
+	BlockHash   string                   `json:"blockHash"`
+}
+
+type Finalization struct {
+	Height int64  `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+type Reorganization struct {
+	ForkHeight int64  `json:"forkHeight"`
+	OldTip     string `json:"oldTip"`
+	NewTip     string `json:"newTip"`
+}
+
+type ChainInfo struct {
+	Height              int64  `json:"height"`
+	TipHash             string `json:"tipHash"`
+	GenesisHash         string `json:"genesisHash"`
+	Difficulty          int    `json:"difficulty"`
+	Consensus           string `json:"consensus"`
+	FinalizedHeight     int64  `json:"finalizedHeight"`
+	TransactionPoolSize int    `json:"transactionPoolSize"`
+}
+
+// GetTransactionPool returns a copy of the pool.
 func (c *BlockChain) GetTransactionPool() []*transaction.Transaction {
-	return c.TransactionPool
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return append([]*transaction.Transaction{}, c.TransactionPool...)
 }
 
-func (c *BlockChain) Mining() {
+// Blocks returns a copy of the block list, safe to read while the chain
+// grows.
+func (c *BlockChain) Blocks() []*block.Block {
 	c.lock.Lock()
-	difficulty := c.GetDifficulty()
-	fmt.Println("difficulty " + "----<> " + strings.Repeat("0", difficulty))
 	defer c.lock.Unlock()
 
-	fmt.Println("mining start")
+	return append([]*block.Block{}, c.BlockList...)
+}
+
+func (c *BlockChain) BlockByHeight(height int64) *block.Block {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.blockByHeight(height)
+}
+
+func (c *BlockChain) blockByHeight(height int64) *block.Block {
+	for _, b := range c.BlockList {
+		if b.Index == height {
+			return b
+		}
+	}
+
+	return nil
+}
+
+func (c *BlockChain) Info() ChainInfo {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	tip := c.BlockList[len(c.BlockList)-1]
+
+	return ChainInfo{
+		Height:              tip.Index,
+		TipHash:             tip.Hash,
+		GenesisHash:         c.BlockList[0].Hash,
+		Difficulty:          tip.Difficulty,
+		Consensus:           c.engine.Name(),
+		FinalizedHeight:     c.finalizedHeight,
+		TransactionPoolSize: len(c.TransactionPool),
+	}
+}
+
+// Mining produces the next block with the consensus engine. It returns
+// consensus.ErrNotProducer while another node is due to produce it.
+func (c *BlockChain) Mining() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	b, err := c.nextBlock()
+
+	if err != nil {
+		return err
+	}
+
+	// Our own block at a checkpoint height is only kept when it is the
+	// checkpointed block; otherwise the node waits for a peer's chain.
+	if err := c.verifyCheckpoint(b); err != nil {
+		return err
+	}
 
-	now := time.Now().UnixMilli()
-	nonce := int64(0)
+	if err := c.engine.VerifyBlock(c.BlockList, b); err != nil {
+		return err
+	}
+
+	c.BlockList = append(c.BlockList, b)
+
+	c.TransactionPool = []*transaction.Transaction{}
+	c.publishBlock(b)
+	return nil
+}
+
+// NextBlock produces the block that would follow the tip, holding the
+// whole transaction pool, without adding it. The Raft leader proposes it
+// to the cluster, which adds it through AppendBlock.
+func (c *BlockChain) NextBlock() (*block.Block, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.nextBlock()
+}
+
+func (c *BlockChain) nextBlock() (*block.Block, error) {
 	prevBlock := c.BlockList[len(c.BlockList)-1]
+	b := &block.Block{
+		Index:     prevBlock.Index + 1,
+		TimeStamp: time.Now().UnixMilli(),
+		PrevHash:  prevBlock.Hash,
+		Producer:  c.MinerAddress,
+	}
 
-	h := c.CalculateHash(prevBlock.Index+1, now, prevBlock.Hash, c.TransactionPool, nonce, difficulty)
-	c.AddTransaction(BlockChainCore, MinerAddress, MiningReward, nil, nil)
+	if err := c.engine.Prepare(c.BlockList, b); err != nil {
+		return nil, err
+	}
 
-	for {
-		if strings.HasPrefix(h, strings.Repeat("0", difficulty)) {
-			fmt.Println(fmt.Sprintf("found, %s", h))
-			break
+	if err := c.prunePool(b); err != nil {
+		return nil, err
+	}
+
+	b.Data = append([]*transaction.Transaction{}, c.TransactionPool...)
+	b.Data = append(b.Data, c.engine.Rewards(c.BlockList, b)...)
+
+	if err := c.engine.Seal(c.BlockList, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// prunePool drops the pooled transactions the tip no longer allows in b,
+// such as a transfer from a sender that spent its balance in a block from a
+// peer, or an unstake after a slashing. The error names what was dropped;
+// the rest of the pool waits for the next block.
+func (c *BlockChain) prunePool(b *block.Block) error {
+	staking, stakes := c.engine.(consensus.Staking)
+	amounts := balances(c.BlockList)
+	used := usedNonces(transactionsOf(c.BlockList))
+
+	slashed := make(map[string]bool)
+	for _, ev := range b.Evidence {
+		slashed[ev.First.Producer] = true
+	}
+
+	// staked follows the stake of each address through the pool, starting
+	// from the chain the first time the address comes up.
+	staked := make(map[string]float32)
+	stake := func(address string) float32 {
+		if _, ok := staked[address]; !ok && !slashed[address] {
+			staked[address] = staking.Stake(c.BlockList, address)
+		}
+
+		return staked[address]
+	}
+
+	// locked follows what each address may not spend yet, growing with
+	// the unstakes kept.
+	locked := make(map[string]float32)
+	lock := func(address string) float32 {
+		if _, ok := locked[address]; !ok {
+			locked[address] = c.locked(c.BlockList, address)
 		}
 
-		nonce++
-		h = c.CalculateHash(prevBlock.Index+1, now, prevBlock.Hash, c.TransactionPool, nonce, difficulty)
+		return locked[address]
 	}
 
-	fmt.Println("mining end")
+	var kept []*transaction.Transaction
+	var dropped []string
+	for _, t := range c.TransactionPool {
+		sender, recipient, value := t.GetSenderAddress(), t.GetRecipientAddress(), t.GetValue()
+
+		var reason string
+		switch {
+		case used[signer(t)][t.GetNonce()]:
+			reason = fmt.Sprintf("nonce %d is used", t.GetNonce())
+		case amounts[sender]-lock(sender) < value:
+			reason = fmt.Sprintf("%s has %.1f, %.1f of it unbonding", sender, amounts[sender], lock(sender))
+		case sender == chainparams.StakeAddress && (!stakes || stake(recipient) < value):
+			reason = fmt.Sprintf("%s has too little staked", recipient)
+		}
+
+		if reason != "" {
+			dropped = append(dropped, fmt.Sprintf("%s sends %.1f to %s (%s)", sender, value, recipient, reason))
+			continue
+		}
+
+		if stakes && recipient == chainparams.StakeAddress {
+			staked[sender] = stake(sender) + value
+		}
+
+		if stakes && sender == chainparams.StakeAddress {
+			staked[recipient] = stake(recipient) - value
+			locked[recipient] = lock(recipient) + value
+		}
+
+		useNonce(used, t)
+		amounts[sender] -= value
+		amounts[recipient] += value
+		kept = append(kept, t)
+	}
+
+	if len(dropped) == 0 {
+		return nil
+	}
+
+	c.TransactionPool = kept
+	return fmt.Errorf("%w: dropped from the pool: %s", ErrInvalidTransaction, strings.Join(dropped, "; "))
+}
+
+// AppendBlock adds b, produced elsewhere, on top of the tip and drops its
+// transactions from the pool. Unlike ReplaceChain it never reorganizes.
+func (c *BlockChain) AppendBlock(b *block.Block) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	tip := c.BlockList[len(c.BlockList)-1]
+
+	if b.Index != tip.Index+1 || b.PrevHash != tip.Hash {
+		return fmt.Errorf("%w: block %d does not follow block %d", ErrInvalidChain, b.Index, tip.Index)
+	}
+
+	if err := c.verifyCheckpoint(b); err != nil {
+		return err
+	}
+
+	if err := c.engine.VerifyBlock(c.BlockList, b); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChain, err)
+	}
+
+	if err := c.verifyTransactions(c.BlockList, balances(c.BlockList), usedNonces(transactionsOf(c.BlockList)), b, true); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChain, err)
+	}
+
+	c.BlockList = append(c.BlockList, b)
+	c.dropFromPool(b.Data)
+	c.publishBlock(b)
+	return nil
+}
 
-	c.BlockList = append(c.BlockList, &block.Block{
-		Index:      prevBlock.Index + 1,
-		TimeStamp:  now,
-		PrevHash:   prevBlock.Hash,
-		Hash:       h,
-		Data:       c.TransactionPool,
-		Nonce:      nonce,
-		Difficulty: difficulty,
+// dropFromPool removes one pooled transaction for each of transactions.
+func (c *BlockChain) dropFromPool(transactions []*transaction.Transaction) {
+	for _, t := range transactions {
+		for i, pooled := range c.TransactionPool {
+			if pooled.GetSenderAddress() == t.GetSenderAddress() &&
+				pooled.GetRecipientAddress() == t.GetRecipientAddress() &&
+				pooled.GetValue() == t.GetValue() &&
+				pooled.GetNonce() == t.GetNonce() {
+				c.TransactionPool = append(c.TransactionPool[:i:i], c.TransactionPool[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+func transactionsOf(blocks []*block.Block) []*transaction.Transaction {
+	var transactions []*transaction.Transaction
+	for _, b := range blocks {
+		transactions = append(transactions, b.Data...)
+	}
+
+	return transactions
+}
+
+// ReplaceChain switches to blocks when they share our genesis, are valid
+// and win the engine's fork choice. Transactions the adopted blocks include
+// leave the pool, and subscribers get a reorg event when blocks of the
+// current chain are dropped.
+func (c *BlockChain) ReplaceChain(blocks []*block.Block) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if len(blocks) == 0 || blocks[0].Hash != c.BlockList[0].Hash {
+		return ErrGenesisMismatch
+	}
+
+	if f := c.finalizedHeight; int64(len(blocks)) <= f || blocks[f].Hash != c.BlockList[f].Hash {
+		return fmt.Errorf("%w: block %d", ErrFinalizedConflict, f)
+	}
+
+	if collector, ok := c.engine.(consensus.EvidenceCollector); ok {
+		collector.Observe(c.BlockList, blocks)
+	}
+
+	if !c.engine.Better(c.BlockList, blocks) {
+		return ErrChainNotLonger
+	}
+
+	if err := c.verifyChain(blocks); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChain, err)
+	}
+
+	forkIndex := 0
+	for forkIndex < len(c.BlockList) && c.BlockList[forkIndex].Hash == blocks[forkIndex].Hash {
+		forkIndex++
+	}
+
+	oldLength := len(c.BlockList)
+	oldTip := c.BlockList[oldLength-1]
+	c.BlockList = blocks
+	c.dropFromPool(transactionsOf(blocks[forkIndex:]))
+
+	if forkIndex < oldLength {
+		c.events.Publish(&events.Event{
+			Type: events.Reorg,
+			Data: &Reorganization{
+				ForkHeight: blocks[forkIndex].Index,
+				OldTip:     oldTip.Hash,
+				NewTip:     blocks[len(blocks)-1].Hash,
+			},
+		})
+	}
+
+	for _, b := range blocks[forkIndex:] {
+		c.publishBlock(b)
+	}
+
+	return nil
+}
+
+func (c *BlockChain) FinalizedHeight() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.finalizedHeight
+}
+
+// Finalize marks the block at height, and so every block before it, as
+// final. ReplaceChain never drops a final block.
+func (c *BlockChain) Finalize(height int64, hash string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if height <= c.finalizedHeight {
+		return nil
+	}
+
+	if b := c.blockByHeight(height); b == nil || b.Hash != hash {
+		return fmt.Errorf("%w: %s at height %d", ErrBlockNotInChain, hash, height)
+	}
+
+	c.finalizedHeight = height
+	c.events.Publish(&events.Event{
+		Type: events.BlockFinalized,
+		Data: &Finalization{Height: height, Hash: hash},
 	})
+	return nil
+}
 
-	c.TransactionPool = []*transaction.Transaction{}
+// BlockView is a block as the API shows it, with its finality status.
+type BlockView struct {
+	*block.Block
+	Final bool
+}
+
+func (c *BlockChain) View(b *block.Block) *BlockView {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.view(b)
+}
+
+func (c *BlockChain) view(b *block.Block) *BlockView {
+	return &BlockView{Block: b, Final: b.Index <= c.finalizedHeight}
+}
+
+// MarshalJSON adds "final" to the block's own JSON; peers decoding the
+// block ignore it.
+func (v *BlockView) MarshalJSON() ([]byte, error) {
+	m, err := json.Marshal(v.Block)
+
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+
+	if err := json.Unmarshal(m, &fields); err != nil {
+		return nil, err
+	}
+
+	if fields["final"], err = json.Marshal(v.Final); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(fields)
+}
+
+func (c *BlockChain) publishBlock(b *block.Block) {
+	var addresses []string
+	for _, t := range b.Data {
+		addresses = append(addresses, t.GetSenderAddress(), t.GetRecipientAddress())
+	}
+
+	c.events.Publish(&events.Event{
+		Type:      events.BlockMined,
+		Addresses: addresses,
+		Data:      b,
+	})
+
+	for _, t := range b.Data {
+		c.events.Publish(&events.Event{
+			Type:      events.TransactionConfirmed,
+			Addresses: []string{t.GetSenderAddress(), t.GetRecipientAddress()},
+			Data: &ConfirmedTransaction{
+				Transaction: t,
+				BlockIndex:  b.Index,
+				BlockHash:   b.Hash,
+			},
+		})
+	}
 }
 
+// RecursiveMiner keeps producing blocks. While the engine reports that
+// another node is due, it waits and tries again; other errors are logged
+// once until they change.
 func (c *BlockChain) RecursiveMiner() {
-	c.Mining()
-	c.RecursiveMiner()
+	var last error
+	for {
+		err := c.Mining()
+
+		if err != nil && !errors.Is(err, consensus.ErrNotProducer) && (last == nil || err.Error() != last.Error()) {
+			log.Println("mining:", err)
+		}
+		last = err
+
+		if err != nil {
+			time.Sleep(consensus.RetryInterval)
+		}
+	}
+}
+
+func (c *BlockChain) CreateTransaction(sender string, recipient string, value float32, nonce uint64, senderPublicKey signaturescheme.PublicKey, signature *utils.Signature) error {
+	return c.AddTransaction(sender, recipient, value, nonce, senderPublicKey, signature)
 }
 
-func (c *BlockChain) GetDifficulty() int {
-	if c.BlockList[len(c.BlockList)-1].Index%DifficultyAdjustmentIntervalBlockCount == 0 && c.BlockList[len(c.BlockList)-1].Index != 0 {
-		fmt.Printf("%s difficulty adjustment started\n", strings.Repeat("=", 25))
+// AddTransaction puts a transaction into the pool, or reports why it was
+// rejected.
+func (c *BlockChain) AddTransaction(sender string, recipient string, value float32, nonce uint64, senderPublicKey signaturescheme.PublicKey, signature *utils.Signature) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if sender == chainparams.StakeAddress || recipient == chainparams.StakeAddress {
+		return c.addStakingTransaction(sender, recipient, value, nonce, senderPublicKey, signature)
+	}
+
+	t := transaction.New(sender, recipient, value).WithNonce(nonce)
+	canonicalSender, err := wallet.CanonicalAddress(sender, c.Params)
+
+	if err != nil {
+		return fmt.Errorf("sender: %w", err)
+	}
+
+	canonicalRecipient, err := wallet.CanonicalAddress(recipient, c.Params)
+
+	if err != nil {
+		return fmt.Errorf("recipient: %w", err)
+	}
+
+	// The signature covers the addresses as the sender spelled them; only
+	// the pooled copy is rewritten to the canonical form.
+	if !c.VerifyTransactionSignature(senderPublicKey, signature, t) {
+		return ErrInvalidSignature
+	}
 
-		return c.AdjustDifficulty()
+	if wallet.AddressFromPublicKey(senderPublicKey, c.Params) != canonicalSender {
+		return fmt.Errorf("%w: public key does not own %s", ErrInvalidSignature, canonicalSender)
 	}
 
-	fmt.Printf("%s difficulty adjustment conditions did not occur yet \n", strings.Repeat("=", 25))
-	return c.BlockList[len(c.BlockList)-1].Difficulty
+	w := spelledWitness(sender, recipient, canonicalSender, canonicalRecipient)
+	w.PublicKey = signaturescheme.FormatPublicKey(senderPublicKey)
+	w.Signature = signature.String()
+	return c.acceptTransaction(transaction.New(canonicalSender, canonicalRecipient, value).WithNonce(nonce).WithWitness(w))
 }
 
-func (c *BlockChain) AdjustDifficulty() int {
-	var expectedProcessTime int64
-	prevAdjustmentBlock := c.BlockList[(len(c.BlockList))-DifficultyAdjustmentIntervalBlockCount]
-	expectedProcessTime = DifficultyAdjustmentIntervalBlockCount * ExpectedMiningProcessIntervalMillisecond
-	timeTaken := c.BlockList[len(c.BlockList)-1].TimeStamp - prevAdjustmentBlock.TimeStamp
+// AddMultisigTransaction pools a transaction spent from a multisig address.
+// policy must hash to the sender address, and at least policy.Threshold of
+// its keys must have signed.
+func (c *BlockChain) AddMultisigTransaction(sender string, recipient string, value float32, nonce uint64, policy *wallet.MultisigPolicy, signatures []wallet.PartialSignature) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	canonicalSender, err := wallet.CanonicalAddress(sender, c.Params)
+
+	if err != nil {
+		return fmt.Errorf("sender: %w", err)
+	}
+
+	canonicalRecipient, err := wallet.CanonicalAddress(recipient, c.Params)
+
+	if err != nil {
+		return fmt.Errorf("recipient: %w", err)
+	}
+
+	if policy == nil || policy.Address(c.Params) != canonicalSender {
+		return fmt.Errorf("%w: multisig policy does not match %s", ErrInvalidSignature, canonicalSender)
+	}
+
+	m, err := json.Marshal(transaction.New(sender, recipient, value).WithNonce(nonce))
 
-	fmt.Println("prevAdjustmentBlock" + "----<> " + strconv.FormatInt(prevAdjustmentBlock.Index, 10))
-	fmt.Println("prevAdjustmentBlock - Difficulty " + "----<>" + strconv.FormatInt(int64(prevAdjustmentBlock.Difficulty), 10))
-	fmt.Println("lastBLock" + "----<> " + strconv.FormatInt(c.BlockList[len(c.BlockList)-1].Index, 10))
-	fmt.Println("time taken" + "----<> " + strconv.FormatInt(timeTaken, 10))
+	if err != nil {
+		return err
+	}
 
-	if timeTaken < expectedProcessTime/2 {
-		fmt.Printf("%s difficulty adjusted as %d \n", strings.Repeat("=", 25), prevAdjustmentBlock.Difficulty+1)
-		return prevAdjustmentBlock.Difficulty + 1
-	} else if timeTaken > expectedProcessTime*2 {
-		fmt.Printf("%s difficulty adjusted as %d \n", strings.Repeat("=", 25), prevAdjustmentBlock.Difficulty-1)
-		return prevAdjustmentBlock.Difficulty - 1
+	digest := sha256.Sum256(m)
+
+	if err := policy.Verify(digest[:], signatures); err != nil {
+		return err
 	}
 
-	fmt.Printf("%s difficulty adjustment no necessary \n", strings.Repeat("=", 25))
-	return prevAdjustmentBlock.Difficulty
+	w := spelledWitness(sender, recipient, canonicalSender, canonicalRecipient)
+
+	if w.Multisig, err = json.Marshal(policy); err != nil {
+		return err
+	}
+
+	if w.Signatures, err = json.Marshal(signatures); err != nil {
+		return err
+	}
+
+	return c.acceptTransaction(transaction.New(canonicalSender, canonicalRecipient, value).WithNonce(nonce).WithWitness(w))
 }
 
-func (c *BlockChain) CreateTransaction(sender string, recipient string, value float32, senderPublicKey *ecdsa.PublicKey, signature *utils.Signature) bool {
-	return c.AddTransaction(sender, recipient, value, senderPublicKey, signature)
+// spelledWitness starts the witness of a transaction, keeping the addresses
+// as the signer spelled them when that is not the canonical form.
+func spelledWitness(sender string, recipient string, canonicalSender string, canonicalRecipient string) *transaction.Witness {
+	w := &transaction.Witness{}
+
+	if sender != canonicalSender {
+		w.Sender = sender
+	}
+
+	if recipient != canonicalRecipient {
+		w.Recipient = recipient
+	}
+
+	return w
 }
-func (c *BlockChain) AddTransaction(sender string, recipient string, value float32, senderPublicKey *ecdsa.PublicKey, signature *utils.Signature) bool {
-	t := transaction.New(sender, recipient, value)
 
-	if sender == BlockChainCore {
-		c.TransactionPool = append(c.TransactionPool, t)
-		return true
+// addStakingTransaction pools a stake, a transfer to StakeAddress signed by
+// the sender, or an unstake, a transfer from StakeAddress signed by the
+// staker it pays back.
+func (c *BlockChain) addStakingTransaction(sender string, recipient string, value float32, nonce uint64, senderPublicKey signaturescheme.PublicKey, signature *utils.Signature) error {
+	staking, ok := c.engine.(consensus.Staking)
+
+	if !ok {
+		return fmt.Errorf("%w: %s does not stake", ErrStakingNotSupported, c.engine.Name())
+	}
+
+	if sender == recipient {
+		return fmt.Errorf("%w: %s cannot send to itself", ErrInvalidTransactionRequest, sender)
+	}
+
+	staker := sender
+	if sender == chainparams.StakeAddress {
+		staker = recipient
+	}
+
+	canonicalStaker, err := wallet.CanonicalAddress(staker, c.Params)
+
+	if err != nil {
+		return fmt.Errorf("staker: %w", err)
+	}
+
+	if !c.VerifyTransactionSignature(senderPublicKey, signature, transaction.New(sender, recipient, value).WithNonce(nonce)) {
+		return ErrInvalidSignature
+	}
+
+	if wallet.AddressFromPublicKey(senderPublicKey, c.Params) != canonicalStaker {
+		return fmt.Errorf("%w: public key does not own %s", ErrInvalidSignature, canonicalStaker)
+	}
+
+	var w *transaction.Witness
+	if sender == chainparams.StakeAddress {
+		w = spelledWitness(sender, recipient, sender, canonicalStaker)
+	} else {
+		w = spelledWitness(sender, recipient, canonicalStaker, recipient)
+	}
+
+	w.PublicKey = signaturescheme.FormatPublicKey(senderPublicKey)
+	w.Signature = signature.String()
+
+	if sender != chainparams.StakeAddress {
+		return c.acceptTransaction(transaction.New(canonicalStaker, chainparams.StakeAddress, value).WithNonce(nonce).WithWitness(w))
+	}
+
+	t := transaction.New(chainparams.StakeAddress, canonicalStaker, value).WithNonce(nonce).WithWitness(w)
+
+	if err := c.checkNonce(t); err != nil {
+		return err
 	}
 
-	if c.VerifyTransactionSignature(senderPublicKey, signature, t) {
-		if c.CalculateTotalAmount(sender) < value {
-			fmt.Println("ERROR: Not enough balance in a wallet")
-			return false
+	stake := staking.Stake(c.BlockList, canonicalStaker)
+	for _, t := range c.TransactionPool {
+		if t.GetSenderAddress() == chainparams.StakeAddress && t.GetRecipientAddress() == canonicalStaker {
+			stake -= t.GetValue()
+		}
+	}
+
+	if stake < value {
+		return fmt.Errorf("%w: %s has %.1f staked, unstakes %.1f", ErrInsufficientStake, canonicalStaker, stake, value)
+	}
+
+	c.poolTransaction(t)
+	return nil
+}
+
+// ReportEvidence queues proof that a producer signed two blocks for one
+// slot, for engines that slash for it.
+func (c *BlockChain) ReportEvidence(evidence *block.Evidence) error {
+	collector, ok := c.engine.(consensus.EvidenceCollector)
+
+	if !ok {
+		return fmt.Errorf("%w: %s does not slash", ErrStakingNotSupported, c.engine.Name())
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return collector.ReportEvidence(c.BlockList, evidence)
+}
+
+func (c *BlockChain) acceptTransaction(t *transaction.Transaction) error {
+	if err := c.checkNonce(t); err != nil {
+		return err
+	}
+
+	balance := c.calculateTotalAmount(t.GetSenderAddress())
+	locked := c.locked(c.BlockList, t.GetSenderAddress())
+
+	if balance-locked < t.GetValue() {
+		return fmt.Errorf("%w: %s has %.1f, %.1f of it unbonding, needs %.1f", ErrInsufficientBalance, t.GetSenderAddress(), balance, locked, t.GetValue())
+	}
+
+	c.poolTransaction(t)
+	return nil
+}
+
+// checkNonce refuses t when its signer already used its nonce in a block or
+// in the pool, such as when a transfer copied from a block is sent again.
+func (c *BlockChain) checkNonce(t *transaction.Transaction) error {
+	if t.GetNonce() == 0 {
+		return fmt.Errorf("%w: transaction from %s has no nonce", ErrInvalidTransaction, signer(t))
+	}
+
+	used := usedNonces(append(transactionsOf(c.BlockList), c.TransactionPool...))
+
+	if used[signer(t)][t.GetNonce()] {
+		return fmt.Errorf("%w: %s already used nonce %d", ErrNonceUsed, signer(t), t.GetNonce())
+	}
+
+	return nil
+}
+
+// NextNonce returns a nonce address has not used yet: one above the highest
+// it used in a block or in the pool.
+func (c *BlockChain) NextNonce(address string) uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	address = c.CanonicalAddress(address)
+
+	var highest uint64
+	for _, t := range append(transactionsOf(c.BlockList), c.TransactionPool...) {
+		if signer(t) == address && t.GetNonce() > highest {
+			highest = t.GetNonce()
 		}
+	}
+
+	return highest + 1
+}
+
+// signer returns the address whose key authorizes t: the sender, or for an
+// unstake the staker it pays back. Nonces are counted per signer.
+func signer(t *transaction.Transaction) string {
+	if t.GetSenderAddress() == chainparams.StakeAddress {
+		return t.GetRecipientAddress()
+	}
+
+	return t.GetSenderAddress()
+}
+
+// usedNonces collects the nonces each signer used in transactions.
+func usedNonces(transactions []*transaction.Transaction) map[string]map[uint64]bool {
+	used := make(map[string]map[uint64]bool)
+	for _, t := range transactions {
+		useNonce(used, t)
+	}
 
-		c.TransactionPool = append(c.TransactionPool, t)
+	return used
+}
+
+// useNonce records the nonce of t, reporting false when its signer had
+// used it already. Unsigned transactions, which have none, are skipped.
+func useNonce(used map[string]map[uint64]bool, t *transaction.Transaction) bool {
+	if t.GetNonce() == 0 {
 		return true
 	}
 
-	fmt.Println("ERROR: Verify Transaction")
-	return false
+	if used[signer(t)] == nil {
+		used[signer(t)] = make(map[uint64]bool)
+	}
+
+	if used[signer(t)][t.GetNonce()] {
+		return false
+	}
+
+	used[signer(t)][t.GetNonce()] = true
+	return true
 }
 
-func (c *BlockChain) CalculateHash(index int64, timeStamp int64, prevHash string, data []*transaction.Transaction, nonce int64, diff int) string {
-	return hash.CalculateHash(fmt.Sprintf("%d-%s-%s-%s-%d-%s", index, timeStamp, prevHash, data, nonce, strings.Repeat("0", diff)))
+func (c *BlockChain) poolTransaction(t *transaction.Transaction) {
+	c.TransactionPool = append(c.TransactionPool, t)
+	c.events.Publish(&events.Event{
+		Type:      events.TransactionAccepted,
+		Addresses: []string{t.GetSenderAddress(), t.GetRecipientAddress()},
+		Data:      t,
+	})
 }
 
-func (c *BlockChain) VerifyTransactionSignature(senderPublicKey *ecdsa.PublicKey, signature *utils.Signature, transaction *transaction.Transaction) bool {
+func (c *BlockChain) VerifyTransactionSignature(senderPublicKey signaturescheme.PublicKey, signature *utils.Signature, transaction *transaction.Transaction) bool {
+	if senderPublicKey == nil {
+		return false
+	}
+
 	m, err := json.Marshal(transaction)
 
 	if err != nil {
@@ -178,27 +856,230 @@ func (c *BlockChain) VerifyTransactionSignature(senderPublicKey *ecdsa.PublicKey
 		return false
 	}
 
-	return ecdsa.Verify(senderPublicKey, h[:], signature.R, signature.S)
+	return signaturescheme.Verify(senderPublicKey, h[:], signature)
 }
 
-func (c *BlockChain) Validate() bool {
-	for i := 1; i < len(c.BlockList); i++ {
-		if c.BlockList[i].PrevHash != c.BlockList[i-1].Hash {
-			fmt.Println("chain is not valid")
-			return false
+// verifyWitness checks the signature a block carries for t the way
+// AddTransaction, AddMultisigTransaction and addStakingTransaction checked
+// it before pooling.
+func (c *BlockChain) verifyWitness(t *transaction.Transaction) error {
+	w := t.GetWitness()
+
+	if w == nil {
+		return fmt.Errorf("%w: transaction from %s is not signed", ErrInvalidSignature, t.GetSenderAddress())
+	}
+
+	signed := t.Signed()
+
+	if c.CanonicalAddress(signed.GetSenderAddress()) != t.GetSenderAddress() ||
+		c.CanonicalAddress(signed.GetRecipientAddress()) != t.GetRecipientAddress() {
+		return fmt.Errorf("%w: witness addresses do not match the transaction", ErrInvalidSignature)
+	}
+
+	m, err := json.Marshal(signed)
+
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(m)
+
+	if w.Multisig != nil {
+		policy := &wallet.MultisigPolicy{}
+
+		if err := json.Unmarshal(w.Multisig, policy); err != nil {
+			return fmt.Errorf("%w: multisig: %v", ErrInvalidSignature, err)
+		}
+
+		var signatures []wallet.PartialSignature
+
+		if err := json.Unmarshal(w.Signatures, &signatures); err != nil {
+			return fmt.Errorf("%w: signatures: %v", ErrInvalidSignature, err)
+		}
+
+		if policy.Address(c.Params) != t.GetSenderAddress() {
+			return fmt.Errorf("%w: multisig policy does not match %s", ErrInvalidSignature, t.GetSenderAddress())
+		}
+
+		if err := policy.Verify(digest[:], signatures); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+		}
+
+		return nil
+	}
+
+	publicKey, err := signaturescheme.ParseFormattedPublicKey(w.PublicKey)
+
+	if err != nil {
+		return fmt.Errorf("%w: public key: %v", ErrInvalidSignature, err)
+	}
+
+	signature, err := utils.SignatureFromString(w.Signature)
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	if !signaturescheme.Verify(publicKey, digest[:], signature) {
+		return fmt.Errorf("%w: transaction from %s", ErrInvalidSignature, t.GetSenderAddress())
+	}
+
+	if owner := signer(t); wallet.AddressFromPublicKey(publicKey, c.Params) != owner {
+		return fmt.Errorf("%w: public key does not own %s", ErrInvalidSignature, owner)
+	}
+
+	return nil
+}
+
+// verifyTransactions replays the transfers of b, on top of chain, on
+// balances and nonces. Every sender but the engine, whose rewards it checks
+// itself, must hold what it sends outside of what is unbonding, must not
+// have used the nonce before and, when signatures is set, must have signed
+// it.
+func (c *BlockChain) verifyTransactions(chain []*block.Block, balances map[string]float32, nonces map[string]map[uint64]bool, b *block.Block, signatures bool) error {
+	_, staking := c.engine.(consensus.Staking)
+
+	locked := make(map[string]float32)
+	lock := func(address string) float32 {
+		if _, ok := locked[address]; !ok {
+			locked[address] = c.locked(chain, address)
+		}
+
+		return locked[address]
+	}
+
+	for _, t := range b.Data {
+		sender, recipient, value := t.GetSenderAddress(), t.GetRecipientAddress(), t.GetValue()
+
+		if sender != consensus.RewardSender {
+			if sender == chainparams.GenesisSender || value <= 0 {
+				return fmt.Errorf("%w: block %d sends %.8f from %s", ErrInvalidTransaction, b.Index, value, sender)
+			}
+
+			if !staking && (sender == chainparams.StakeAddress || recipient == chainparams.StakeAddress) {
+				return fmt.Errorf("%w: block %d stakes, %s does not", ErrInvalidTransaction, b.Index, c.engine.Name())
+			}
+
+			if balances[sender]-lock(sender) < value {
+				return fmt.Errorf("%w: block %d sends %.8f from %s, which has %.8f, %.8f of it unbonding", ErrInsufficientBalance, b.Index, value, sender, balances[sender], lock(sender))
+			}
+
+			if t.GetNonce() == 0 {
+				return fmt.Errorf("%w: block %d sends from %s without a nonce", ErrInvalidTransaction, b.Index, sender)
+			}
+
+			if !useNonce(nonces, t) {
+				return fmt.Errorf("%w: block %d uses nonce %d of %s again", ErrNonceUsed, b.Index, t.GetNonce(), signer(t))
+			}
+
+			if signatures {
+				if err := c.verifyWitness(t); err != nil {
+					return fmt.Errorf("block %d: %w", b.Index, err)
+				}
+			}
+		}
+
+		if sender == chainparams.StakeAddress {
+			locked[recipient] = lock(recipient) + value
 		}
 
-		if c.BlockList[i].Hash != c.CalculateHash(c.BlockList[i].Index, c.BlockList[i].TimeStamp, c.BlockList[i].PrevHash, c.BlockList[i].Data, c.BlockList[i].Nonce, c.BlockList[i].Difficulty) {
-			fmt.Println("chain is not valid")
-			return false
+		balances[sender] -= value
+		balances[recipient] += value
+	}
+
+	return nil
+}
+
+// locked returns what address may not spend in the block on top of chain.
+// Only staking engines lock anything.
+func (c *BlockChain) locked(chain []*block.Block, address string) float32 {
+	if staking, ok := c.engine.(consensus.Staking); ok {
+		return staking.Locked(chain, address)
+	}
+
+	return 0
+}
+
+// balances sums what each address holds at the tip of blocks, the way
+// CalculateTotalAmount does for one address.
+func balances(blocks []*block.Block) map[string]float32 {
+	amounts := make(map[string]float32)
+	for _, b := range blocks {
+		for _, t := range b.Data {
+			amounts[t.GetSenderAddress()] -= t.GetValue()
+			amounts[t.GetRecipientAddress()] += t.GetValue()
 		}
 	}
 
+	return amounts
+}
+
+func (c *BlockChain) Validate() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := c.verifyChain(c.BlockList); err != nil {
+		fmt.Println("chain is not valid:", err)
+		return false
+	}
+
 	fmt.Println("chain is valid")
 	return true
 }
 
+// verifyChain checks blocks from genesis. Up to the assume-valid block,
+// when blocks hold it, transaction signatures and, for engines that sign
+// blocks, producer signatures are trusted; linkage, hashes, balances and
+// the other consensus rules are checked all the same.
+func (c *BlockChain) verifyChain(blocks []*block.Block) error {
+	skipper, skips := c.engine.(consensus.SignatureSkipper)
+
+	assumedValid := 0
+	for i, b := range blocks {
+		if c.Params.AssumeValid != "" && b.Hash == c.Params.AssumeValid {
+			assumedValid = i
+		}
+	}
+
+	amounts := balances(blocks[:1])
+	nonces := usedNonces(blocks[0].Data)
+	for i := 1; i < len(blocks); i++ {
+		if blocks[i].Index != blocks[i-1].Index+1 || blocks[i].PrevHash != blocks[i-1].Hash {
+			return fmt.Errorf("block %d does not follow block %d", blocks[i].Index, blocks[i-1].Index)
+		}
+
+		if err := c.verifyCheckpoint(blocks[i]); err != nil {
+			return err
+		}
+
+		if skips && i <= assumedValid {
+			if err := skipper.VerifyBlockWithoutSignatures(blocks[:i], blocks[i]); err != nil {
+				return err
+			}
+		} else if err := c.engine.VerifyBlock(blocks[:i], blocks[i]); err != nil {
+			return err
+		}
+
+		if err := c.verifyTransactions(blocks[:i], amounts, nonces, blocks[i], i > assumedValid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *BlockChain) verifyCheckpoint(b *block.Block) error {
+	if hash, ok := c.Params.Checkpoint(b.Index); ok && b.Hash != hash {
+		return fmt.Errorf("%w: block %d is %s, checkpoint is %s", ErrCheckpointMismatch, b.Index, b.Hash, hash)
+	}
+
+	return nil
+}
+
 func (c *BlockChain) Print() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
 	for i, b := range c.BlockList {
 		fmt.Printf("%s Block %d %s\n", strings.Repeat("=", 25), i,
 			strings.Repeat("=", 25))
@@ -208,6 +1089,15 @@ func (c *BlockChain) Print() {
 }
 
 func (c *BlockChain) CalculateTotalAmount(blockchainAddress string) float32 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.calculateTotalAmount(blockchainAddress)
+}
+
+func (c *BlockChain) calculateTotalAmount(blockchainAddress string) float32 {
+	blockchainAddress = c.CanonicalAddress(blockchainAddress)
+
 	var totalAmount float32 = 0.0
 	for _, b := range c.BlockList {
 		for _, t := range b.Data {
@@ -224,10 +1114,81 @@ func (c *BlockChain) CalculateTotalAmount(blockchainAddress string) float32 {
 	return totalAmount
 }
 
+func (c *BlockChain) IsAddressUsed(blockchainAddress string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	blockchainAddress = c.CanonicalAddress(blockchainAddress)
+
+	for _, b := range c.BlockList {
+		for _, t := range b.Data {
+			if blockchainAddress == t.GetSenderAddress() || blockchainAddress == t.GetRecipientAddress() {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// HistoryEntry is one confirmed transaction that touches an address.
+type HistoryEntry struct {
+	BlockIndex  int64                    `json:"blockIndex"`
+	BlockHash   string                   `json:"blockHash"`
+	Timestamp   int64                    `json:"timestamp"`
+	Transaction *transaction.Transaction `json:"transaction"`
+}
+
+// AddressHistory lists the confirmed transactions sent from or to an
+// address, oldest first.
+func (c *BlockChain) AddressHistory(blockchainAddress string) []HistoryEntry {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	blockchainAddress = c.CanonicalAddress(blockchainAddress)
+
+	history := []HistoryEntry{}
+	for _, b := range c.BlockList {
+		for _, t := range b.Data {
+			if blockchainAddress == t.GetSenderAddress() || blockchainAddress == t.GetRecipientAddress() {
+				history = append(history, HistoryEntry{
+					BlockIndex:  b.Index,
+					BlockHash:   b.Hash,
+					Timestamp:   b.TimeStamp,
+					Transaction: t,
+				})
+			}
+		}
+	}
+
+	return history
+}
+
+// CanonicalAddress maps either spelling of an address to the one stored in
+// blocks. Strings that are not addresses, such as BlockChainCore, are
+// returned unchanged.
+func (c *BlockChain) CanonicalAddress(address string) string {
+	if canonical, err := wallet.CanonicalAddress(address, c.Params); err == nil {
+		return canonical
+	}
+
+	return address
+}
+
 func (c *BlockChain) MarshalJSON() ([]byte, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	views := make([]*BlockView, 0, len(c.BlockList))
+	for _, b := range c.BlockList {
+		views = append(views, c.view(b))
+	}
+
 	return json.Marshal(struct {
-		Blocks []*block.Block `json:"chains"`
+		Blocks          []*BlockView `json:"chains"`
+		FinalizedHeight int64        `json:"finalizedHeight"`
 	}{
-		Blocks: c.BlockList,
+		Blocks:          views,
+		FinalizedHeight: c.finalizedHeight,
 	})
 }
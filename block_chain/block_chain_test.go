@@ -0,0 +1,300 @@
+package blockchain_test
+
+import (
+	"../block"
+	"../block_chain"
+	"../chain_params"
+	"../consensus"
+	"../signature_scheme"
+	"../transaction"
+	"../utils"
+	"../wallet"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type account struct {
+	key     signaturescheme.PrivateKey
+	address string
+}
+
+func newAccount(t *testing.T, params *chainparams.Params) account {
+	t.Helper()
+	key, err := signaturescheme.P256.GenerateKey()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return account{key: key, address: wallet.AddressFromPublicKey(key.PublicKey(), params)}
+}
+
+// send signs a transfer with nonce and offers it to chain.
+func send(t *testing.T, chain *blockchain.BlockChain, from account, to string, value float32, nonce uint64) error {
+	t.Helper()
+	signature, err := wallet.NewTransaction(from.key, from.key.PublicKey(), from.address, to, value, nonce).GenerateSignature()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return chain.AddTransaction(from.address, to, value, nonce, from.key.PublicKey(), signature)
+}
+
+func newChain(t *testing.T) (*blockchain.BlockChain, account, account) {
+	t.Helper()
+	params := chainparams.Regtest
+	sender := newAccount(t, &params)
+	recipient := newAccount(t, &params)
+	params.GenesisAllocations = []chainparams.Allocation{{Address: sender.address, Value: 100}}
+
+	return blockchain.New(&params), sender, recipient
+}
+
+func TestConfirmedTransactionCannotBeResent(t *testing.T) {
+	chain, sender, recipient := newChain(t)
+
+	if err := send(t, chain, sender, recipient.address, 10, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chain.Mining(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The block carries everything needed to send the transfer again.
+	confirmed := chain.BlockByHeight(1).Data[0]
+	w := confirmed.GetWitness()
+	publicKey, err := signaturescheme.ParseFormattedPublicKey(w.PublicKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signature, err := utils.SignatureFromString(w.Signature)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = chain.AddTransaction(confirmed.GetSenderAddress(), confirmed.GetRecipientAddress(), confirmed.GetValue(), confirmed.GetNonce(), publicKey, signature)
+
+	if !errors.Is(err, blockchain.ErrNonceUsed) {
+		t.Fatalf("resending a confirmed transaction: error = %v, want %v", err, blockchain.ErrNonceUsed)
+	}
+
+	if amount := chain.CalculateTotalAmount(recipient.address); amount != 10 {
+		t.Errorf("recipient holds %.1f, want 10", amount)
+	}
+
+	if nonce := chain.NextNonce(sender.address); nonce != 2 {
+		t.Errorf("NextNonce = %d, want 2", nonce)
+	}
+
+	if err := send(t, chain, sender, recipient.address, 10, 2); err != nil {
+		t.Errorf("a fresh nonce is refused: %v", err)
+	}
+}
+
+func TestPooledTransactionCannotBeResent(t *testing.T) {
+	chain, sender, recipient := newChain(t)
+
+	if err := send(t, chain, sender, recipient.address, 10, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := send(t, chain, sender, recipient.address, 10, 1); !errors.Is(err, blockchain.ErrNonceUsed) {
+		t.Errorf("resending a pooled transaction: error = %v, want %v", err, blockchain.ErrNonceUsed)
+	}
+
+	if err := send(t, chain, sender, recipient.address, 10, 0); !errors.Is(err, blockchain.ErrInvalidTransaction) {
+		t.Errorf("nonce 0: error = %v, want %v", err, blockchain.ErrInvalidTransaction)
+	}
+
+	if size := chain.Info().TransactionPoolSize; size != 1 {
+		t.Errorf("pool holds %d transactions, want 1", size)
+	}
+}
+
+func TestBlockReplayingTransactionIsRefused(t *testing.T) {
+	chain, sender, recipient := newChain(t)
+
+	if err := send(t, chain, sender, recipient.address, 10, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chain.Mining(); err != nil {
+		t.Fatal(err)
+	}
+
+	blocks := chain.Blocks()
+	tip := blocks[len(blocks)-1]
+	b := &block.Block{
+		Index:     tip.Index + 1,
+		TimeStamp: time.Now().UnixMilli(),
+		PrevHash:  tip.Hash,
+		Producer:  blockchain.MinerAddress,
+	}
+
+	engine := chain.Engine()
+
+	if err := engine.Prepare(blocks, b); err != nil {
+		t.Fatal(err)
+	}
+
+	b.Data = append([]*transaction.Transaction{tip.Data[0]}, engine.Rewards(blocks, b)...)
+
+	if err := engine.Seal(blocks, b); err != nil {
+		t.Fatal(err)
+	}
+
+	err := chain.AppendBlock(b)
+
+	if !errors.Is(err, blockchain.ErrInvalidChain) || !strings.Contains(err.Error(), blockchain.ErrNonceUsed.Error()) {
+		t.Fatalf("block replaying a transaction: error = %v, want %v and %v", err, blockchain.ErrInvalidChain, blockchain.ErrNonceUsed)
+	}
+
+	if height := chain.Info().Height; height != 1 {
+		t.Errorf("height = %d, want 1", height)
+	}
+}
+
+// newStakingChain starts a proof-of-stake chain produced by validator, with
+// staker as a second, smaller validator.
+func newStakingChain(t *testing.T) (*blockchain.BlockChain, account, account) {
+	t.Helper()
+	params := chainparams.Regtest
+	params.Consensus = chainparams.ProofOfStake
+	params.SlotDurationMillisecond = 10
+	validator := newAccount(t, &params)
+	staker := newAccount(t, &params)
+	params.GenesisStakes = []chainparams.Allocation{{Address: validator.address, Value: 90}, {Address: staker.address, Value: 10}}
+	engine := consensus.NewProofOfStake(&params, wallet.FromPrivateKey(validator.key, &params))
+
+	return blockchain.NewWithEngine(&params, engine), validator, staker
+}
+
+// mine waits for a slot of the chain's validator and produces a block in it.
+func mine(t *testing.T, chain *blockchain.BlockChain) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+
+	for time.Now().Before(deadline) {
+		err := chain.Mining()
+
+		if err == nil {
+			return
+		}
+
+		if !errors.Is(err, consensus.ErrNotProducer) {
+			t.Fatal(err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatal("no slot for the validator")
+}
+
+func unstake(t *testing.T, chain *blockchain.BlockChain, staker account, value float32, nonce uint64) {
+	t.Helper()
+	signature, err := wallet.NewTransaction(staker.key, staker.key.PublicKey(), chainparams.StakeAddress, staker.address, value, nonce).GenerateSignature()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chain.AddTransaction(chainparams.StakeAddress, staker.address, value, nonce, staker.key.PublicKey(), signature); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// doubleSign returns evidence of producer signing two headers for slot 1.
+func doubleSign(t *testing.T, producer account) *block.Evidence {
+	t.Helper()
+	var headers []*block.SignedHeader
+
+	for _, timestamp := range []int64{1, 2} {
+		b := &block.Block{Index: 1, TimeStamp: timestamp, Slot: 1, Producer: producer.address}
+		b.Hash = b.Header().Hash()
+		digest, err := hex.DecodeString(b.Hash)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signature, err := signaturescheme.Sign(producer.key, digest)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b.PublicKey = signaturescheme.FormatPublicKey(producer.key.PublicKey())
+		b.Signature = signature.String()
+		headers = append(headers, b.SignedHeader())
+	}
+
+	return &block.Evidence{First: headers[0], Second: headers[1]}
+}
+
+func TestUnstakedFundsUnbondForAnEpoch(t *testing.T) {
+	chain, _, staker := newStakingChain(t)
+	recipient := newAccount(t, chain.Params)
+
+	unstake(t, chain, staker, 5, 1)
+	mine(t, chain)
+
+	if err := send(t, chain, staker, recipient.address, 5, 2); !errors.Is(err, blockchain.ErrInsufficientBalance) {
+		t.Fatalf("spending while unbonding: error = %v, want %v", err, blockchain.ErrInsufficientBalance)
+	}
+
+	for i := int64(0); i < chain.Params.EpochLength; i++ {
+		mine(t, chain)
+	}
+
+	if err := send(t, chain, staker, recipient.address, 5, 2); err != nil {
+		t.Fatalf("spending after unbonding: %v", err)
+	}
+
+	mine(t, chain)
+
+	if amount := chain.CalculateTotalAmount(recipient.address); amount != 5 {
+		t.Errorf("recipient holds %.1f, want 5", amount)
+	}
+}
+
+func TestUnbondingStakeIsSlashed(t *testing.T) {
+	chain, _, staker := newStakingChain(t)
+	recipient := newAccount(t, chain.Params)
+
+	unstake(t, chain, staker, 10, 1)
+	mine(t, chain)
+
+	if stake := chain.Engine().(consensus.Staking).Stake(chain.Blocks(), staker.address); stake != 0 {
+		t.Fatalf("stake after unstaking = %.1f, want 0", stake)
+	}
+
+	if err := chain.ReportEvidence(doubleSign(t, staker)); err != nil {
+		t.Fatalf("evidence against an unbonding stake: %v", err)
+	}
+
+	mine(t, chain)
+
+	if n := len(chain.BlockByHeight(2).Evidence); n != 1 {
+		t.Fatalf("block 2 carries %d pieces of evidence, want 1", n)
+	}
+
+	for i := int64(0); i <= chain.Params.EpochLength; i++ {
+		mine(t, chain)
+	}
+
+	if err := send(t, chain, staker, recipient.address, 1, 2); !errors.Is(err, blockchain.ErrInsufficientBalance) {
+		t.Errorf("spending a slashed unbonding stake: error = %v, want %v", err, blockchain.ErrInsufficientBalance)
+	}
+
+	if !chain.Validate() {
+		t.Error("chain with a slashed unbonding stake is not valid")
+	}
+}
@@ -0,0 +1,19 @@
+package blockchain
+
+import "errors"
+
+var (
+	ErrInvalidTransactionRequest = errors.New("invalid transaction request")
+	ErrInvalidSignature          = errors.New("invalid transaction signature")
+	ErrInvalidTransaction        = errors.New("transaction is not valid")
+	ErrInsufficientBalance       = errors.New("insufficient balance")
+	ErrNonceUsed                 = errors.New("nonce already used")
+	ErrGenesisMismatch           = errors.New("genesis block does not match")
+	ErrChainNotLonger            = errors.New("chain is not longer than the current one")
+	ErrInvalidChain              = errors.New("chain is not valid")
+	ErrStakingNotSupported       = errors.New("consensus engine does not support staking")
+	ErrInsufficientStake         = errors.New("insufficient stake")
+	ErrFinalizedConflict         = errors.New("chain conflicts with a finalized block")
+	ErrBlockNotInChain           = errors.New("block is not in the chain")
+	ErrCheckpointMismatch        = errors.New("block conflicts with a checkpoint")
+)
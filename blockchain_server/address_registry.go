@@ -0,0 +1,125 @@
+package main
+
+import (
+	"../api_error"
+	"../block_chain"
+	"../wallet"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrMissingAddress = errors.New("address is required")
+	ErrRegistryFull   = errors.New("address registry is full")
+)
+
+// maxRegisteredAddresses bounds the registry. Registration needs no proof
+// of ownership, so without a cap anyone could grow it until the node runs
+// out of memory.
+const maxRegisteredAddresses = 10000
+
+// RegisterAddressRequest carries only a public address: wallets are
+// created and kept by the wallet app, and the node never sees a key.
+type RegisterAddressRequest struct {
+	Address *string `json:"address"`
+}
+
+func (r RegisterAddressRequest) Validate() error {
+	if r.Address == nil {
+		return ErrMissingAddress
+	}
+
+	return nil
+}
+
+type registeredAddress struct {
+	Address string  `json:"address"`
+	Balance float32 `json:"balance"`
+}
+
+// addressRegistry remembers the addresses wallet apps registered, so
+// operators can list the wallets this node serves.
+type addressRegistry struct {
+	lock      sync.Mutex
+	addresses map[string]bool
+}
+
+var registry = &addressRegistry{addresses: make(map[string]bool)}
+
+// add records address. Registering an address again always succeeds, a
+// new one only while the registry is below maxRegisteredAddresses.
+func (r *addressRegistry) add(address string) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.addresses[address] && len(r.addresses) >= maxRegisteredAddresses {
+		return fmt.Errorf("%w: %d addresses", ErrRegistryFull, maxRegisteredAddresses)
+	}
+
+	r.addresses[address] = true
+	return nil
+}
+
+func (r *addressRegistry) list() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	addresses := make([]string, 0, len(r.addresses))
+	for address := range r.addresses {
+		addresses = append(addresses, address)
+	}
+
+	sort.Strings(addresses)
+	return addresses
+}
+
+func HandleRegisterAddress(c echo.Context) error {
+	req := RegisterAddressRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	bc := chainStore["blockchain"]
+	address, err := wallet.CanonicalAddress(*req.Address, bc.Params)
+
+	if err != nil {
+		return fmt.Errorf("address: %w", err)
+	}
+
+	if err := registry.add(address); err != nil {
+		return apierror.New(http.StatusInsufficientStorage, apierror.CodeInternalError, "address registry is full", err.Error())
+	}
+
+	return c.JSON(http.StatusCreated, registeredAddressResponse(bc, address))
+}
+
+func HandleListRegisteredAddresses(c echo.Context) error {
+	bc := chainStore["blockchain"]
+
+	addresses := []registeredAddress{}
+	for _, address := range registry.list() {
+		addresses = append(addresses, registeredAddressResponse(bc, address))
+	}
+
+	return c.JSON(http.StatusOK, struct {
+		Addresses []registeredAddress `json:"addresses"`
+	}{
+		Addresses: addresses,
+	})
+}
+
+func registeredAddressResponse(bc *blockchain.BlockChain, address string) registeredAddress {
+	return registeredAddress{
+		Address: address,
+		Balance: bc.CalculateTotalAmount(address),
+	}
+}
@@ -1,97 +1,360 @@
 package main
 
 import (
-	"../block"
+	"../api_error"
 	"../block_chain"
-	"../transaction"
+	"../chain_params"
+	"../config"
+	"../consensus"
+	"../keystore"
+	"../signature_scheme"
 	"../utils"
 	"../wallet"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
 	"log"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var chainStore = make(map[string]*blockchain.BlockChain)
-var walletStore = make(map[string]*wallet.Wallet)
 
 type TransactionResponse struct {
 	SenderAddress    string  `json:"senderAddress"`
 	RecipientAddress string  `json:"recipientAddress"`
 	Value            float32 `json:"value"`
+	Nonce            uint64  `json:"nonce,omitempty"`
 }
 
 func init() {
 	log.SetPrefix("Blockchain Server: ")
+}
+
+func setupChain(cfg *config.Node) {
+	params := chainParams(cfg)
+	minerWallet := loadMinerWallet(cfg, params)
+
+	if cfg.Chain.MinerAddress == "" {
+		cfg.Chain.MinerAddress = minerWallet.BlockchainAddress()
+	}
+
+	minerAddress, err := wallet.CanonicalAddress(cfg.Chain.MinerAddress, params)
+
+	if err != nil {
+		log.Fatalln("chain.minerAddress:", err)
+	}
+	cfg.Chain.MinerAddress = minerAddress
+
+	var chain *blockchain.BlockChain
+	if cfg.GenesisFile != "" {
+		chain = genesisChain(cfg, params, minerWallet)
+	} else {
+		chain = migrationChain(cfg, params, minerWallet)
+	}
+
+	chainStore["blockchain"] = chain
+
+	// A Raft cluster orders the blocks itself; its nodes neither mine nor
+	// sync chains from peers.
+	if params.Consensus == chainparams.Raft {
+		setupOrdering(cfg, chain)
+	} else {
+		go chain.RecursiveMiner()
+	}
+
+	if len(params.FinalityValidators) > 0 {
+		setupFinality(cfg, chain, minerWallet)
+	}
+
+	if len(cfg.Peers) > 0 && params.Consensus != chainparams.Raft {
+		go syncWithPeers(chain, cfg.Peers, time.Duration(cfg.SyncIntervalSecond)*time.Second)
+	}
+}
+
+// genesisChain starts from the genesis file so that every node given the
+// same file shares the same genesis hash.
+func genesisChain(cfg *config.Node, params *chainparams.Params, minerWallet *wallet.Wallet) *blockchain.BlockChain {
+	g, err := chainparams.LoadGenesis(cfg.GenesisFile)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	for i, a := range g.Allocations {
+		address, err := wallet.CanonicalAddress(a.Address, params)
+
+		if err != nil {
+			log.Fatalln(err)
+		}
+		g.Allocations[i].Address = address
+	}
 
+	for i, a := range g.Stakes {
+		address, err := wallet.CanonicalAddress(a.Address, params)
+
+		if err != nil {
+			log.Fatalln(err)
+		}
+		g.Stakes[i].Address = address
+	}
+
+	for i, signer := range g.Signers {
+		publicKey, err := signaturescheme.ParseFormattedPublicKey(signer)
+
+		if err != nil {
+			log.Fatalln(err)
+		}
+		g.Signers[i] = signaturescheme.FormatPublicKey(publicKey)
+	}
+
+	params.ApplyGenesis(g)
+	chain := blockchain.NewWithEngine(params, newEngine(params, minerWallet))
+	chain.MinerAddress = cfg.Chain.MinerAddress
+	log.Printf("genesis %s loaded from %s\n", chain.Info().GenesisHash, cfg.GenesisFile)
+
+	return chain
+}
+
+// migrationChain builds a throwaway chain funded through the miner key and
+// two random wallets, used when no genesis file is configured. Under proof
+// of stake or authority the miner key is also the only genesis validator or
+// signer.
+func migrationChain(cfg *config.Node, params *chainparams.Params, minerWallet *wallet.Wallet) *blockchain.BlockChain {
 	fmt.Printf("%s migration part started \n", strings.Repeat("=", 25))
 
-	minerWallet := wallet.NewWallet()
-	walletUserA := wallet.NewWallet()
-	walletUserB := wallet.NewWallet()
-	//walletUserC := wallet.NewWallet()
+	walletUserA, err := wallet.NewWallet(params)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	walletUserB, err := wallet.NewWallet(params)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//walletUserC := wallet.NewWallet(params)
+
+	params.GenesisAllocations = append(params.GenesisAllocations, chainparams.Allocation{Address: minerWallet.BlockchainAddress(), Value: 3000})
+
+	if params.Consensus == chainparams.ProofOfStake {
+		params.GenesisStakes = append(params.GenesisStakes, chainparams.Allocation{Address: minerWallet.BlockchainAddress(), Value: 1000})
+	}
+
+	if params.Consensus == chainparams.ProofOfAuthority {
+		params.GenesisSigners = append(params.GenesisSigners, signaturescheme.FormatPublicKey(minerWallet.PublicKey()))
+	}
+
+	chain := blockchain.NewWithEngine(params, newEngine(params, minerWallet))
+	chain.MinerAddress = cfg.Chain.MinerAddress
 
-	chain := blockchain.New()
+	t := wallet.NewTransaction(minerWallet.PrivateKey(), minerWallet.PublicKey(), minerWallet.BlockchainAddress(), walletUserB.BlockchainAddress(), 1000, 1)
+	t2 := wallet.NewTransaction(walletUserB.PrivateKey(), walletUserB.PublicKey(), walletUserB.BlockchainAddress(), walletUserA.BlockchainAddress(), 100, 1)
 
-	t := wallet.NewTransaction(minerWallet.PrivateKey(), minerWallet.PublicKey(), minerWallet.BlockchainAddress(), walletUserB.BlockchainAddress(), 1000)
-	t2 := wallet.NewTransaction(walletUserB.PrivateKey(), walletUserB.PublicKey(), walletUserB.BlockchainAddress(), walletUserA.BlockchainAddress(), 100)
+	produceBlock(chain)
 
-	chain.BlockList = append(chain.BlockList, block.CreateGenesisBlock([]*transaction.Transaction{transaction.New("genesis", minerWallet.BlockchainAddress(), 3000)}))
-	chain.Mining()
+	signature, err := t.GenerateSignature()
 
-	isOk := chain.AddTransaction(minerWallet.BlockchainAddress(), walletUserB.BlockchainAddress(), 1000, minerWallet.PublicKey(), t.GenerateSignature())
-	fmt.Printf("is ok, %v \n", isOk)
-	chain.Mining()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	err = chain.AddTransaction(minerWallet.BlockchainAddress(), walletUserB.BlockchainAddress(), 1000, 1, minerWallet.PublicKey(), signature)
+	fmt.Printf("is ok, %v \n", err == nil)
+	produceBlock(chain)
+
+	signature, err = t2.GenerateSignature()
+
+	if err != nil {
+		log.Fatalln(err)
+	}
 
-	isOk = chain.AddTransaction(walletUserB.BlockchainAddress(), walletUserA.BlockchainAddress(), 100, walletUserB.PublicKey(), t2.GenerateSignature())
-	fmt.Printf("is ok, %v \n", isOk)
+	err = chain.AddTransaction(walletUserB.BlockchainAddress(), walletUserA.BlockchainAddress(), 100, 1, walletUserB.PublicKey(), signature)
+	fmt.Printf("is ok, %v \n", err == nil)
 
-	chain.Mining()
+	produceBlock(chain)
 	fmt.Printf("A %.1f\n", chain.CalculateTotalAmount(walletUserA.BlockchainAddress()))
 	fmt.Printf("B %.1f\n", chain.CalculateTotalAmount(walletUserB.BlockchainAddress()))
 	fmt.Printf("M %.1f\n", chain.CalculateTotalAmount(minerWallet.BlockchainAddress()))
 
 	fmt.Printf("%s migration part completed\n", strings.Repeat("=", 25))
 
-	go chain.RecursiveMiner()
-	chainStore["blockchain"] = chain
-	walletStore["minerWallet"] = minerWallet
-	walletStore["walletUserA"] = walletUserA
-	walletStore["walletUserB"] = walletUserB
+	return chain
+}
+
+// produceBlock mines one block, waiting for this node's slot when the
+// engine hands out turns.
+func produceBlock(chain *blockchain.BlockChain) {
+	for {
+		err := chain.Mining()
+
+		if err == nil {
+			return
+		}
+
+		if !errors.Is(err, consensus.ErrNotProducer) {
+			log.Fatalln(err)
+		}
+
+		time.Sleep(consensus.RetryInterval)
+	}
+}
+
+func newEngine(params *chainparams.Params, minerWallet *wallet.Wallet) consensus.Engine {
+	engine, err := consensus.New(params, minerWallet)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("consensus %s\n", engine.Name())
+	return engine
+}
+
+// loadMinerWallet decrypts the miner key from the keystore. A keystore
+// without keys gets a new one, so a fresh node still starts on its own.
+func loadMinerWallet(cfg *config.Node, params *chainparams.Params) *wallet.Wallet {
+	ks, err := keystore.Open(cfg.Keystore.Dir, params)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if cfg.Keystore.Passphrase == "" {
+		log.Println("keystore passphrase is empty, set BLOCKCHAIN_NODE_KEYSTORE_PASSPHRASE")
+	}
+
+	address := cfg.Keystore.MinerKey
+
+	if address == "" {
+		addresses, err := ks.Addresses()
+
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		if len(addresses) == 0 {
+			w, err := wallet.NewWallet(params)
+
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			if err := ks.Store(w, cfg.Keystore.Passphrase); err != nil {
+				log.Fatalln(err)
+			}
+
+			log.Printf("created miner key %s in %s\n", w.BlockchainAddress(), cfg.Keystore.Dir)
+			return w
+		}
+
+		address = addresses[0]
+	}
+
+	w, err := ks.Load(address, cfg.Keystore.Passphrase)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("miner key %s loaded from %s\n", w.BlockchainAddress(), cfg.Keystore.Dir)
+	return w
+}
+
+func chainParams(cfg *config.Node) *chainparams.Params {
+	params, err := chainparams.ByName(cfg.Chain.Network)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if cfg.Chain.MiningReward > 0 {
+		params.BlockReward = cfg.Chain.MiningReward
+	}
+
+	if cfg.Chain.TargetBlockTimeMillisecond > 0 {
+		params.TargetBlockTimeMillisecond = cfg.Chain.TargetBlockTimeMillisecond
+	}
+
+	if cfg.Chain.DifficultyAdjustmentIntervalBlockCount > 0 {
+		params.DifficultyAdjustmentIntervalBlockCount = cfg.Chain.DifficultyAdjustmentIntervalBlockCount
+	}
+
+	if cfg.Chain.Consensus != "" {
+		params.Consensus = cfg.Chain.Consensus
+	}
+
+	if cfg.Chain.SlotDurationMillisecond > 0 {
+		params.SlotDurationMillisecond = cfg.Chain.SlotDurationMillisecond
+	}
+
+	if len(cfg.Chain.FinalityValidators) > 0 {
+		params.FinalityValidators = cfg.Chain.FinalityValidators
+	}
+
+	// Configured checkpoints replace the preset's at the same height.
+	for _, s := range cfg.Chain.Checkpoints {
+		height, hash, err := config.ParseCheckpoint(s)
+
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		checkpoints := []chainparams.Checkpoint{{Height: height, Hash: hash}}
+		for _, c := range params.Checkpoints {
+			if c.Height != height {
+				checkpoints = append(checkpoints, c)
+			}
+		}
+		params.Checkpoints = checkpoints
+	}
+
+	if cfg.Chain.AssumeValid != "" {
+		params.AssumeValid = cfg.Chain.AssumeValid
+	}
+
+	return params
 }
 
 func main() {
+	cfg := config.DefaultNode()
+
+	if err := config.Load("blockchain_server", "BLOCKCHAIN_NODE", cfg, os.Args[1:]); err != nil {
+		log.Fatalln(err)
+	}
+	config.Print(cfg)
+
+	setupChain(cfg)
+
 	fmt.Println("server run")
 
 	server := echo.New()
+	server.HTTPErrorHandler = apierror.HTTPErrorHandler
 
+	server.Use(requireSameGenesis)
+	// Any page may read the chain, but no page may have a visitor's browser
+	// submit to it: requests that change state get no CORS headers.
 	server.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		Skipper:      changesState,
 		AllowOrigins: []string{"*"},
+		AllowMethods: []string{http.MethodGet, http.MethodHead},
 		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
 	}))
 
-	server.GET("/random-wallet", func(c echo.Context) error {
-		w := walletStore["minerWallet"]
-
-		walletJson, err := w.MarshallJson()
-
-		if err != nil {
-			panic(err)
-		}
-
-		return c.JSONBlob(http.StatusOK, walletJson)
-	})
-
 	server.GET("/blocks", func(c echo.Context) error {
 		bc := chainStore["blockchain"]
 
 		blockListJson, err := bc.MarshalJSON()
 
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		return c.JSONBlob(http.StatusOK, blockListJson)
@@ -101,7 +364,7 @@ func main() {
 		addresses := make(map[string]float32)
 		bc := chainStore["blockchain"]
 
-		for _, bc := range bc.BlockList {
+		for _, bc := range bc.Blocks() {
 			for _, t := range bc.Data {
 				if _, ok := addresses[t.GetSenderAddress()]; !ok {
 					addresses[t.GetSenderAddress()] = 0.0
@@ -120,7 +383,7 @@ func main() {
 		walletJson, err := json.Marshal(addresses)
 
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		return c.JSONBlob(http.StatusOK, walletJson)
@@ -140,41 +403,135 @@ func main() {
 		})
 
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		return c.JSONBlob(http.StatusOK, walletBalanceJSON)
 	})
 
-	server.GET("/transaction-pool", func(c echo.Context) error {
+	server.GET("/wallet-nonce/:walletAddress", func(c echo.Context) error {
+		bc := chainStore["blockchain"]
+
+		return c.JSON(http.StatusOK, struct {
+			Nonce uint64 `json:"nonce"`
+		}{
+			Nonce: bc.NextNonce(c.Param("walletAddress")),
+		})
+	})
+
+	server.GET("/wallet-activity/:walletAddress", func(c echo.Context) error {
 		bc := chainStore["blockchain"]
 
-		response := struct {
-			TransactionPool []TransactionResponse `json:"transactionPool"`
-			Length          int                   `json:"length"`
+		return c.JSON(http.StatusOK, struct {
+			Used bool `json:"used"`
 		}{
-			Length: len(bc.TransactionPool),
+			Used: bc.IsAddressUsed(c.Param("walletAddress")),
+		})
+	})
+
+	server.GET("/wallet-history/:walletAddress", func(c echo.Context) error {
+		bc := chainStore["blockchain"]
+
+		return c.JSON(http.StatusOK, struct {
+			History []blockchain.HistoryEntry `json:"history"`
+		}{
+			History: bc.AddressHistory(c.Param("walletAddress")),
+		})
+	})
+
+	server.GET("/blocks/:height", func(c echo.Context) error {
+		bc := chainStore["blockchain"]
+
+		height, err := strconv.ParseInt(c.Param("height"), 10, 64)
+
+		if err != nil {
+			return apierror.BadRequest("height must be an integer")
 		}
 
-		for _, t := range bc.TransactionPool {
-			response.TransactionPool = append(response.TransactionPool, TransactionResponse{
-				SenderAddress:    t.GetSenderAddress(),
-				RecipientAddress: t.GetRecipientAddress(),
-				Value:            t.GetValue(),
-			})
+		b := bc.BlockByHeight(height)
+
+		if b == nil {
+			return apierror.New(http.StatusNotFound, apierror.CodeNotFound, "block not found", c.Param("height"))
 		}
 
-		m, err := json.Marshal(response)
+		return c.JSON(http.StatusOK, bc.View(b))
+	})
+
+	server.GET("/chain-info", func(c echo.Context) error {
+		bc := chainStore["blockchain"]
+
+		return c.JSON(http.StatusOK, bc.Info())
+	})
+
+	server.GET("/transaction-pool", func(c echo.Context) error {
+		bc := chainStore["blockchain"]
+
+		m, err := json.Marshal(transactionPoolResponse(bc))
 
 		if err != nil {
-			panic(err)
+			return err
 		}
 
 		return c.JSONBlob(http.StatusOK, m)
 	})
 
+	server.GET("/events", HandleEventStream)
+	server.GET("/ws", HandleWebSocket)
+
+	server.GET("/addresses", HandleListRegisteredAddresses)
+	server.POST("/addresses", HandleRegisterAddress)
 	server.POST("/transactions", HandleTransaction)
-	server.Logger.Fatal(server.Start(":5001"))
+	server.POST("/rpc", HandleJSONRPC)
+	server.POST("/messages/verify", HandleVerifyMessage)
+	server.GET("/validators", HandleValidators)
+	server.POST("/evidence", HandleReportEvidence)
+	server.GET("/signers", HandleSigners)
+	server.GET("/raft", HandleRaftStatus)
+	server.GET("/finality", HandleFinality)
+	server.GET("/finality/votes", HandleFinalityVotes)
+	server.POST("/finality/votes", HandleFinalityVote)
+
+	go serveGRPC(cfg.GRPCAddress, chainStore["blockchain"])
+	go serveAdmin(cfg.AdminAddress)
+	server.Logger.Fatal(server.Start(cfg.HTTPAddress))
+}
+
+// serveAdmin serves the routes that steer what this node votes for. They
+// are not authenticated, so they listen apart from the public API, on the
+// loopback address config.Node.Validate insists on, and refuse browsers.
+func serveAdmin(address string) {
+	admin := echo.New()
+	admin.HideBanner = true
+	admin.HTTPErrorHandler = apierror.HTTPErrorHandler
+
+	admin.Use(refuseBrowsers)
+
+	admin.POST("/signers/proposals", HandleProposeSigner)
+	admin.DELETE("/signers/proposals/:publicKey", HandleDiscardProposal)
+
+	log.Fatalln(admin.Start(address))
+}
+
+// refuseBrowsers turns away requests a web page sends, which carry an
+// Origin, so a page open on the operator's machine cannot reach the admin
+// API on localhost.
+func refuseBrowsers(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if origin := c.Request().Header.Get(echo.HeaderOrigin); origin != "" {
+			return apierror.New(http.StatusForbidden, apierror.CodeForbidden, "browser requests are not allowed", origin)
+		}
+
+		return next(c)
+	}
+}
+
+func changesState(c echo.Context) bool {
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+
+	return true
 }
 
 func HandleTransaction(c echo.Context) error {
@@ -182,37 +539,92 @@ func HandleTransaction(c echo.Context) error {
 	err := c.Bind(&req)
 
 	if err != nil {
-		panic(err)
+		return apierror.BadRequest(err.Error())
 	}
 
-	if !req.Validate() {
-		panic("bad request")
+	if err := req.Validate(); err != nil {
+		return err
 	}
 
-	publicKey := utils.PublicKeyFromString(*req.SenderPublicKey)
-	signature := utils.SignatureFromString(*req.Signature)
-
 	bc := chainStore["blockchain"]
 
-	isCreated := bc.CreateTransaction(*req.SenderBlockchainAddress, *req.RecipientBlockchainAddress, *req.Value, publicKey, signature)
+	if err := submitTransaction(bc, req); err != nil {
+		return err
+	}
 
-	if isCreated == true {
-		err := c.NoContent(http.StatusCreated)
+	return c.NoContent(http.StatusCreated)
+}
 
-		if err != nil {
-			panic(err)
-		}
-		return nil
+// HandleVerifyMessage checks a message signed with wallet.SignMessage. The
+// node holds no user keys, so signing is left to the wallet app.
+func HandleVerifyMessage(c echo.Context) error {
+	signed := wallet.SignedMessage{}
+
+	if err := c.Bind(&signed); err != nil {
+		return apierror.BadRequest(err.Error())
 	}
 
-	err = c.JSON(http.StatusBadRequest, struct {
-		Message string
+	address, err := signed.Verify(chainStore["blockchain"].Params)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, struct {
+		Valid   bool   `json:"valid"`
+		Address string `json:"address"`
 	}{
-		Message: "transaction didnt completed",
+		Valid:   true,
+		Address: address,
 	})
+}
+
+func submitTransaction(bc *blockchain.BlockChain, req blockchain.TransactionRequest) error {
+	if raftNode != nil && !raftNode.IsLeader() {
+		return forwardToLeader(req)
+	}
+
+	if req.Multisig != nil {
+		return bc.AddMultisigTransaction(*req.SenderBlockchainAddress, *req.RecipientBlockchainAddress, *req.Value, *req.Nonce, req.Multisig, req.Signatures)
+	}
+
+	var scheme string
+	if req.Scheme != nil {
+		scheme = *req.Scheme
+	}
+
+	publicKey, err := signaturescheme.ParsePublicKey(scheme, *req.SenderPublicKey)
 
 	if err != nil {
-		panic(err)
+		return err
 	}
-	return nil
+
+	signature, err := utils.SignatureFromString(*req.Signature)
+
+	if err != nil {
+		return err
+	}
+
+	return bc.CreateTransaction(*req.SenderBlockchainAddress, *req.RecipientBlockchainAddress, *req.Value, *req.Nonce, publicKey, signature)
+}
+
+func transactionPoolResponse(bc *blockchain.BlockChain) interface{} {
+	pool := bc.GetTransactionPool()
+	response := struct {
+		TransactionPool []TransactionResponse `json:"transactionPool"`
+		Length          int                   `json:"length"`
+	}{
+		Length: len(pool),
+	}
+
+	for _, t := range pool {
+		response.TransactionPool = append(response.TransactionPool, TransactionResponse{
+			SenderAddress:    t.GetSenderAddress(),
+			RecipientAddress: t.GetRecipientAddress(),
+			Value:            t.GetValue(),
+			Nonce:            t.GetNonce(),
+		})
+	}
+
+	return response
 }
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"../api_error"
+	"../block"
+	"../block_chain"
+	"../consensus"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo"
+	"net/http"
+)
+
+var ErrMissingProposalField = errors.New("publicKey and authorize are required")
+
+// HandleValidators lists the validator set of the current slot and its
+// leader on a proof-of-stake chain.
+func HandleValidators(c echo.Context) error {
+	bc := chainStore["blockchain"]
+	pos, ok := bc.Engine().(*consensus.ProofOfStake)
+
+	if !ok {
+		return fmt.Errorf("%w: %s has no validators", blockchain.ErrStakingNotSupported, bc.Engine().Name())
+	}
+
+	blocks := bc.Blocks()
+	slot := pos.CurrentSlot(blocks)
+	leader, err := pos.Leader(blocks, slot)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, struct {
+		Slot       int64                 `json:"slot"`
+		Epoch      int64                 `json:"epoch"`
+		Leader     string                `json:"leader"`
+		Validators []consensus.Validator `json:"validators"`
+	}{
+		Slot:       slot,
+		Epoch:      slot / bc.Params.EpochLength,
+		Leader:     leader,
+		Validators: pos.Validators(blocks, slot),
+	})
+}
+
+// HandleReportEvidence takes two headers one producer signed for the same
+// slot. The evidence goes into the next block this node produces.
+func HandleReportEvidence(c echo.Context) error {
+	evidence := block.Evidence{}
+
+	if err := c.Bind(&evidence); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := chainStore["blockchain"].ReportEvidence(&evidence); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+// ProposalRequest asks this node to vote on a signer in the blocks it
+// produces.
+type ProposalRequest struct {
+	PublicKey *string `json:"publicKey"`
+	Authorize *bool   `json:"authorize"`
+}
+
+func (r ProposalRequest) Validate() error {
+	if r.PublicKey == nil || r.Authorize == nil {
+		return ErrMissingProposalField
+	}
+
+	return nil
+}
+
+// HandleSigners lists the signers of a proof-of-authority chain, the signer
+// due in the current slot, the votes on chain and this node's proposals.
+func HandleSigners(c echo.Context) error {
+	bc := chainStore["blockchain"]
+	poa, err := authorityEngine(bc)
+
+	if err != nil {
+		return err
+	}
+
+	blocks := bc.Blocks()
+	slot := poa.CurrentSlot(blocks)
+	inTurn, err := poa.InTurn(blocks, slot)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, struct {
+		Slot      int64                `json:"slot"`
+		InTurn    string               `json:"inTurn"`
+		Signers   []consensus.Signer   `json:"signers"`
+		Tallies   []consensus.Tally    `json:"tallies"`
+		Proposals []consensus.Proposal `json:"proposals"`
+	}{
+		Slot:      slot,
+		InTurn:    inTurn.Address,
+		Signers:   poa.Signers(blocks),
+		Tallies:   poa.Tallies(blocks),
+		Proposals: poa.Proposals(),
+	})
+}
+
+func HandleProposeSigner(c echo.Context) error {
+	req := ProposalRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	poa, err := authorityEngine(chainStore["blockchain"])
+
+	if err != nil {
+		return err
+	}
+
+	if err := poa.Propose(*req.PublicKey, *req.Authorize); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusAccepted, poa.Proposals())
+}
+
+func HandleDiscardProposal(c echo.Context) error {
+	poa, err := authorityEngine(chainStore["blockchain"])
+
+	if err != nil {
+		return err
+	}
+
+	if err := poa.Discard(c.Param("publicKey")); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func authorityEngine(bc *blockchain.BlockChain) (*consensus.ProofOfAuthority, error) {
+	poa, ok := bc.Engine().(*consensus.ProofOfAuthority)
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s has no signers", consensus.ErrUnsupported, bc.Engine().Name())
+	}
+
+	return poa, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"../block_chain"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"strings"
+)
+
+// HandleEventStream pushes chain events as Server-Sent Events. Clients can
+// narrow the stream with one or more ?address= query parameters.
+func HandleEventStream(c echo.Context) error {
+	bc := chainStore["blockchain"]
+	sub := bc.Events().Subscribe(subscriptionAddresses(c))
+	defer bc.Events().Unsubscribe(sub)
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case e, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+
+			m, err := json.Marshal(e)
+
+			if err != nil {
+				return err
+			}
+
+			if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", e.Type, m); err != nil {
+				return nil
+			}
+			res.Flush()
+		}
+	}
+}
+
+// HandleWebSocket pushes the same events as HandleEventStream over a
+// WebSocket connection, one JSON message per event.
+func HandleWebSocket(c echo.Context) error {
+	bc := chainStore["blockchain"]
+	addresses := subscriptionAddresses(c)
+
+	websocket.Handler(func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		sub := bc.Events().Subscribe(addresses)
+		defer bc.Events().Unsubscribe(sub)
+
+		closed := make(chan struct{})
+		go func() {
+			var discard string
+			for websocket.Message.Receive(ws, &discard) == nil {
+			}
+			close(closed)
+		}()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case e, ok := <-sub.C:
+				if !ok {
+					return
+				}
+
+				if err := websocket.JSON.Send(ws, e); err != nil {
+					return
+				}
+			}
+		}
+	}).ServeHTTP(c.Response(), c.Request())
+
+	return nil
+}
+
+func subscriptionAddresses(c echo.Context) []string {
+	var addresses []string
+	for _, param := range c.QueryParams()["address"] {
+		addresses = append(addresses, strings.Split(param, ",")...)
+	}
+
+	return canonicalAddresses(chainStore["blockchain"], addresses)
+}
+
+// canonicalAddresses rewrites subscription filters to the spelling events
+// carry, so Bech32m and Base58Check filters match the same transactions.
+func canonicalAddresses(bc *blockchain.BlockChain, addresses []string) []string {
+	canonical := make([]string, 0, len(addresses))
+	for _, a := range addresses {
+		canonical = append(canonical, bc.CanonicalAddress(a))
+	}
+
+	return canonical
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"../api_error"
+	"../block_chain"
+	"../config"
+	"../finality"
+	"../wallet"
+	"github.com/labstack/echo"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var gadget *finality.Gadget
+
+// setupFinality starts the finality gadget. The node votes when its miner
+// key is a validator, and passes every new vote on to its peers.
+func setupFinality(cfg *config.Node, chain *blockchain.BlockChain, minerWallet *wallet.Wallet) {
+	g, err := finality.New(chain, chain.Params.FinalityValidators, minerWallet)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	g.OnVote = func(v *finality.Vote) {
+		for _, peer := range cfg.Peers {
+			go func(peer string) {
+				if err := postToPeer(strings.TrimRight(peer, "/")+"/finality/votes", chain.Info().GenesisHash, v); err != nil {
+					log.Printf("finality vote to %s: %v\n", peer, err)
+				}
+			}(peer)
+		}
+	}
+
+	log.Printf("finality with %d validators, voting %t\n", len(g.Validators()), g.IsValidator())
+	gadget = g
+	go g.Run()
+}
+
+// HandleFinality shows the finalized height and the validator set.
+func HandleFinality(c echo.Context) error {
+	if gadget == nil {
+		return finalityDisabled()
+	}
+
+	validators := gadget.Validators()
+
+	return c.JSON(http.StatusOK, struct {
+		FinalizedHeight int64    `json:"finalizedHeight"`
+		Validators      []string `json:"validators"`
+		Quorum          int      `json:"quorum"`
+	}{
+		FinalizedHeight: chainStore["blockchain"].FinalizedHeight(),
+		Validators:      validators,
+		Quorum:          2*len(validators)/3 + 1,
+	})
+}
+
+// HandleFinalityVotes lists the votes above the height given as from, so a
+// peer that missed them can catch up.
+func HandleFinalityVotes(c echo.Context) error {
+	if gadget == nil {
+		return finalityDisabled()
+	}
+
+	var from int64
+
+	if s := c.QueryParam("from"); s != "" {
+		var err error
+
+		if from, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return apierror.BadRequest("from must be an integer")
+		}
+	}
+
+	return c.JSON(http.StatusOK, struct {
+		Votes []*finality.Vote `json:"votes"`
+	}{
+		Votes: gadget.Votes(from),
+	})
+}
+
+// HandleFinalityVote takes a prevote or precommit from a peer.
+func HandleFinalityVote(c echo.Context) error {
+	if gadget == nil {
+		return finalityDisabled()
+	}
+
+	v := finality.Vote{}
+
+	if err := c.Bind(&v); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if _, err := gadget.AddVote(&v); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusAccepted)
+}
+
+func finalityDisabled() error {
+	return apierror.New(http.StatusNotFound, apierror.CodeNotFound, "finality is not enabled on this node", "")
+}
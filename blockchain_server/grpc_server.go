@@ -0,0 +1,257 @@
+package main
+
+import (
+	"../api_error"
+	"../block"
+	"../block_chain"
+	"../events"
+	"../node_rpc"
+	"../signature_scheme"
+	"../transaction"
+	"../utils"
+	"../wallet"
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"net"
+	"net/http"
+)
+
+type NodeServer struct {
+	noderpc.UnimplementedNodeServer
+	chain *blockchain.BlockChain
+}
+
+func serveGRPC(address string, chain *blockchain.BlockChain) {
+	listener, err := net.Listen("tcp", address)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	server := grpc.NewServer()
+	noderpc.RegisterNodeServer(server, &NodeServer{chain: chain})
+
+	log.Fatalln(server.Serve(listener))
+}
+
+func (s *NodeServer) GetBlockByHeight(ctx context.Context, req *noderpc.GetBlockByHeightRequest) (*noderpc.Block, error) {
+	b := s.chain.BlockByHeight(req.GetHeight())
+
+	if b == nil {
+		return nil, status.Errorf(codes.NotFound, "block %d not found", req.GetHeight())
+	}
+
+	return toProtoBlock(b), nil
+}
+
+func (s *NodeServer) GetBalance(ctx context.Context, req *noderpc.GetBalanceRequest) (*noderpc.Balance, error) {
+	if req.GetAddress() == "" {
+		return nil, status.Error(codes.InvalidArgument, "address is required")
+	}
+
+	return &noderpc.Balance{
+		Address:   req.GetAddress(),
+		Balance:   s.chain.CalculateTotalAmount(req.GetAddress()),
+		NextNonce: s.chain.NextNonce(req.GetAddress()),
+	}, nil
+}
+
+func (s *NodeServer) GetMempool(ctx context.Context, req *noderpc.GetMempoolRequest) (*noderpc.Mempool, error) {
+	mempool := &noderpc.Mempool{}
+	for _, t := range s.chain.GetTransactionPool() {
+		mempool.Transactions = append(mempool.Transactions, toProtoTransaction(t))
+	}
+
+	return mempool, nil
+}
+
+func (s *NodeServer) GetChainInfo(ctx context.Context, req *noderpc.GetChainInfoRequest) (*noderpc.ChainInfo, error) {
+	info := s.chain.Info()
+
+	return &noderpc.ChainInfo{
+		Height:              info.Height,
+		TipHash:             info.TipHash,
+		GenesisHash:         info.GenesisHash,
+		Difficulty:          int32(info.Difficulty),
+		Consensus:           info.Consensus,
+		FinalizedHeight:     info.FinalizedHeight,
+		TransactionPoolSize: int32(info.TransactionPoolSize),
+	}, nil
+}
+
+func (s *NodeServer) SendTransaction(ctx context.Context, req *noderpc.SendTransactionRequest) (*noderpc.SendTransactionResponse, error) {
+	tr := blockchain.TransactionRequest{
+		SenderBlockchainAddress:    &req.SenderBlockchainAddress,
+		RecipientBlockchainAddress: &req.RecipientBlockchainAddress,
+		Value:                      &req.Value,
+		Nonce:                      &req.Nonce,
+	}
+
+	if req.GetMultisig() != nil {
+		policy, signatures, err := fromProtoMultisig(req.GetMultisig(), req.GetSignatures())
+
+		if err != nil {
+			return nil, grpcError(err)
+		}
+
+		tr.Multisig = policy
+		tr.Signatures = signatures
+	} else {
+		if req.GetScheme() != "" {
+			tr.Scheme = &req.Scheme
+		}
+		tr.SenderPublicKey = &req.SenderPublicKey
+		tr.Signature = &req.Signature
+	}
+
+	if err := tr.Validate(); err != nil {
+		return nil, grpcError(err)
+	}
+
+	if err := submitTransaction(s.chain, tr); err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &noderpc.SendTransactionResponse{
+		Accepted: true,
+	}, nil
+}
+
+func (s *NodeServer) SubscribeBlocks(req *noderpc.SubscribeRequest, stream noderpc.Node_SubscribeBlocksServer) error {
+	sub := s.chain.Events().Subscribe(canonicalAddresses(s.chain, req.GetAddresses()))
+	defer s.chain.Events().Unsubscribe(sub)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case e, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+
+			if e.Type != events.BlockMined {
+				continue
+			}
+
+			if err := stream.Send(toProtoBlock(e.Data.(*block.Block))); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *NodeServer) SubscribeMempool(req *noderpc.SubscribeRequest, stream noderpc.Node_SubscribeMempoolServer) error {
+	sub := s.chain.Events().Subscribe(canonicalAddresses(s.chain, req.GetAddresses()))
+	defer s.chain.Events().Unsubscribe(sub)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case e, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+
+			var event *noderpc.MempoolEvent
+
+			switch e.Type {
+			case events.TransactionAccepted:
+				event = &noderpc.MempoolEvent{
+					Type:        noderpc.MempoolEvent_TRANSACTION_ACCEPTED,
+					Transaction: toProtoTransaction(e.Data.(*transaction.Transaction)),
+				}
+			case events.TransactionConfirmed:
+				confirmed := e.Data.(*blockchain.ConfirmedTransaction)
+				event = &noderpc.MempoolEvent{
+					Type:        noderpc.MempoolEvent_TRANSACTION_CONFIRMED,
+					Transaction: toProtoTransaction(confirmed.Transaction),
+					BlockIndex:  confirmed.BlockIndex,
+					BlockHash:   confirmed.BlockHash,
+				}
+			default:
+				continue
+			}
+
+			if err := stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func grpcError(err error) error {
+	apiErr := apierror.From(err)
+
+	switch {
+	case apiErr.Status == http.StatusBadRequest:
+		return status.Error(codes.InvalidArgument, apiErr.Error())
+	case apiErr.Status == http.StatusNotFound:
+		return status.Error(codes.NotFound, apiErr.Error())
+	case apiErr.Status < http.StatusInternalServerError:
+		return status.Error(codes.FailedPrecondition, apiErr.Error())
+	}
+
+	return status.Error(codes.Internal, apiErr.Error())
+}
+
+func fromProtoMultisig(pm *noderpc.MultisigPolicy, ps []*noderpc.PartialSignature) (*wallet.MultisigPolicy, []wallet.PartialSignature, error) {
+	policy, err := wallet.ParseMultisigPolicy(int(pm.GetThreshold()), pm.GetPublicKeys())
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var signatures []wallet.PartialSignature
+	for _, p := range ps {
+		publicKey, err := signaturescheme.ParsePublicKey(p.GetScheme(), p.GetPublicKey())
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		signature, err := utils.SignatureFromString(p.GetSignature())
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		signatures = append(signatures, wallet.PartialSignature{PublicKey: publicKey, Signature: signature})
+	}
+
+	return policy, signatures, nil
+}
+
+func toProtoBlock(b *block.Block) *noderpc.Block {
+	pb := &noderpc.Block{
+		Index:        b.Index,
+		Timestamp:    b.TimeStamp,
+		PreviousHash: b.PrevHash,
+		Hash:         b.Hash,
+		Nonce:        b.Nonce,
+		Difficulty:   int32(b.Difficulty),
+		Slot:         b.Slot,
+		Producer:     b.Producer,
+		PublicKey:    b.PublicKey,
+		Signature:    b.Signature,
+	}
+
+	for _, t := range b.Data {
+		pb.Transactions = append(pb.Transactions, toProtoTransaction(t))
+	}
+
+	return pb
+}
+
+func toProtoTransaction(t *transaction.Transaction) *noderpc.Transaction {
+	return &noderpc.Transaction{
+		SenderBlockchainAddress:    t.GetSenderAddress(),
+		RecipientBlockchainAddress: t.GetRecipientAddress(),
+		Value:                      t.GetValue(),
+		Nonce:                      t.GetNonce(),
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"../api_error"
+	"../block_chain"
+	"bytes"
+	"encoding/json"
+	"github.com/labstack/echo"
+	"io/ioutil"
+	"net/http"
+)
+
+const jsonRPCVersion = "2.0"
+
+const (
+	rpcParseError          = -32700
+	rpcInvalidRequest      = -32600
+	rpcMethodNotFound      = -32601
+	rpcInvalidParams       = -32602
+	rpcInternalError       = -32603
+	rpcTransactionRejected = -32000
+)
+
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// MarshalJSON writes exactly one of result and error, as JSON-RPC 2.0
+// requires: a call that succeeds with a nil result still answers
+// "result": null.
+func (r RPCResponse) MarshalJSON() ([]byte, error) {
+	if r.Error != nil {
+		return json.Marshal(struct {
+			JSONRPC string          `json:"jsonrpc"`
+			Error   *RPCError       `json:"error"`
+			ID      json.RawMessage `json:"id"`
+		}{r.JSONRPC, r.Error, r.ID})
+	}
+
+	return json.Marshal(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Result  interface{}     `json:"result"`
+		ID      json.RawMessage `json:"id"`
+	}{r.JSONRPC, r.Result, r.ID})
+}
+
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type rpcMethod func(bc *blockchain.BlockChain, params json.RawMessage) (interface{}, *RPCError)
+
+var rpcMethods = map[string]rpcMethod{
+	"getBlockByHeight":   rpcGetBlockByHeight,
+	"getBalance":         rpcGetBalance,
+	"getNonce":           rpcGetNonce,
+	"sendRawTransaction": rpcSendRawTransaction,
+	"getMempool":         rpcGetMempool,
+	"getChainInfo":       rpcGetChainInfo,
+}
+
+// HandleJSONRPC serves JSON-RPC 2.0 calls, single or batched, on top of the
+// same BlockChain methods the REST routes use.
+func HandleJSONRPC(c echo.Context) error {
+	body, err := ioutil.ReadAll(c.Request().Body)
+
+	if err != nil {
+		return c.JSON(http.StatusOK, rpcErrorResponse(nil, rpcParseError, "parse error"))
+	}
+
+	body = bytes.TrimSpace(body)
+
+	if len(body) > 0 && body[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(body, &batch); err != nil {
+			return c.JSON(http.StatusOK, rpcErrorResponse(nil, rpcParseError, "parse error"))
+		}
+
+		if len(batch) == 0 {
+			return c.JSON(http.StatusOK, rpcErrorResponse(nil, rpcInvalidRequest, "invalid request"))
+		}
+
+		var responses []*RPCResponse
+		for _, raw := range batch {
+			if res := handleRPCCall(raw); res != nil {
+				responses = append(responses, res)
+			}
+		}
+
+		if len(responses) == 0 {
+			return c.NoContent(http.StatusNoContent)
+		}
+
+		return c.JSON(http.StatusOK, responses)
+	}
+
+	res := handleRPCCall(body)
+
+	if res == nil {
+		return c.NoContent(http.StatusNoContent)
+	}
+
+	return c.JSON(http.StatusOK, res)
+}
+
+// handleRPCCall returns nil for notifications, which get no response.
+func handleRPCCall(raw json.RawMessage) *RPCResponse {
+	req := RPCRequest{}
+
+	if err := json.Unmarshal(raw, &req); err != nil {
+		if _, ok := err.(*json.SyntaxError); ok {
+			return rpcErrorResponse(nil, rpcParseError, "parse error")
+		}
+		return rpcErrorResponse(nil, rpcInvalidRequest, "invalid request")
+	}
+
+	if req.JSONRPC != jsonRPCVersion || req.Method == "" {
+		return rpcErrorResponse(req.ID, rpcInvalidRequest, "invalid request")
+	}
+
+	method, ok := rpcMethods[req.Method]
+
+	if !ok {
+		if req.ID == nil {
+			return nil
+		}
+		return rpcErrorResponse(req.ID, rpcMethodNotFound, "method not found")
+	}
+
+	result, rpcErr := method(chainStore["blockchain"], req.Params)
+
+	if req.ID == nil {
+		return nil
+	}
+
+	if rpcErr != nil {
+		return &RPCResponse{JSONRPC: jsonRPCVersion, Error: rpcErr, ID: req.ID}
+	}
+
+	return &RPCResponse{JSONRPC: jsonRPCVersion, Result: result, ID: req.ID}
+}
+
+func rpcErrorResponse(id json.RawMessage, code int, message string) *RPCResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+
+	return &RPCResponse{
+		JSONRPC: jsonRPCVersion,
+		Error:   &RPCError{Code: code, Message: message},
+		ID:      id,
+	}
+}
+
+// bindRPCParams accepts params by name ({"height": 1}) or by position
+// ([1]); positional values are matched against names in order.
+func bindRPCParams(params json.RawMessage, v interface{}, names ...string) *RPCError {
+	invalid := &RPCError{Code: rpcInvalidParams, Message: "invalid params"}
+	params = bytes.TrimSpace(params)
+
+	if len(params) == 0 {
+		return invalid
+	}
+
+	if params[0] == '[' {
+		var positional []json.RawMessage
+		if err := json.Unmarshal(params, &positional); err != nil || len(positional) > len(names) {
+			return invalid
+		}
+
+		named := make(map[string]json.RawMessage)
+		for i, p := range positional {
+			named[names[i]] = p
+		}
+
+		m, err := json.Marshal(named)
+		if err != nil {
+			return invalid
+		}
+		params = m
+	}
+
+	if err := json.Unmarshal(params, v); err != nil {
+		invalid.Data = err.Error()
+		return invalid
+	}
+
+	return nil
+}
+
+func rpcGetBlockByHeight(bc *blockchain.BlockChain, params json.RawMessage) (interface{}, *RPCError) {
+	p := struct {
+		Height *int64 `json:"height"`
+	}{}
+
+	if err := bindRPCParams(params, &p, "height"); err != nil {
+		return nil, err
+	}
+
+	if p.Height == nil {
+		return nil, &RPCError{Code: rpcInvalidParams, Message: "invalid params", Data: "height is required"}
+	}
+
+	b := bc.BlockByHeight(*p.Height)
+
+	if b == nil {
+		return nil, &RPCError{Code: rpcInvalidParams, Message: "block not found"}
+	}
+
+	return bc.View(b), nil
+}
+
+func rpcGetBalance(bc *blockchain.BlockChain, params json.RawMessage) (interface{}, *RPCError) {
+	p := struct {
+		Address string `json:"address"`
+	}{}
+
+	if err := bindRPCParams(params, &p, "address"); err != nil {
+		return nil, err
+	}
+
+	if p.Address == "" {
+		return nil, &RPCError{Code: rpcInvalidParams, Message: "invalid params", Data: "address is required"}
+	}
+
+	return struct {
+		Balance float32 `json:"balance"`
+	}{
+		Balance: bc.CalculateTotalAmount(p.Address),
+	}, nil
+}
+
+func rpcGetNonce(bc *blockchain.BlockChain, params json.RawMessage) (interface{}, *RPCError) {
+	p := struct {
+		Address string `json:"address"`
+	}{}
+
+	if err := bindRPCParams(params, &p, "address"); err != nil {
+		return nil, err
+	}
+
+	if p.Address == "" {
+		return nil, &RPCError{Code: rpcInvalidParams, Message: "invalid params", Data: "address is required"}
+	}
+
+	return struct {
+		Nonce uint64 `json:"nonce"`
+	}{
+		Nonce: bc.NextNonce(p.Address),
+	}, nil
+}
+
+func rpcSendRawTransaction(bc *blockchain.BlockChain, params json.RawMessage) (interface{}, *RPCError) {
+	req := blockchain.TransactionRequest{}
+
+	if params = bytes.TrimSpace(params); len(params) > 0 && params[0] == '[' {
+		var positional []json.RawMessage
+		if err := json.Unmarshal(params, &positional); err != nil || len(positional) != 1 {
+			return nil, &RPCError{Code: rpcInvalidParams, Message: "invalid params"}
+		}
+		params = positional[0]
+	}
+
+	if err := json.Unmarshal(params, &req); err != nil {
+		return nil, &RPCError{Code: rpcInvalidParams, Message: "invalid params", Data: err.Error()}
+	}
+
+	if err := req.Validate(); err != nil {
+		return nil, rpcErrorFrom(err)
+	}
+
+	if err := submitTransaction(bc, req); err != nil {
+		return nil, rpcErrorFrom(err)
+	}
+
+	return true, nil
+}
+
+// rpcErrorFrom keeps the structured API error as data so RPC clients see
+// the same machine-readable code as REST clients.
+func rpcErrorFrom(err error) *RPCError {
+	apiErr := apierror.From(err)
+
+	code := rpcTransactionRejected
+	if apiErr.Status == http.StatusBadRequest {
+		code = rpcInvalidParams
+	} else if apiErr.Status >= http.StatusInternalServerError {
+		code = rpcInternalError
+	}
+
+	return &RPCError{Code: code, Message: apiErr.Message, Data: apiErr}
+}
+
+func rpcGetMempool(bc *blockchain.BlockChain, params json.RawMessage) (interface{}, *RPCError) {
+	return transactionPoolResponse(bc), nil
+}
+
+func rpcGetChainInfo(bc *blockchain.BlockChain, params json.RawMessage) (interface{}, *RPCError) {
+	return bc.Info(), nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"../api_error"
+	"../block_chain"
+	"../config"
+	"../ordering"
+	"fmt"
+	"github.com/hashicorp/raft"
+	"github.com/labstack/echo"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+var raftNode *ordering.Node
+
+// raftURLs maps the Raft ID of each cluster member to its HTTP base URL.
+var raftURLs = make(map[string]string)
+
+// setupOrdering joins the Raft cluster of cfg.Raft. The chain only grows
+// through blocks the cluster commits.
+func setupOrdering(cfg *config.Node, chain *blockchain.BlockChain) {
+	if cfg.Raft.Address == "" || len(cfg.Raft.Members) == 0 {
+		log.Fatalln("raft.address and raft.members are required with Raft consensus")
+	}
+
+	var members []raft.Server
+	for _, member := range cfg.Raft.Members {
+		address, httpURL, _ := strings.Cut(member, "=")
+		members = append(members, raft.Server{ID: raft.ServerID(address), Address: raft.ServerAddress(address)})
+		raftURLs[address] = strings.TrimRight(httpURL, "/")
+	}
+
+	if _, ok := raftURLs[cfg.Raft.Address]; !ok {
+		log.Fatalf("raft.members does not list raft.address %s\n", cfg.Raft.Address)
+	}
+
+	advertise, err := net.ResolveTCPAddr("tcp", cfg.Raft.Address)
+
+	if err != nil {
+		log.Fatalln("raft.address:", err)
+	}
+
+	transport, err := raft.NewTCPTransport(cfg.Raft.Address, advertise, 3, 10*time.Second, os.Stderr)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	stores := ordering.InmemStores()
+
+	if cfg.Raft.DataDir != "" {
+		if stores, err = ordering.FileStores(cfg.Raft.DataDir); err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	raftConfig := raft.DefaultConfig()
+	raftConfig.LocalID = raft.ServerID(cfg.Raft.Address)
+	raftConfig.LogLevel = "INFO"
+
+	node, err := ordering.New(chain, raftConfig, transport, stores, time.Duration(cfg.Raft.BatchIntervalMillisecond)*time.Millisecond)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if err := node.Bootstrap(members); err != nil {
+		log.Fatalln(err)
+	}
+
+	log.Printf("raft %s with %d members\n", cfg.Raft.Address, len(members))
+	raftNode = node
+	go node.Run()
+}
+
+// forwardToLeader hands a transaction a follower received to the leader,
+// whose pool the next block is made from, and passes back its answer.
+func forwardToLeader(req blockchain.TransactionRequest) error {
+	id, _, err := raftNode.Leader()
+
+	if err != nil {
+		return err
+	}
+
+	leaderURL, ok := raftURLs[id]
+
+	if !ok {
+		return fmt.Errorf("%w: leader %s is not in raft.members", ordering.ErrNoLeader, id)
+	}
+
+	return postToPeer(leaderURL+"/transactions", chainStore["blockchain"].Info().GenesisHash, req)
+}
+
+// HandleRaftStatus shows this node's view of the Raft cluster.
+func HandleRaftStatus(c echo.Context) error {
+	if raftNode == nil {
+		return apierror.New(http.StatusNotFound, apierror.CodeNotFound, "Raft ordering is not enabled on this node", "")
+	}
+
+	return c.JSON(http.StatusOK, raftNode.Status())
+}
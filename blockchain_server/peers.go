@@ -0,0 +1,179 @@
+package main
+
+import (
+	"../api_error"
+	"../block"
+	"../block_chain"
+	"../finality"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GenesisHashHeader is sent by peers on every sync request and by this node
+// on every response, so both sides can tell they run the same network.
+const GenesisHashHeader = "X-Genesis-Hash"
+
+// requireSameGenesis refuses to serve peers that announce another genesis.
+func requireSameGenesis(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		genesisHash := chainStore["blockchain"].Info().GenesisHash
+		c.Response().Header().Set(GenesisHashHeader, genesisHash)
+
+		if peerHash := c.Request().Header.Get(GenesisHashHeader); peerHash != "" && peerHash != genesisHash {
+			return fmt.Errorf("%w: peer has %s", blockchain.ErrGenesisMismatch, peerHash)
+		}
+
+		return next(c)
+	}
+}
+
+func syncWithPeers(chain *blockchain.BlockChain, peers []string, interval time.Duration) {
+	for {
+		for _, peer := range peers {
+			if err := syncWithPeer(chain, strings.TrimRight(peer, "/")); err != nil {
+				log.Printf("sync with %s: %v\n", peer, err)
+			}
+
+			if gadget == nil {
+				continue
+			}
+
+			if err := syncVotesWithPeer(chain, strings.TrimRight(peer, "/")); err != nil {
+				log.Printf("finality votes from %s: %v\n", peer, err)
+			}
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// syncWithPeer adopts the peer's chain when it is longer than ours and
+// starts from the same genesis block.
+func syncWithPeer(chain *blockchain.BlockChain, peer string) error {
+	ours := chain.Info()
+	theirs := blockchain.ChainInfo{}
+
+	if err := getFromPeer(peer+"/chain-info", ours.GenesisHash, &theirs); err != nil {
+		return err
+	}
+
+	if theirs.GenesisHash != ours.GenesisHash {
+		return fmt.Errorf("%w: peer has %s, we have %s", blockchain.ErrGenesisMismatch, theirs.GenesisHash, ours.GenesisHash)
+	}
+
+	if theirs.Height <= ours.Height {
+		return nil
+	}
+
+	blocks := struct {
+		Blocks []*block.Block `json:"chains"`
+	}{}
+
+	if err := getFromPeer(peer+"/blocks", ours.GenesisHash, &blocks); err != nil {
+		return err
+	}
+
+	if err := chain.ReplaceChain(blocks.Blocks); err != nil {
+		return err
+	}
+
+	log.Printf("synced to height %d from %s\n", theirs.Height, peer)
+	return nil
+}
+
+// syncVotesWithPeer fetches the peer's finality votes above our finalized
+// height, in case some of them never reached us. A bad vote does not stop
+// the others from being counted.
+func syncVotesWithPeer(chain *blockchain.BlockChain, peer string) error {
+	votes := struct {
+		Votes []*finality.Vote `json:"votes"`
+	}{}
+
+	url := fmt.Sprintf("%s/finality/votes?from=%d", peer, chain.FinalizedHeight())
+
+	if err := getFromPeer(url, chain.Info().GenesisHash, &votes); err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, v := range votes.Votes {
+		if _, err := gadget.AddVote(v); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func getFromPeer(url string, genesisHash string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(GenesisHashHeader, genesisHash)
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return peerError(url, res)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func postToPeer(url string, genesisHash string, v interface{}) error {
+	body, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set(GenesisHashHeader, genesisHash)
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	res, err := http.DefaultClient.Do(req)
+
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= http.StatusBadRequest {
+		return peerError(url, res)
+	}
+
+	return nil
+}
+
+// peerError is the API error a peer answered with, so it can be passed on
+// as is.
+func peerError(url string, res *http.Response) error {
+	body := struct {
+		Error *apierror.Error `json:"error"`
+	}{}
+
+	if json.NewDecoder(res.Body).Decode(&body) == nil && body.Error != nil {
+		return body.Error
+	}
+
+	return fmt.Errorf("%s answered %d", url, res.StatusCode)
+}
@@ -0,0 +1,238 @@
+package chainparams
+
+import (
+	"../block"
+	"../signature_scheme"
+	"../transaction"
+	"fmt"
+	"strings"
+)
+
+const GenesisSender = "genesis"
+
+// StakeAddress holds staked funds. A transfer to it stakes the sender's
+// funds and a transfer from it, signed by the recipient, unstakes them.
+const StakeAddress = "STAKE"
+
+const (
+	ProofOfWork      = "pow"
+	ProofOfStake     = "pos"
+	ProofOfAuthority = "poa"
+	Raft             = "raft"
+)
+
+type Allocation struct {
+	Address string  `json:"address"`
+	Value   float32 `json:"value"`
+}
+
+// Checkpoint pins the hash of the block at Height. A chain holding another
+// block there is rejected, whatever its length.
+type Checkpoint struct {
+	Height int64  `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+// Params describes one network. Two nodes only agree on a chain when they
+// run with the same parameters.
+type Params struct {
+	Name                   string
+	AddressVersion         byte
+	MultisigAddressVersion byte
+	Bech32HRP              string
+	HDCoinType             uint32
+
+	GenesisTimestamp   int64
+	GenesisAllocations []Allocation
+	GenesisExtraData   string
+	// GenesisStakes are funded and staked in the genesis block, so a proof
+	// of stake network starts with validators.
+	GenesisStakes []Allocation
+	// GenesisSigners are the public keys, in signaturescheme.FormatPublicKey
+	// form, that start out producing proof-of-authority blocks.
+	GenesisSigners []string
+	// FinalityValidators are the public keys whose prevotes and precommits
+	// finalize blocks. Like the consensus engine and the signers, they are
+	// part of the genesis hash, so nodes that disagree on what makes a
+	// block final do not sync with each other.
+	FinalityValidators []string
+
+	InitialDifficulty                      int
+	MinimumDifficulty                      int
+	MaximumDifficulty                      int
+	DifficultyAdjustmentIntervalBlockCount int64
+	TargetBlockTimeMillisecond             int64
+
+	BlockReward           float32
+	RewardHalvingInterval int64
+
+	// Consensus names the engine: ProofOfWork, ProofOfStake,
+	// ProofOfAuthority or Raft. Proof of stake and authority divide time
+	// into slots with one producer each. Under proof of stake the validator
+	// set and the leader seed are fixed for an epoch of EpochLength slots.
+	// Under Raft a cluster leader orders the blocks and nothing is mined.
+	Consensus               string
+	SlotDurationMillisecond int64
+	EpochLength             int64
+	MinimumStake            float32
+
+	// Checkpoints are hard-coded into the network preset as the chain
+	// grows; a node may add more. Blocks up to the AssumeValid block are
+	// synced without checking their signatures, when a chain holds it.
+	// Neither changes what a valid chain is, so both are left out of the
+	// genesis hash.
+	Checkpoints []Checkpoint
+	AssumeValid string
+}
+
+var Mainnet = Params{
+	Name:                                   "mainnet",
+	AddressVersion:                         0x00,
+	MultisigAddressVersion:                 0x05,
+	Bech32HRP:                              "bx",
+	HDCoinType:                             0,
+	GenesisTimestamp:                       1672531200000,
+	InitialDifficulty:                      1,
+	MinimumDifficulty:                      1,
+	MaximumDifficulty:                      8,
+	DifficultyAdjustmentIntervalBlockCount: 20,
+	TargetBlockTimeMillisecond:             2000,
+	BlockReward:                            0.1,
+	RewardHalvingInterval:                  210000,
+	Consensus:                              ProofOfWork,
+	SlotDurationMillisecond:                2000,
+	EpochLength:                            32,
+	MinimumStake:                           100,
+}
+
+var Testnet = Params{
+	Name:                                   "testnet",
+	AddressVersion:                         0x6f,
+	MultisigAddressVersion:                 0xc4,
+	Bech32HRP:                              "tbx",
+	HDCoinType:                             1,
+	GenesisTimestamp:                       1672531200000,
+	InitialDifficulty:                      1,
+	MinimumDifficulty:                      1,
+	MaximumDifficulty:                      6,
+	DifficultyAdjustmentIntervalBlockCount: 20,
+	TargetBlockTimeMillisecond:             2000,
+	BlockReward:                            0.1,
+	RewardHalvingInterval:                  210000,
+	Consensus:                              ProofOfWork,
+	SlotDurationMillisecond:                2000,
+	EpochLength:                            32,
+	MinimumStake:                           10,
+}
+
+// Regtest mines instantly: difficulty zero means every hash is accepted and
+// the difficulty is never adjusted.
+var Regtest = Params{
+	Name:                                   "regtest",
+	AddressVersion:                         0x7a,
+	MultisigAddressVersion:                 0xc5,
+	Bech32HRP:                              "bxrt",
+	HDCoinType:                             1,
+	GenesisTimestamp:                       1672531200000,
+	InitialDifficulty:                      0,
+	MinimumDifficulty:                      0,
+	MaximumDifficulty:                      0,
+	DifficultyAdjustmentIntervalBlockCount: 0,
+	TargetBlockTimeMillisecond:             0,
+	BlockReward:                            50,
+	RewardHalvingInterval:                  150,
+	Consensus:                              ProofOfWork,
+	SlotDurationMillisecond:                200,
+	EpochLength:                            4,
+	MinimumStake:                           1,
+}
+
+func ByName(name string) (*Params, error) {
+	for _, p := range []Params{Mainnet, Testnet, Regtest} {
+		if p.Name == name {
+			return &p, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown network %q", name)
+}
+
+func (p *Params) GenesisBlock() *block.Block {
+	var tx []*transaction.Transaction
+	for _, a := range p.GenesisAllocations {
+		tx = append(tx, transaction.New(GenesisSender, a.Address, a.Value))
+	}
+
+	for _, a := range p.GenesisStakes {
+		tx = append(tx, transaction.New(GenesisSender, a.Address, a.Value), transaction.New(a.Address, StakeAddress, a.Value))
+	}
+
+	// The consensus engine, signers and finality validators go into the
+	// extra data, so networks that differ in any of them do not share a
+	// genesis hash.
+	extraData := p.GenesisExtraData + "\nconsensus:" + p.Consensus
+	if len(p.GenesisSigners) > 0 {
+		extraData += "\nsigners:" + strings.Join(formattedKeys(p.GenesisSigners), ",")
+	}
+
+	if len(p.FinalityValidators) > 0 {
+		extraData += "\nfinality:" + strings.Join(formattedKeys(p.FinalityValidators), ",")
+	}
+
+	return block.NewGenesisBlock(p.GenesisTimestamp, tx, p.InitialDifficulty, extraData)
+}
+
+// formattedKeys rewrites public keys the way signaturescheme.FormatPublicKey
+// does, so one key spelled two ways still gives one genesis hash. Keys
+// that do not parse are kept as they are.
+func formattedKeys(keys []string) []string {
+	formatted := make([]string, len(keys))
+	for i, key := range keys {
+		formatted[i] = key
+
+		if publicKey, err := signaturescheme.ParseFormattedPublicKey(key); err == nil {
+			formatted[i] = signaturescheme.FormatPublicKey(publicKey)
+		}
+	}
+
+	return formatted
+}
+
+// Reward returns the mining reward for a block at height, halving every
+// RewardHalvingInterval blocks.
+func (p *Params) Reward(height int64) float32 {
+	reward := p.BlockReward
+
+	if p.RewardHalvingInterval <= 0 {
+		return reward
+	}
+
+	for i := height / p.RewardHalvingInterval; i > 0 && reward > 0; i-- {
+		reward /= 2
+	}
+
+	return reward
+}
+
+// Checkpoint returns the hash pinned at height, if any.
+func (p *Params) Checkpoint(height int64) (string, bool) {
+	for _, c := range p.Checkpoints {
+		if c.Height == height {
+			return c.Hash, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *Params) ClampDifficulty(difficulty int) int {
+	if difficulty < p.MinimumDifficulty {
+		return p.MinimumDifficulty
+	}
+
+	if difficulty > p.MaximumDifficulty {
+		return p.MaximumDifficulty
+	}
+
+	return difficulty
+}
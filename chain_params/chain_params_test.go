@@ -0,0 +1,48 @@
+package chainparams_test
+
+import (
+	"../chain_params"
+	"../signature_scheme"
+	"testing"
+)
+
+func newKey(t *testing.T) string {
+	t.Helper()
+	key, err := signaturescheme.P256.GenerateKey()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signaturescheme.FormatPublicKey(key.PublicKey())
+}
+
+func TestGenesisHashCoversConsensus(t *testing.T) {
+	signer, validator := newKey(t), newKey(t)
+	base := chainparams.Regtest
+	genesis := base.GenesisBlock().Hash
+
+	tests := []struct {
+		name   string
+		change func(p *chainparams.Params)
+	}{
+		{"consensus", func(p *chainparams.Params) { p.Consensus = chainparams.ProofOfStake }},
+		{"signers", func(p *chainparams.Params) { p.GenesisSigners = []string{signer} }},
+		{"finality validators", func(p *chainparams.Params) { p.FinalityValidators = []string{validator} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := base
+			tt.change(&params)
+
+			if params.GenesisBlock().Hash == genesis {
+				t.Error("the genesis hash did not change")
+			}
+
+			if params.GenesisBlock().Hash != params.GenesisBlock().Hash {
+				t.Error("the genesis hash is not reproducible")
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package chainparams
+
+import (
+	"../signature_scheme"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+)
+
+var ErrInvalidGenesis = errors.New("invalid genesis file")
+
+// Genesis is the on-disk description of a network's first block. Nodes
+// loading the same file build the same genesis hash.
+type Genesis struct {
+	Timestamp   int64        `json:"timestamp"`
+	Difficulty  int          `json:"difficulty"`
+	ExtraData   string       `json:"extraData"`
+	Allocations []Allocation `json:"allocations"`
+	Consensus   string       `json:"consensus,omitempty"`
+	Stakes      []Allocation `json:"stakes,omitempty"`
+	Signers     []string     `json:"signers,omitempty"`
+	// FinalityValidators enables the finality gadget.
+	FinalityValidators []string `json:"finalityValidators,omitempty"`
+}
+
+func LoadGenesis(path string) (*Genesis, error) {
+	content, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGenesis, err)
+	}
+
+	g := &Genesis{}
+
+	if err := json.Unmarshal(content, g); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidGenesis, path, err)
+	}
+
+	if err := g.Validate(); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+func (g *Genesis) Validate() error {
+	if g.Timestamp <= 0 {
+		return fmt.Errorf("%w: timestamp must be positive", ErrInvalidGenesis)
+	}
+
+	if g.Difficulty < 0 {
+		return fmt.Errorf("%w: difficulty must not be negative", ErrInvalidGenesis)
+	}
+
+	for i, a := range g.Allocations {
+		if a.Address == "" || a.Value <= 0 {
+			return fmt.Errorf("%w: allocation %d needs an address and a positive value", ErrInvalidGenesis, i)
+		}
+	}
+
+	if g.Consensus != "" && g.Consensus != ProofOfWork && g.Consensus != ProofOfStake && g.Consensus != ProofOfAuthority && g.Consensus != Raft {
+		return fmt.Errorf("%w: consensus must be %s, %s, %s or %s", ErrInvalidGenesis, ProofOfWork, ProofOfStake, ProofOfAuthority, Raft)
+	}
+
+	for i, a := range g.Stakes {
+		if a.Address == "" || a.Value <= 0 {
+			return fmt.Errorf("%w: stake %d needs an address and a positive value", ErrInvalidGenesis, i)
+		}
+	}
+
+	if g.Consensus == ProofOfStake && len(g.Stakes) == 0 {
+		return fmt.Errorf("%w: proof of stake needs at least one stake", ErrInvalidGenesis)
+	}
+
+	for i, signer := range g.Signers {
+		if _, err := signaturescheme.ParseFormattedPublicKey(signer); err != nil {
+			return fmt.Errorf("%w: signer %d: %v", ErrInvalidGenesis, i, err)
+		}
+	}
+
+	for i, validator := range g.FinalityValidators {
+		if _, err := signaturescheme.ParseFormattedPublicKey(validator); err != nil {
+			return fmt.Errorf("%w: finality validator %d: %v", ErrInvalidGenesis, i, err)
+		}
+	}
+
+	if g.Consensus == ProofOfAuthority && len(g.Signers) == 0 {
+		return fmt.Errorf("%w: proof of authority needs at least one signer", ErrInvalidGenesis)
+	}
+
+	return nil
+}
+
+// ApplyGenesis replaces the preset genesis of p with g. The difficulty
+// bounds are widened when needed so the genesis difficulty stays in range.
+func (p *Params) ApplyGenesis(g *Genesis) {
+	p.GenesisTimestamp = g.Timestamp
+	p.GenesisAllocations = g.Allocations
+	p.GenesisExtraData = g.ExtraData
+	p.GenesisStakes = g.Stakes
+	p.GenesisSigners = g.Signers
+
+	if len(g.FinalityValidators) > 0 {
+		p.FinalityValidators = g.FinalityValidators
+	}
+
+	p.InitialDifficulty = g.Difficulty
+
+	if g.Consensus != "" {
+		p.Consensus = g.Consensus
+	}
+
+	if g.Difficulty < p.MinimumDifficulty {
+		p.MinimumDifficulty = g.Difficulty
+	}
+
+	if g.Difficulty > p.MaximumDifficulty {
+		p.MaximumDifficulty = g.Difficulty
+	}
+}
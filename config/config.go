@@ -0,0 +1,190 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Validator is implemented by configs that check their own values after
+// loading.
+type Validator interface {
+	Validate() error
+}
+
+type field struct {
+	name   string
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	value  reflect.Value
+}
+
+// Load fills cfg, which must point to a struct already holding the defaults.
+// Values are applied in order of precedence, lowest first: defaults, the
+// YAML file given by -config or <envPrefix>_CONFIG, environment variables
+// named <envPrefix>_<env tag>, and finally command-line flags.
+func Load(name string, envPrefix string, cfg interface{}, args []string) error {
+	fields := collectFields(reflect.ValueOf(cfg).Elem(), "")
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv(envPrefix+"_CONFIG"), "path to a YAML config file")
+
+	flagValues := make(map[string]*string)
+	for _, f := range fields {
+		if f.flag == "" {
+			continue
+		}
+
+		defaultValue := fmt.Sprint(f.value.Interface())
+		if f.secret {
+			defaultValue = ""
+		}
+
+		flagValues[f.flag] = fs.String(f.flag, defaultValue, f.usage)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *configPath != "" {
+		content, err := ioutil.ReadFile(*configPath)
+
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+		}
+
+		if err := yaml.Unmarshal(content, cfg); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, *configPath, err)
+		}
+	}
+
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+
+		if v, ok := os.LookupEnv(envPrefix + "_" + f.env); ok {
+			if err := setValue(f.value, v); err != nil {
+				return fmt.Errorf("%w: %s_%s: %v", ErrInvalidConfig, envPrefix, f.env, err)
+			}
+		}
+	}
+
+	setFlags := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		setFlags[f.Name] = true
+	})
+
+	for _, f := range fields {
+		if !setFlags[f.flag] {
+			continue
+		}
+
+		if err := setValue(f.value, *flagValues[f.flag]); err != nil {
+			return fmt.Errorf("%w: -%s: %v", ErrInvalidConfig, f.flag, err)
+		}
+	}
+
+	if v, ok := cfg.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Print logs every effective value so startup output shows what the process
+// is actually running with. Fields tagged secret:"true" are masked.
+func Print(cfg interface{}) {
+	for _, f := range collectFields(reflect.ValueOf(cfg).Elem(), "") {
+		if f.secret {
+			log.Printf("config %s = ***\n", f.name)
+			continue
+		}
+
+		log.Printf("config %s = %v\n", f.name, f.value.Interface())
+	}
+}
+
+func collectFields(v reflect.Value, prefix string) []field {
+	var fields []field
+
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+
+		if name == "" || name == "-" {
+			continue
+		}
+
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		if sf.Type.Kind() == reflect.Struct {
+			fields = append(fields, collectFields(v.Field(i), name)...)
+			continue
+		}
+
+		fields = append(fields, field{
+			name:   name,
+			env:    sf.Tag.Get("env"),
+			flag:   sf.Tag.Get("flag"),
+			usage:  sf.Tag.Get("usage"),
+			secret: sf.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+
+	return fields
+}
+
+func setValue(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported config type %s", v.Kind())
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Chain picks a network preset. The numeric fields override the preset
+// when they are non-zero.
+type Chain struct {
+	Network                                string   `yaml:"network" env:"NETWORK" flag:"network" usage:"network preset: mainnet, testnet or regtest"`
+	MinerAddress                           string   `yaml:"minerAddress" env:"MINER_ADDRESS" flag:"miner-address" usage:"address mining rewards are paid to; defaults to the miner key"`
+	MiningReward                           float32  `yaml:"miningReward" env:"MINING_REWARD" flag:"mining-reward" usage:"override the network block reward"`
+	TargetBlockTimeMillisecond             int64    `yaml:"targetBlockTimeMillisecond" env:"TARGET_BLOCK_TIME_MS" flag:"target-block-time-ms" usage:"override the network block-time target in milliseconds"`
+	DifficultyAdjustmentIntervalBlockCount int64    `yaml:"difficultyAdjustmentIntervalBlockCount" env:"DIFFICULTY_ADJUSTMENT_INTERVAL" flag:"difficulty-adjustment-interval" usage:"override the number of blocks between difficulty adjustments"`
+	Consensus                              string   `yaml:"consensus" env:"CONSENSUS" flag:"consensus" usage:"override the consensus engine: pow, pos, poa or raft; a genesis file naming one wins"`
+	SlotDurationMillisecond                int64    `yaml:"slotDurationMillisecond" env:"SLOT_DURATION_MS" flag:"slot-duration-ms" usage:"override the proof-of-stake and proof-of-authority slot length in milliseconds"`
+	FinalityValidators                     []string `yaml:"finalityValidators" env:"FINALITY_VALIDATORS" flag:"finality-validators" usage:"comma-separated public keys that finalize blocks; a genesis file naming them wins"`
+	Checkpoints                            []string `yaml:"checkpoints" env:"CHECKPOINTS" flag:"checkpoints" usage:"comma-separated height:hash pairs added to the network's checkpoints"`
+	AssumeValid                            string   `yaml:"assumeValid" env:"ASSUME_VALID" flag:"assume-valid" usage:"hash of a block up to which synced block signatures are not checked"`
+}
+
+// Keystore points at the encrypted key files the node signs with. The
+// passphrase is read from the environment or the config file only, never
+// from a flag that would show it in the process list, and it is never
+// printed.
+type Keystore struct {
+	Dir        string `yaml:"dir" env:"KEYSTORE_DIR" flag:"keystore-dir" usage:"directory holding encrypted key files"`
+	MinerKey   string `yaml:"minerKey" env:"MINER_KEY" flag:"miner-key" usage:"address of the miner key; the first key, or a new one, when empty"`
+	Passphrase string `yaml:"passphrase" env:"KEYSTORE_PASSPHRASE" secret:"true"`
+}
+
+// Raft joins the node to a Raft cluster when the chain consensus is raft.
+// Each member is named by its Raft address, which is also its Raft ID.
+type Raft struct {
+	Address                  string   `yaml:"address" env:"RAFT_ADDRESS" flag:"raft-address" usage:"host:port the other members reach this node's Raft transport on"`
+	Members                  []string `yaml:"members" env:"RAFT_MEMBERS" flag:"raft-members" usage:"comma-separated raftAddress=httpURL pairs of every cluster member, this node included"`
+	DataDir                  string   `yaml:"dataDir" env:"RAFT_DATA_DIR" flag:"raft-data-dir" usage:"directory holding the Raft log and snapshots; kept in memory when empty"`
+	BatchIntervalMillisecond int64    `yaml:"batchIntervalMillisecond" env:"RAFT_BATCH_INTERVAL_MS" flag:"raft-batch-interval-ms" usage:"milliseconds between the blocks the leader batches from the pool"`
+}
+
+type Node struct {
+	HTTPAddress        string   `yaml:"httpAddress" env:"HTTP_ADDRESS" flag:"http-address" usage:"listen address of the REST, JSON-RPC and event API"`
+	GRPCAddress        string   `yaml:"grpcAddress" env:"GRPC_ADDRESS" flag:"grpc-address" usage:"listen address of the gRPC API"`
+	AdminAddress       string   `yaml:"adminAddress" env:"ADMIN_ADDRESS" flag:"admin-address" usage:"loopback listen address of the admin API, which steers this node's signer votes"`
+	GenesisFile        string   `yaml:"genesisFile" env:"GENESIS_FILE" flag:"genesis-file" usage:"JSON genesis file; a throwaway demo chain is built when empty"`
+	Peers              []string `yaml:"peers" env:"PEERS" flag:"peers" usage:"comma-separated base URLs of peer nodes to sync from"`
+	SyncIntervalSecond int64    `yaml:"syncIntervalSecond" env:"SYNC_INTERVAL_SECOND" flag:"sync-interval-second" usage:"seconds between peer sync rounds"`
+	Chain              Chain    `yaml:"chain"`
+	Keystore           Keystore `yaml:"keystore"`
+	Raft               Raft     `yaml:"raft"`
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ParseCheckpoint splits a "height:hash" checkpoint.
+func ParseCheckpoint(s string) (int64, string, error) {
+	heightStr, hash, ok := strings.Cut(s, ":")
+	height, err := strconv.ParseInt(heightStr, 10, 64)
+
+	if !ok || err != nil || height <= 0 || hash == "" {
+		return 0, "", fmt.Errorf("%w: checkpoint %q must be height:hash with a positive height", ErrInvalidConfig, s)
+	}
+
+	return height, hash, nil
+}
+
+func DefaultNode() *Node {
+	return &Node{
+		HTTPAddress:        ":5001",
+		GRPCAddress:        ":5002",
+		AdminAddress:       "localhost:5003",
+		SyncIntervalSecond: 10,
+		Chain: Chain{
+			Network: "mainnet",
+		},
+		Keystore: Keystore{
+			Dir: "keys",
+		},
+		Raft: Raft{
+			BatchIntervalMillisecond: 500,
+		},
+	}
+}
+
+func (n *Node) Validate() error {
+	var problems []string
+
+	if n.HTTPAddress == "" {
+		problems = append(problems, "httpAddress is required")
+	}
+
+	if n.GRPCAddress == "" {
+		problems = append(problems, "grpcAddress is required")
+	}
+
+	if n.HTTPAddress != "" && n.HTTPAddress == n.GRPCAddress {
+		problems = append(problems, "httpAddress and grpcAddress must differ")
+	}
+
+	// The admin API has no authentication; only local processes may reach it.
+	if host, _, err := net.SplitHostPort(n.AdminAddress); err != nil || !isLoopback(host) {
+		problems = append(problems, "adminAddress must be a loopback host:port, such as localhost:5003")
+	}
+
+	if n.AdminAddress == n.HTTPAddress || n.AdminAddress == n.GRPCAddress {
+		problems = append(problems, "adminAddress must differ from httpAddress and grpcAddress")
+	}
+
+	if len(n.Peers) > 0 && n.SyncIntervalSecond <= 0 {
+		problems = append(problems, "syncIntervalSecond must be positive when peers are configured")
+	}
+
+	for _, peer := range n.Peers {
+		if u, err := url.Parse(peer); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("peer %q must be an absolute URL", peer))
+		}
+	}
+
+	if n.Chain.Network != "mainnet" && n.Chain.Network != "testnet" && n.Chain.Network != "regtest" {
+		problems = append(problems, "chain.network must be mainnet, testnet or regtest")
+	}
+
+	if n.Keystore.Dir == "" {
+		problems = append(problems, "keystore.dir is required")
+	}
+
+	if n.Chain.MiningReward < 0 {
+		problems = append(problems, "chain.miningReward must not be negative")
+	}
+
+	if n.Chain.TargetBlockTimeMillisecond < 0 {
+		problems = append(problems, "chain.targetBlockTimeMillisecond must not be negative")
+	}
+
+	if n.Chain.DifficultyAdjustmentIntervalBlockCount < 0 {
+		problems = append(problems, "chain.difficultyAdjustmentIntervalBlockCount must not be negative")
+	}
+
+	if n.Chain.Consensus != "" && n.Chain.Consensus != "pow" && n.Chain.Consensus != "pos" && n.Chain.Consensus != "poa" && n.Chain.Consensus != "raft" {
+		problems = append(problems, "chain.consensus must be pow, pos, poa or raft")
+	}
+
+	if n.Chain.SlotDurationMillisecond < 0 {
+		problems = append(problems, "chain.slotDurationMillisecond must not be negative")
+	}
+
+	for _, checkpoint := range n.Chain.Checkpoints {
+		if _, _, err := ParseCheckpoint(checkpoint); err != nil {
+			problems = append(problems, fmt.Sprintf("chain.checkpoints: %q must be height:hash with a positive height", checkpoint))
+		}
+	}
+
+	for _, member := range n.Raft.Members {
+		address, httpURL, ok := strings.Cut(member, "=")
+
+		if u, err := url.Parse(httpURL); !ok || address == "" || err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("raft member %q must be raftAddress=httpURL", member))
+		}
+	}
+
+	if n.Chain.Consensus == "raft" && (n.Raft.Address == "" || len(n.Raft.Members) == 0) {
+		problems = append(problems, "raft.address and raft.members are required with chain.consensus raft")
+	}
+
+	// The demo chain is built by each node on its own, so the members
+	// would not share its blocks.
+	if n.Chain.Consensus == "raft" && n.GenesisFile == "" {
+		problems = append(problems, "genesisFile is required with chain.consensus raft")
+	}
+
+	if n.Raft.BatchIntervalMillisecond <= 0 {
+		problems = append(problems, "raft.batchIntervalMillisecond must be positive")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+type WalletApp struct {
+	HTTPAddress  string `yaml:"httpAddress" env:"HTTP_ADDRESS" flag:"http-address" usage:"listen address of the wallet app; it holds keys, so keep it on localhost"`
+	NodeURL      string `yaml:"nodeUrl" env:"NODE_URL" flag:"node-url" usage:"base URL of the blockchain node"`
+	TemplateDir  string `yaml:"templateDir" env:"TEMPLATE_DIR" flag:"template-dir" usage:"directory holding the HTML templates"`
+	Network      string `yaml:"network" env:"NETWORK" flag:"network" usage:"network preset of the node: mainnet, testnet or regtest"`
+	KeystoreDir  string `yaml:"keystoreDir" env:"KEYSTORE_DIR" flag:"keystore-dir" usage:"directory holding encrypted key files"`
+	DatabasePath string `yaml:"databasePath" env:"DATABASE_PATH" flag:"database-path" usage:"JSON file holding watch-only wallets and the address book"`
+	// AllowedOrigins are the web origins, besides the app's own page, whose
+	// scripts may call the API. The app signs with keystore keys, so the
+	// list is empty by default.
+	AllowedOrigins []string `yaml:"allowedOrigins" env:"ALLOWED_ORIGINS" flag:"allowed-origins" usage:"comma-separated origins, such as http://localhost:3000, that may call the API besides the app's own page"`
+}
+
+func DefaultWalletApp() *WalletApp {
+	return &WalletApp{
+		HTTPAddress:  "localhost:5000",
+		NodeURL:      "http://localhost:5001",
+		TemplateDir:  "templates",
+		Network:      "mainnet",
+		KeystoreDir:  "keys",
+		DatabasePath: "wallet_db.json",
+	}
+}
+
+func (w *WalletApp) Validate() error {
+	var problems []string
+
+	if w.HTTPAddress == "" {
+		problems = append(problems, "httpAddress is required")
+	}
+
+	if u, err := url.Parse(w.NodeURL); err != nil || u.Scheme == "" || u.Host == "" {
+		problems = append(problems, "nodeUrl must be an absolute URL")
+	}
+
+	if w.TemplateDir == "" {
+		problems = append(problems, "templateDir is required")
+	}
+
+	if w.KeystoreDir == "" {
+		problems = append(problems, "keystoreDir is required")
+	}
+
+	if w.DatabasePath == "" {
+		problems = append(problems, "databasePath is required")
+	}
+
+	if w.Network != "mainnet" && w.Network != "testnet" && w.Network != "regtest" {
+		problems = append(problems, "network must be mainnet, testnet or regtest")
+	}
+
+	for _, origin := range w.AllowedOrigins {
+		if origin == "*" {
+			problems = append(problems, "allowedOrigins cannot be *")
+		} else if u, err := url.Parse(origin); err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" {
+			problems = append(problems, fmt.Sprintf("allowed origin %q must be a scheme and host", origin))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package consensus
+
+import (
+	"../block"
+	"../chain_params"
+	"../transaction"
+	"../wallet"
+	"fmt"
+	"time"
+)
+
+// RewardSender is the sender of block rewards; its payouts are created by
+// the engine, never signed.
+const RewardSender = "BLOCKCHAIN_CORE"
+
+// RetryInterval is how long a miner waits after ErrNotProducer before it
+// tries again.
+const RetryInterval = 100 * time.Millisecond
+
+// Engine decides who may produce a block, what producing it pays and which
+// chain wins. Every method gets the blocks before the one in question,
+// starting at genesis.
+type Engine interface {
+	// Name is the chainparams.Params.Consensus value that selects the
+	// engine.
+	Name() string
+	// Prepare fills in the consensus fields of b, the next block after
+	// chain, before its transactions are final. ErrNotProducer means this
+	// node may not produce b now.
+	Prepare(chain []*block.Block, b *block.Block) error
+	// Rewards are the payouts for producing b. The chain adds them to its
+	// transactions before sealing.
+	Rewards(chain []*block.Block, b *block.Block) []*transaction.Transaction
+	// Seal completes b once its transactions are final and sets its hash.
+	Seal(chain []*block.Block, b *block.Block) error
+	// VerifyBlock checks that b may follow chain.
+	VerifyBlock(chain []*block.Block, b *block.Block) error
+	// Better is the fork-choice rule: it reports whether candidate should
+	// replace current. Both share the same genesis block.
+	Better(current []*block.Block, candidate []*block.Block) bool
+}
+
+// Staking is implemented by engines whose validators lock up funds at
+// chainparams.StakeAddress. Locked is the part of an address's balance,
+// returned by unstaking, that it may not spend in the next block.
+type Staking interface {
+	Stake(chain []*block.Block, address string) float32
+	Locked(chain []*block.Block, address string) float32
+}
+
+// EvidenceCollector is implemented by engines that punish producers for
+// signing two blocks for the same slot. Observe looks for such pairs
+// between our chain and one received from a peer.
+type EvidenceCollector interface {
+	ReportEvidence(chain []*block.Block, e *block.Evidence) error
+	Observe(current []*block.Block, candidate []*block.Block)
+}
+
+// SignatureSkipper is implemented by engines whose blocks carry
+// signatures. VerifyBlockWithoutSignatures checks b like VerifyBlock but
+// trusts its signatures, for blocks below the assume-valid block.
+type SignatureSkipper interface {
+	VerifyBlockWithoutSignatures(chain []*block.Block, b *block.Block) error
+}
+
+// New returns the engine params.Consensus names. Engines whose producers
+// sign blocks sign with producer; with a nil producer the node only
+// follows the chain.
+func New(params *chainparams.Params, producer *wallet.Wallet) (Engine, error) {
+	switch params.Consensus {
+	case "", chainparams.ProofOfWork:
+		return NewProofOfWork(params), nil
+	case chainparams.ProofOfStake:
+		return NewProofOfStake(params, producer), nil
+	case chainparams.ProofOfAuthority:
+		if len(params.GenesisSigners) == 0 {
+			return nil, ErrNoSigners
+		}
+
+		return NewProofOfAuthority(params, producer), nil
+	case chainparams.Raft:
+		return NewRaft(params), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownEngine, params.Consensus)
+}
+
+func payouts(b *block.Block) []*transaction.Transaction {
+	var paid []*transaction.Transaction
+	for _, t := range b.Data {
+		if t.GetSenderAddress() == RewardSender {
+			paid = append(paid, t)
+		}
+	}
+
+	return paid
+}
+
+// verifyRewards checks that the RewardSender payouts of b are exactly
+// expected, in order.
+func verifyRewards(b *block.Block, expected []*transaction.Transaction) error {
+	paid := payouts(b)
+
+	if len(paid) != len(expected) {
+		return fmt.Errorf("%w: block %d has %d payouts, expected %d", ErrInvalidReward, b.Index, len(paid), len(expected))
+	}
+
+	for i, t := range paid {
+		if t.GetRecipientAddress() != expected[i].GetRecipientAddress() || t.GetValue() != expected[i].GetValue() {
+			return fmt.Errorf("%w: block %d pays %.8f to %s, expected %.8f to %s", ErrInvalidReward, b.Index,
+				t.GetValue(), t.GetRecipientAddress(), expected[i].GetValue(), expected[i].GetRecipientAddress())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+package consensus
+
+import "errors"
+
+var (
+	ErrUnknownEngine   = errors.New("unknown consensus engine")
+	ErrNotProducer     = errors.New("node may not produce the next block")
+	ErrNoValidators    = errors.New("no validator holds the minimum stake")
+	ErrInvalidBlock    = errors.New("block breaks the consensus rules")
+	ErrInvalidReward   = errors.New("block pays the wrong reward")
+	ErrWrongProducer   = errors.New("block is produced out of turn")
+	ErrInvalidSeal     = errors.New("block signature is not valid")
+	ErrInvalidEvidence = errors.New("invalid double-signing evidence")
+	ErrInvalidVote     = errors.New("invalid signer vote")
+	ErrNoSigners       = errors.New("no signers configured")
+	ErrUnsupported     = errors.New("not supported by the consensus engine")
+)
@@ -0,0 +1,416 @@
+package consensus
+
+import (
+	"../block"
+	"../chain_params"
+	"../signature_scheme"
+	"../transaction"
+	"../wallet"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ProofOfAuthority lets a set of signer keys take turns producing blocks,
+// one slot each, in address order. Each block is signed by its producer. A
+// producer may carry one vote per block to authorize or to drop a signer;
+// once more than half of the signers agree, the set changes from the next
+// block on.
+type ProofOfAuthority struct {
+	params   *chainparams.Params
+	producer *wallet.Wallet
+
+	lock           sync.Mutex
+	lastSignedSlot int64
+	proposals      map[string]bool
+}
+
+type Signer struct {
+	Address   string `json:"address"`
+	PublicKey string `json:"publicKey"`
+}
+
+// Proposal is a vote this node casts in the blocks it produces.
+type Proposal struct {
+	PublicKey string `json:"publicKey"`
+	Authorize bool   `json:"authorize"`
+}
+
+// Tally counts the votes cast on chain for one key that have not yet
+// changed the signer set.
+type Tally struct {
+	PublicKey string `json:"publicKey"`
+	Authorize int    `json:"authorize"`
+	Drop      int    `json:"drop"`
+}
+
+func NewProofOfAuthority(params *chainparams.Params, producer *wallet.Wallet) *ProofOfAuthority {
+	return &ProofOfAuthority{
+		params:    params,
+		producer:  producer,
+		proposals: make(map[string]bool),
+	}
+}
+
+func (e *ProofOfAuthority) Name() string {
+	return chainparams.ProofOfAuthority
+}
+
+func (e *ProofOfAuthority) CurrentSlot(chain []*block.Block) int64 {
+	return slotOf(e.params, chain, time.Now().UnixMilli())
+}
+
+// Signers returns the signers after the last block of chain, sorted by
+// address.
+func (e *ProofOfAuthority) Signers(chain []*block.Block) []Signer {
+	return e.replay(chain).sorted()
+}
+
+// Tallies returns the open votes after the last block of chain.
+func (e *ProofOfAuthority) Tallies(chain []*block.Block) []Tally {
+	a := e.replay(chain)
+
+	tallies := []Tally{}
+	for key, votes := range a.votes {
+		t := Tally{PublicKey: key}
+		for _, authorize := range votes {
+			if authorize {
+				t.Authorize++
+			} else {
+				t.Drop++
+			}
+		}
+		tallies = append(tallies, t)
+	}
+
+	sort.Slice(tallies, func(i, j int) bool {
+		return tallies[i].PublicKey < tallies[j].PublicKey
+	})
+
+	return tallies
+}
+
+// InTurn returns the signer due to produce in slot on top of chain.
+func (e *ProofOfAuthority) InTurn(chain []*block.Block, slot int64) (Signer, error) {
+	return inTurn(e.replay(chain).sorted(), slot)
+}
+
+// Propose makes this node vote to authorize, or to drop, the signer with
+// publicKey in the blocks it produces, until the vote passes.
+func (e *ProofOfAuthority) Propose(publicKey string, authorize bool) error {
+	key, err := canonicalKey(publicKey)
+
+	if err != nil {
+		return err
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.proposals[key] = authorize
+	return nil
+}
+
+// Discard withdraws a proposal. Votes already on chain stay counted.
+func (e *ProofOfAuthority) Discard(publicKey string) error {
+	key, err := canonicalKey(publicKey)
+
+	if err != nil {
+		return err
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	delete(e.proposals, key)
+	return nil
+}
+
+func (e *ProofOfAuthority) Proposals() []Proposal {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	proposals := []Proposal{}
+	for key, authorize := range e.proposals {
+		proposals = append(proposals, Proposal{PublicKey: key, Authorize: authorize})
+	}
+
+	sort.Slice(proposals, func(i, j int) bool {
+		return proposals[i].PublicKey < proposals[j].PublicKey
+	})
+
+	return proposals
+}
+
+func (e *ProofOfAuthority) Prepare(chain []*block.Block, b *block.Block) error {
+	if e.producer == nil {
+		return fmt.Errorf("%w: node has no signer key", ErrNotProducer)
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	slot := slotOf(e.params, chain, b.TimeStamp)
+
+	if slot <= chain[len(chain)-1].Slot || slot <= e.lastSignedSlot {
+		return ErrNotProducer
+	}
+
+	a := e.replay(chain)
+	signer, err := inTurn(a.sorted(), slot)
+
+	if err != nil {
+		return err
+	}
+
+	if signer.Address != e.producer.BlockchainAddress() {
+		return ErrNotProducer
+	}
+
+	b.Slot = slot
+	b.Producer = e.producer.BlockchainAddress()
+	b.Vote = e.nextVote(a, b.Producer)
+	return nil
+}
+
+func (e *ProofOfAuthority) Rewards(chain []*block.Block, b *block.Block) []*transaction.Transaction {
+	return []*transaction.Transaction{transaction.New(RewardSender, b.Producer, e.params.Reward(b.Index))}
+}
+
+func (e *ProofOfAuthority) Seal(chain []*block.Block, b *block.Block) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := signBlock(b, e.producer); err != nil {
+		return err
+	}
+
+	e.lastSignedSlot = b.Slot
+	return nil
+}
+
+func (e *ProofOfAuthority) VerifyBlock(chain []*block.Block, b *block.Block) error {
+	return e.verifyBlock(chain, b, true)
+}
+
+// VerifyBlockWithoutSignatures skips the signer's signature; the turn and
+// votes are still checked.
+func (e *ProofOfAuthority) VerifyBlockWithoutSignatures(chain []*block.Block, b *block.Block) error {
+	return e.verifyBlock(chain, b, false)
+}
+
+func (e *ProofOfAuthority) verifyBlock(chain []*block.Block, b *block.Block, signatures bool) error {
+	if err := verifySlot(e.params, chain, b); err != nil {
+		return err
+	}
+
+	if len(b.Evidence) > 0 {
+		return fmt.Errorf("%w: block %d carries slashing evidence", ErrInvalidBlock, b.Index)
+	}
+
+	a := e.replay(chain)
+	signer, err := inTurn(a.sorted(), b.Slot)
+
+	if err != nil {
+		return err
+	}
+
+	if b.Producer != signer.Address {
+		return fmt.Errorf("%w: slot %d belongs to %s, not %s", ErrWrongProducer, b.Slot, signer.Address, b.Producer)
+	}
+
+	if b.Hash != b.Header().Hash() {
+		return fmt.Errorf("%w: block %d hash does not match its contents", ErrInvalidBlock, b.Index)
+	}
+
+	if signatures {
+		if err := verifySignedHeader(b.SignedHeader(), e.params); err != nil {
+			return err
+		}
+	}
+
+	if b.Vote != nil {
+		if err := a.checkVote(b.Vote, e.params); err != nil {
+			return fmt.Errorf("block %d: %w", b.Index, err)
+		}
+	}
+
+	return verifyRewards(b, e.Rewards(chain, b))
+}
+
+func (e *ProofOfAuthority) Better(current []*block.Block, candidate []*block.Block) bool {
+	return len(candidate) > len(current)
+}
+
+// nextVote picks a proposal that would change the signer set and that
+// voter has not voted for yet. The caller holds e.lock.
+func (e *ProofOfAuthority) nextVote(a *authorities, voter string) *block.Vote {
+	keys := make([]string, 0, len(e.proposals))
+	for key := range e.proposals {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		vote := &block.Vote{PublicKey: key, Authorize: e.proposals[key]}
+
+		if a.checkVote(vote, e.params) != nil {
+			continue
+		}
+
+		if cast, ok := a.votes[key][voter]; ok && cast == vote.Authorize {
+			continue
+		}
+
+		return vote
+	}
+
+	return nil
+}
+
+// authorities is the signer set and the open votes at one block.
+type authorities struct {
+	params  *chainparams.Params
+	signers map[string]string
+	votes   map[string]map[string]bool
+}
+
+func (e *ProofOfAuthority) replay(chain []*block.Block) *authorities {
+	a := &authorities{
+		params:  e.params,
+		signers: make(map[string]string),
+		votes:   make(map[string]map[string]bool),
+	}
+
+	for _, signer := range e.params.GenesisSigners {
+		publicKey, err := signaturescheme.ParseFormattedPublicKey(signer)
+
+		if err != nil {
+			continue
+		}
+
+		a.signers[wallet.AddressFromPublicKey(publicKey, e.params)] = signaturescheme.FormatPublicKey(publicKey)
+	}
+
+	for _, b := range chain[1:] {
+		if b.Vote != nil {
+			a.apply(b.Producer, b.Vote)
+		}
+	}
+
+	return a
+}
+
+// apply counts the vote of voter, and changes the signer set when more
+// than half of the signers agree. The votes on a key are cleared when it
+// joins or leaves, and a dropped signer's votes stop counting.
+func (a *authorities) apply(voter string, vote *block.Vote) {
+	key, err := canonicalKey(vote.PublicKey)
+
+	if err != nil {
+		return
+	}
+
+	if a.votes[key] == nil {
+		a.votes[key] = make(map[string]bool)
+	}
+	a.votes[key][voter] = vote.Authorize
+
+	agree := 0
+	for v, authorize := range a.votes[key] {
+		if _, ok := a.signers[v]; ok && authorize == vote.Authorize {
+			agree++
+		}
+	}
+
+	if agree <= len(a.signers)/2 {
+		return
+	}
+
+	delete(a.votes, key)
+	address := addressOfKey(key, a.params)
+
+	if vote.Authorize {
+		a.signers[address] = key
+		return
+	}
+
+	delete(a.signers, address)
+	for k, votes := range a.votes {
+		delete(votes, address)
+
+		if len(votes) == 0 {
+			delete(a.votes, k)
+		}
+	}
+}
+
+// checkVote refuses votes that would not change the signer set, and a
+// vote to drop the last signer.
+func (a *authorities) checkVote(vote *block.Vote, params *chainparams.Params) error {
+	key, err := canonicalKey(vote.PublicKey)
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidVote, err)
+	}
+
+	_, isSigner := a.signers[addressOfKey(key, params)]
+
+	if vote.Authorize && isSigner {
+		return fmt.Errorf("%w: %s is already a signer", ErrInvalidVote, key)
+	}
+
+	if !vote.Authorize && !isSigner {
+		return fmt.Errorf("%w: %s is not a signer", ErrInvalidVote, key)
+	}
+
+	if !vote.Authorize && len(a.signers) == 1 {
+		return fmt.Errorf("%w: the last signer cannot be dropped", ErrInvalidVote)
+	}
+
+	return nil
+}
+
+func (a *authorities) sorted() []Signer {
+	signers := make([]Signer, 0, len(a.signers))
+	for address, key := range a.signers {
+		signers = append(signers, Signer{Address: address, PublicKey: key})
+	}
+
+	sort.Slice(signers, func(i, j int) bool {
+		return signers[i].Address < signers[j].Address
+	})
+
+	return signers
+}
+
+func inTurn(signers []Signer, slot int64) (Signer, error) {
+	if len(signers) == 0 {
+		return Signer{}, ErrNoSigners
+	}
+
+	return signers[slot%int64(len(signers))], nil
+}
+
+// canonicalKey rewrites a public key to its FormatPublicKey form, so each
+// key is counted under one spelling.
+func canonicalKey(publicKey string) (string, error) {
+	key, err := signaturescheme.ParseFormattedPublicKey(publicKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	return signaturescheme.FormatPublicKey(key), nil
+}
+
+func addressOfKey(key string, params *chainparams.Params) string {
+	publicKey, err := signaturescheme.ParseFormattedPublicKey(key)
+
+	if err != nil {
+		return ""
+	}
+
+	return wallet.AddressFromPublicKey(publicKey, params)
+}
@@ -0,0 +1,262 @@
+package consensus_test
+
+import (
+	"../block"
+	"../chain_params"
+	"../consensus"
+	"../signature_scheme"
+	"../wallet"
+	"errors"
+	"testing"
+)
+
+// authorityNetwork is a proof-of-authority chain with one engine per key,
+// so whichever signer is in turn can produce. The observer, an engine
+// without a key, checks every block.
+type authorityNetwork struct {
+	params   *chainparams.Params
+	chain    []*block.Block
+	engines  map[string]*consensus.ProofOfAuthority
+	keys     map[string]string
+	observer *consensus.ProofOfAuthority
+}
+
+// newAuthorityNetwork starts a chain signed by the first signers of n
+// wallets; the others can be voted in.
+func newAuthorityNetwork(t *testing.T, n int, signers int) (*authorityNetwork, []string) {
+	t.Helper()
+	params := chainparams.Regtest
+	params.Consensus = chainparams.ProofOfAuthority
+
+	net := &authorityNetwork{
+		params:  &params,
+		engines: make(map[string]*consensus.ProofOfAuthority),
+		keys:    make(map[string]string),
+	}
+
+	var addresses []string
+	for i := 0; i < n; i++ {
+		w, err := wallet.NewWallet(&params)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key := signaturescheme.FormatPublicKey(w.PublicKey())
+		if i < signers {
+			params.GenesisSigners = append(params.GenesisSigners, key)
+		}
+
+		net.engines[w.BlockchainAddress()] = consensus.NewProofOfAuthority(&params, w)
+		net.keys[w.BlockchainAddress()] = key
+		addresses = append(addresses, w.BlockchainAddress())
+	}
+
+	net.chain = []*block.Block{params.GenesisBlock()}
+	net.observer = consensus.NewProofOfAuthority(&params, nil)
+	return net, addresses
+}
+
+// produce lets the signer in the next slot produce a block, has the
+// observer check it and appends it.
+func (net *authorityNetwork) produce(t *testing.T) *block.Block {
+	t.Helper()
+	tip := net.chain[len(net.chain)-1]
+	slot := tip.Slot + 1
+	signer, err := net.observer.InTurn(net.chain, slot)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := &block.Block{
+		Index:     tip.Index + 1,
+		TimeStamp: net.chain[0].TimeStamp + slot*net.params.SlotDurationMillisecond,
+		PrevHash:  tip.Hash,
+	}
+
+	e := net.engines[signer.Address]
+
+	if err := e.Prepare(net.chain, b); err != nil {
+		t.Fatal(err)
+	}
+
+	b.Data = e.Rewards(net.chain, b)
+
+	if err := e.Seal(net.chain, b); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := net.observer.VerifyBlock(net.chain, b); err != nil {
+		t.Fatal(err)
+	}
+
+	net.chain = append(net.chain, b)
+	return b
+}
+
+// produceUntil produces blocks until done holds, at most limit of them.
+func (net *authorityNetwork) produceUntil(t *testing.T, limit int, done func() bool) {
+	t.Helper()
+
+	for i := 0; i < limit && !done(); i++ {
+		net.produce(t)
+	}
+
+	if !done() {
+		t.Fatalf("not done after %d blocks", limit)
+	}
+}
+
+func (net *authorityNetwork) signers() map[string]bool {
+	signers := make(map[string]bool)
+	for _, s := range net.observer.Signers(net.chain) {
+		signers[s.Address] = true
+	}
+
+	return signers
+}
+
+func (net *authorityNetwork) tally(address string) consensus.Tally {
+	for _, tally := range net.observer.Tallies(net.chain) {
+		if tally.PublicKey == net.keys[address] {
+			return tally
+		}
+	}
+
+	return consensus.Tally{}
+}
+
+func (net *authorityNetwork) propose(t *testing.T, voter string, candidate string, authorize bool) {
+	t.Helper()
+
+	if err := net.engines[voter].Propose(net.keys[candidate], authorize); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func votes(blocks []*block.Block) int {
+	n := 0
+	for _, b := range blocks {
+		if b.Vote != nil {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestSignerVotes(t *testing.T) {
+	net, addresses := newAuthorityNetwork(t, 4, 3)
+	a, b, c, d := addresses[0], addresses[1], addresses[2], addresses[3]
+
+	// Two of three signers is a majority.
+	net.propose(t, a, d, true)
+	net.propose(t, b, d, true)
+
+	net.produceUntil(t, 6, func() bool { return votes(net.chain) == 1 })
+
+	if net.signers()[d] {
+		t.Fatal("one vote of three authorized a signer")
+	}
+
+	if tally := net.tally(d); tally.Authorize != 1 {
+		t.Fatalf("tally after one vote = %+v", tally)
+	}
+
+	net.produceUntil(t, 6, func() bool { return net.signers()[d] })
+
+	if n := votes(net.chain); n != 2 {
+		t.Errorf("%d votes were cast, want 2", n)
+	}
+
+	if tally := net.tally(d); tally != (consensus.Tally{}) {
+		t.Errorf("votes on an authorized signer stay open: %+v", tally)
+	}
+
+	// A vote that changes nothing is no longer cast.
+	for i := 0; i < 8; i++ {
+		if produced := net.produce(t); produced.Vote != nil {
+			t.Fatalf("block %d votes on %s after the vote passed", produced.Index, produced.Vote.PublicKey)
+		}
+	}
+
+	// Of four signers, two are not a majority, three are.
+	net.propose(t, a, c, false)
+	net.propose(t, b, c, false)
+	net.produceUntil(t, 8, func() bool { return net.tally(c).Drop == 2 })
+
+	for i := 0; i < 8; i++ {
+		net.produce(t)
+	}
+
+	if !net.signers()[c] || net.tally(c).Drop != 2 {
+		t.Fatalf("two votes of four: signers = %v, tally = %+v", net.signers(), net.tally(c))
+	}
+
+	net.propose(t, d, c, false)
+	net.produceUntil(t, 8, func() bool { return !net.signers()[c] })
+
+	if len(net.signers()) != 3 {
+		t.Errorf("signers = %v, want 3", net.signers())
+	}
+}
+
+func TestDroppedSignerVotesAreCleared(t *testing.T) {
+	net, addresses := newAuthorityNetwork(t, 4, 3)
+	a, b, c, d := addresses[0], addresses[1], addresses[2], addresses[3]
+
+	net.propose(t, c, d, true)
+	net.produceUntil(t, 6, func() bool { return net.tally(d).Authorize == 1 })
+
+	net.propose(t, a, c, false)
+	net.propose(t, b, c, false)
+	net.produceUntil(t, 8, func() bool { return !net.signers()[c] })
+
+	if tally := net.tally(d); tally != (consensus.Tally{}) {
+		t.Errorf("a dropped signer's vote still counts: %+v", tally)
+	}
+
+	// A needs B's vote as well now: C's no longer counts.
+	net.propose(t, a, d, true)
+	net.produceUntil(t, 6, func() bool { return net.tally(d).Authorize == 1 })
+
+	if net.signers()[d] {
+		t.Error("one vote of two authorized a signer")
+	}
+}
+
+func TestLastSignerCannotBeDropped(t *testing.T) {
+	net, addresses := newAuthorityNetwork(t, 1, 1)
+	only := addresses[0]
+	e := net.engines[only]
+
+	net.propose(t, only, only, false)
+
+	if b := net.produce(t); b.Vote != nil {
+		t.Fatal("the last signer voted to drop itself")
+	}
+
+	tip := net.chain[len(net.chain)-1]
+	b := &block.Block{
+		Index:     tip.Index + 1,
+		TimeStamp: net.chain[0].TimeStamp + (tip.Slot+1)*net.params.SlotDurationMillisecond,
+		PrevHash:  tip.Hash,
+		Slot:      tip.Slot + 1,
+		Producer:  only,
+		Vote:      &block.Vote{PublicKey: net.keys[only], Authorize: false},
+	}
+	b.Data = e.Rewards(net.chain, b)
+	b.Hash = b.Header().Hash()
+
+	if err := e.VerifyBlockWithoutSignatures(net.chain, b); !errors.Is(err, consensus.ErrInvalidVote) {
+		t.Errorf("dropping the last signer: error = %v, want %v", err, consensus.ErrInvalidVote)
+	}
+
+	b.Vote = &block.Vote{PublicKey: net.keys[only], Authorize: true}
+	b.Hash = b.Header().Hash()
+
+	if err := e.VerifyBlockWithoutSignatures(net.chain, b); !errors.Is(err, consensus.ErrInvalidVote) {
+		t.Errorf("authorizing a signer: error = %v, want %v", err, consensus.ErrInvalidVote)
+	}
+}
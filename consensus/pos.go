@@ -0,0 +1,456 @@
+package consensus
+
+import (
+	"../block"
+	"../chain_params"
+	"../transaction"
+	"../wallet"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ProofOfStake lets validators, addresses with at least MinimumStake staked,
+// take turns producing blocks. Time is cut into slots. The leader of a slot
+// is drawn by stake weight from a seed every node can recompute, and signs
+// the block it produces. A validator that signs two blocks for one slot
+// loses its whole stake once the evidence is in a block. Unstaked value
+// unbonds for EpochLength blocks, at least an epoch of slots: the staker
+// cannot spend it yet, and evidence in that time forfeits it for good.
+type ProofOfStake struct {
+	params   *chainparams.Params
+	producer *wallet.Wallet
+
+	lock           sync.Mutex
+	lastSignedSlot int64
+	evidence       []*block.Evidence
+}
+
+type Validator struct {
+	Address string  `json:"address"`
+	Stake   float32 `json:"stake"`
+}
+
+func NewProofOfStake(params *chainparams.Params, producer *wallet.Wallet) *ProofOfStake {
+	return &ProofOfStake{
+		params:   params,
+		producer: producer,
+	}
+}
+
+func (e *ProofOfStake) Name() string {
+	return chainparams.ProofOfStake
+}
+
+// Slot returns the slot timestamp falls in; the genesis block opens slot 0.
+func (e *ProofOfStake) Slot(chain []*block.Block, timestamp int64) int64 {
+	return slotOf(e.params, chain, timestamp)
+}
+
+func (e *ProofOfStake) CurrentSlot(chain []*block.Block) int64 {
+	return e.Slot(chain, time.Now().UnixMilli())
+}
+
+// Stakes replays chain and returns what each address has staked.
+func (e *ProofOfStake) Stakes(chain []*block.Block) map[string]float32 {
+	return e.ledger(chain).stakes
+}
+
+func (e *ProofOfStake) Stake(chain []*block.Block, address string) float32 {
+	return e.Stakes(chain)[address]
+}
+
+// Locked returns what address received by unstaking on chain and may not
+// spend in the next block: value still unbonding, and value forfeited by
+// evidence while it was.
+func (e *ProofOfStake) Locked(chain []*block.Block, address string) float32 {
+	l := e.ledger(chain)
+	return l.unbondingOf(address, nextHeight(chain)) + l.forfeited[address]
+}
+
+// Validators returns the validator set of the epoch slot falls in, sorted
+// by address. It is fixed by the stakes at the last block before the
+// epoch.
+func (e *ProofOfStake) Validators(chain []*block.Block, slot int64) []Validator {
+	validators := []Validator{}
+	for address, stake := range e.Stakes(e.epochSnapshot(chain, slot)) {
+		if stake >= e.params.MinimumStake {
+			validators = append(validators, Validator{Address: address, Stake: stake})
+		}
+	}
+
+	sort.Slice(validators, func(i, j int) bool {
+		return validators[i].Address < validators[j].Address
+	})
+
+	return validators
+}
+
+// Leader draws the producer of slot. The seed hashes the last block before
+// the epoch together with the slot, so every node draws the same leader
+// and the draw is fixed once the epoch starts. It is reproducible, not
+// unbiasable: the producer of that last block can try contents, or skip
+// its slot, until the hash favours it for the next epoch.
+func (e *ProofOfStake) Leader(chain []*block.Block, slot int64) (string, error) {
+	validators := e.Validators(chain, slot)
+
+	if len(validators) == 0 {
+		return "", ErrNoValidators
+	}
+
+	snapshot := e.epochSnapshot(chain, slot)
+	seed := sha256.Sum256([]byte(fmt.Sprintf("%s-%d", snapshot[len(snapshot)-1].Hash, slot)))
+
+	var total float64
+	for _, v := range validators {
+		total += float64(v.Stake)
+	}
+
+	target := float64(binary.BigEndian.Uint64(seed[:8])) / (math.MaxUint64 + 1.0) * total
+	for _, v := range validators {
+		if target < float64(v.Stake) {
+			return v.Address, nil
+		}
+		target -= float64(v.Stake)
+	}
+
+	return validators[len(validators)-1].Address, nil
+}
+
+func (e *ProofOfStake) Prepare(chain []*block.Block, b *block.Block) error {
+	if e.producer == nil {
+		return fmt.Errorf("%w: node has no validator key", ErrNotProducer)
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	// A slot is never signed twice, not even on another fork after a
+	// reorganization: that is what slashing punishes.
+	slot := e.Slot(chain, b.TimeStamp)
+
+	if slot <= chain[len(chain)-1].Slot || slot <= e.lastSignedSlot {
+		return ErrNotProducer
+	}
+
+	leader, err := e.Leader(chain, slot)
+
+	if err != nil {
+		return err
+	}
+
+	if leader != e.producer.BlockchainAddress() {
+		return ErrNotProducer
+	}
+
+	b.Slot = slot
+	b.Producer = leader
+	b.Evidence = e.pendingEvidence(chain)
+	return nil
+}
+
+func (e *ProofOfStake) Rewards(chain []*block.Block, b *block.Block) []*transaction.Transaction {
+	return []*transaction.Transaction{transaction.New(RewardSender, b.Producer, e.params.Reward(b.Index))}
+}
+
+func (e *ProofOfStake) Seal(chain []*block.Block, b *block.Block) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if err := signBlock(b, e.producer); err != nil {
+		return err
+	}
+
+	e.lastSignedSlot = b.Slot
+	return nil
+}
+
+func (e *ProofOfStake) VerifyBlock(chain []*block.Block, b *block.Block) error {
+	return e.verifyBlock(chain, b, true)
+}
+
+// VerifyBlockWithoutSignatures skips the producer's signature and those
+// of slashing evidence; the leader, stakes and rewards are still checked.
+func (e *ProofOfStake) VerifyBlockWithoutSignatures(chain []*block.Block, b *block.Block) error {
+	return e.verifyBlock(chain, b, false)
+}
+
+func (e *ProofOfStake) verifyBlock(chain []*block.Block, b *block.Block, signatures bool) error {
+	if err := verifySlot(e.params, chain, b); err != nil {
+		return err
+	}
+
+	if b.Vote != nil {
+		return fmt.Errorf("%w: block %d carries a signer vote", ErrInvalidBlock, b.Index)
+	}
+
+	leader, err := e.Leader(chain, b.Slot)
+
+	if err != nil {
+		return err
+	}
+
+	if b.Producer != leader {
+		return fmt.Errorf("%w: slot %d belongs to %s, not %s", ErrWrongProducer, b.Slot, leader, b.Producer)
+	}
+
+	if b.Hash != b.Header().Hash() {
+		return fmt.Errorf("%w: block %d hash does not match its contents", ErrInvalidBlock, b.Index)
+	}
+
+	if signatures {
+		if err := verifySignedHeader(b.SignedHeader(), e.params); err != nil {
+			return err
+		}
+	}
+
+	l := e.ledger(chain)
+	stakes := l.stakes
+	slashed := slashedOffences(chain)
+
+	for _, ev := range b.Evidence {
+		if err := e.checkEvidence(l, b.Index, slashed, ev, signatures); err != nil {
+			return err
+		}
+		slashed[offence(ev)] = true
+	}
+
+	for _, ev := range b.Evidence {
+		delete(stakes, ev.First.Producer)
+	}
+
+	for _, t := range b.Data {
+		if t.GetSenderAddress() == chainparams.StakeAddress && stakes[t.GetRecipientAddress()] < t.GetValue() {
+			return fmt.Errorf("%w: block %d unstakes %.8f for %s, more than is staked", ErrInvalidBlock, b.Index, t.GetValue(), t.GetRecipientAddress())
+		}
+		applyStakeTransaction(stakes, t)
+	}
+
+	return verifyRewards(b, e.Rewards(chain, b))
+}
+
+// Better prefers the longer chain; slots nobody produced in leave no block,
+// so the chain with more honest producers grows faster.
+func (e *ProofOfStake) Better(current []*block.Block, candidate []*block.Block) bool {
+	return len(candidate) > len(current)
+}
+
+// ReportEvidence queues proof of a double signature for the next block this
+// node produces.
+func (e *ProofOfStake) ReportEvidence(chain []*block.Block, ev *block.Evidence) error {
+	if err := e.checkEvidence(e.ledger(chain), nextHeight(chain), slashedOffences(chain), ev, true); err != nil {
+		return err
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for _, queued := range e.evidence {
+		if offence(queued) == offence(ev) {
+			return nil
+		}
+	}
+
+	e.evidence = append(e.evidence, ev)
+	return nil
+}
+
+// Observe reports every slot for which current and candidate hold two
+// different blocks from the same producer.
+func (e *ProofOfStake) Observe(current []*block.Block, candidate []*block.Block) {
+	bySlot := make(map[int64]*block.Block)
+	for _, b := range current[1:] {
+		bySlot[b.Slot] = b
+	}
+
+	for _, b := range candidate[1:] {
+		ours, ok := bySlot[b.Slot]
+
+		if !ok || ours.Producer != b.Producer || ours.Hash == b.Hash {
+			continue
+		}
+
+		ev := &block.Evidence{First: ours.SignedHeader(), Second: b.SignedHeader()}
+
+		if err := e.ReportEvidence(current, ev); err == nil {
+			log.Printf("%s signed two blocks for slot %d\n", b.Producer, b.Slot)
+		}
+	}
+}
+
+// checkEvidence checks ev for the block at height. The producer must still
+// have something to lose there: a stake, or value unbonding.
+func (e *ProofOfStake) checkEvidence(l *stakeLedger, height int64, slashed map[string]bool, ev *block.Evidence, signatures bool) error {
+	if err := verifyEvidence(ev, e.params, signatures); err != nil {
+		return err
+	}
+
+	if slashed[offence(ev)] {
+		return fmt.Errorf("%w: %s is already slashed for slot %d", ErrInvalidEvidence, ev.First.Producer, ev.First.Slot)
+	}
+
+	if !l.slashable(ev.First.Producer, height) {
+		return fmt.Errorf("%w: %s has no stake", ErrInvalidEvidence, ev.First.Producer)
+	}
+
+	return nil
+}
+
+// pendingEvidence returns the queued evidence that still slashes a stake on
+// chain, and forgets the rest. The caller holds e.lock.
+func (e *ProofOfStake) pendingEvidence(chain []*block.Block) []*block.Evidence {
+	l := e.ledger(chain)
+	slashed := slashedOffences(chain)
+
+	var pending []*block.Evidence
+	for _, ev := range e.evidence {
+		if !slashed[offence(ev)] && l.slashable(ev.First.Producer, nextHeight(chain)) {
+			pending = append(pending, ev)
+		}
+	}
+
+	e.evidence = pending
+	return pending
+}
+
+// epochSnapshot returns the blocks before the epoch slot falls in, at least
+// the genesis block.
+func (e *ProofOfStake) epochSnapshot(chain []*block.Block, slot int64) []*block.Block {
+	start := slot - slot%e.params.EpochLength
+
+	n := 1
+	for n < len(chain) && chain[n].Slot < start {
+		n++
+	}
+
+	return chain[:n]
+}
+
+// stakeLedger is what a chain holds at StakeAddress: the stakes, the value
+// unstaked but still unbonding, and the unbonding value slashed validators
+// forfeited. Value unstaked in the block at height h unbonds until h+period
+// and can be spent from the block after.
+type stakeLedger struct {
+	period    int64
+	stakes    map[string]float32
+	unbonding []unbonding
+	forfeited map[string]float32
+}
+
+// unbonding is value unstaked for address in the block at height.
+type unbonding struct {
+	address string
+	value   float32
+	height  int64
+}
+
+// ledger replays chain with an unbonding period of EpochLength blocks. A
+// block takes a slot, so that is at least an epoch.
+func (e *ProofOfStake) ledger(chain []*block.Block) *stakeLedger {
+	l := &stakeLedger{
+		period:    e.params.EpochLength,
+		stakes:    make(map[string]float32),
+		forfeited: make(map[string]float32),
+	}
+
+	for _, b := range chain {
+		l.apply(b)
+	}
+
+	return l
+}
+
+// apply updates l with b. Evidence goes first, so a validator cannot
+// unstake in the block that slashes it, and takes what the validator is
+// unbonding along with its stake.
+func (l *stakeLedger) apply(b *block.Block) {
+	var pending []unbonding
+	for _, u := range l.unbonding {
+		if u.height+l.period >= b.Index {
+			pending = append(pending, u)
+		}
+	}
+
+	for _, ev := range b.Evidence {
+		producer := ev.First.Producer
+		delete(l.stakes, producer)
+
+		var kept []unbonding
+		for _, u := range pending {
+			if u.address == producer {
+				l.forfeited[producer] += u.value
+			} else {
+				kept = append(kept, u)
+			}
+		}
+		pending = kept
+	}
+
+	for _, t := range b.Data {
+		applyStakeTransaction(l.stakes, t)
+
+		if t.GetSenderAddress() == chainparams.StakeAddress {
+			pending = append(pending, unbonding{address: t.GetRecipientAddress(), value: t.GetValue(), height: b.Index})
+		}
+	}
+
+	l.unbonding = pending
+}
+
+// unbondingOf returns what address still has unbonding at the block at
+// height.
+func (l *stakeLedger) unbondingOf(address string, height int64) float32 {
+	var value float32
+	for _, u := range l.unbonding {
+		if u.address == address && u.height+l.period >= height {
+			value += u.value
+		}
+	}
+
+	return value
+}
+
+// slashable reports whether evidence against address in the block at
+// height takes anything from it.
+func (l *stakeLedger) slashable(address string, height int64) bool {
+	return l.stakes[address] > 0 || l.unbondingOf(address, height) > 0
+}
+
+// nextHeight is the height of the block on top of chain.
+func nextHeight(chain []*block.Block) int64 {
+	return chain[len(chain)-1].Index + 1
+}
+
+func applyStakeTransaction(stakes map[string]float32, t *transaction.Transaction) {
+	if t.GetRecipientAddress() == chainparams.StakeAddress {
+		stakes[t.GetSenderAddress()] += t.GetValue()
+	}
+
+	if t.GetSenderAddress() == chainparams.StakeAddress {
+		stakes[t.GetRecipientAddress()] -= t.GetValue()
+
+		if stakes[t.GetRecipientAddress()] <= 0 {
+			delete(stakes, t.GetRecipientAddress())
+		}
+	}
+}
+
+func slashedOffences(chain []*block.Block) map[string]bool {
+	slashed := make(map[string]bool)
+	for _, b := range chain {
+		for _, ev := range b.Evidence {
+			slashed[offence(ev)] = true
+		}
+	}
+
+	return slashed
+}
+
+func offence(ev *block.Evidence) string {
+	return fmt.Sprintf("%s-%d", ev.First.Producer, ev.First.Slot)
+}
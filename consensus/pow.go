@@ -0,0 +1,161 @@
+package consensus
+
+import (
+	"../block"
+	"../chain_params"
+	"../hash"
+	"../transaction"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ProofOfWork is the original engine: a miner searches for the nonce that
+// gives the block hash Difficulty leading zeros, and the longest chain
+// wins. Producer only names the reward recipient; it is not signed.
+type ProofOfWork struct {
+	params *chainparams.Params
+}
+
+func NewProofOfWork(params *chainparams.Params) *ProofOfWork {
+	return &ProofOfWork{params: params}
+}
+
+func (e *ProofOfWork) Name() string {
+	return chainparams.ProofOfWork
+}
+
+func (e *ProofOfWork) Prepare(chain []*block.Block, b *block.Block) error {
+	b.Difficulty = e.difficulty(chain)
+	return nil
+}
+
+func (e *ProofOfWork) Rewards(chain []*block.Block, b *block.Block) []*transaction.Transaction {
+	return []*transaction.Transaction{transaction.New(RewardSender, b.Producer, e.params.Reward(b.Index))}
+}
+
+func (e *ProofOfWork) Seal(chain []*block.Block, b *block.Block) error {
+	data, err := marshalData(b)
+
+	if err != nil {
+		return err
+	}
+
+	b.Nonce = 0
+	h := e.hash(b, data)
+
+	for !strings.HasPrefix(h, strings.Repeat("0", b.Difficulty)) {
+		b.Nonce++
+		h = e.hash(b, data)
+	}
+
+	b.Hash = h
+	return nil
+}
+
+func (e *ProofOfWork) VerifyBlock(chain []*block.Block, b *block.Block) error {
+	if b.Slot != 0 || b.PublicKey != "" || b.Signature != "" || len(b.Evidence) > 0 || b.Vote != nil {
+		return fmt.Errorf("%w: block %d carries fields proof of work does not hash", ErrInvalidBlock, b.Index)
+	}
+
+	h, err := e.Hash(b)
+
+	if err != nil {
+		return err
+	}
+
+	if b.Hash != h {
+		return fmt.Errorf("%w: block %d hash does not match its contents", ErrInvalidBlock, b.Index)
+	}
+
+	if expected := e.difficulty(chain); b.Difficulty != expected {
+		return fmt.Errorf("%w: block %d has difficulty %d, expected %d", ErrInvalidBlock, b.Index, b.Difficulty, expected)
+	}
+
+	if !strings.HasPrefix(b.Hash, strings.Repeat("0", b.Difficulty)) {
+		return fmt.Errorf("%w: block %d hash does not meet difficulty %d", ErrInvalidBlock, b.Index, b.Difficulty)
+	}
+
+	expected := e.Rewards(chain, b)
+
+	// Blocks mined before blocks named their producer only name the
+	// reward recipient in the payout itself.
+	if paid := payouts(b); b.Producer == "" && len(paid) == 1 {
+		expected = []*transaction.Transaction{transaction.New(RewardSender, paid[0].GetRecipientAddress(), e.params.Reward(b.Index))}
+	}
+
+	return verifyRewards(b, expected)
+}
+
+// Better prefers the chain with more work, so a long chain of easy blocks
+// cannot replace a shorter one that took more hashing.
+func (e *ProofOfWork) Better(current []*block.Block, candidate []*block.Block) bool {
+	return e.work(candidate).Cmp(e.work(current)) > 0
+}
+
+// work sums the expected hashes behind blocks: a hash with difficulty
+// leading hex zeros takes 16^difficulty tries. Better runs before the
+// blocks are verified, so difficulties are clamped to the network's range.
+func (e *ProofOfWork) work(blocks []*block.Block) *big.Int {
+	total := new(big.Int)
+	for _, b := range blocks {
+		total.Add(total, new(big.Int).Lsh(big.NewInt(1), uint(4*e.params.ClampDifficulty(b.Difficulty))))
+	}
+
+	return total
+}
+
+// Hash covers the JSON form of the transactions so that a block decoded
+// from a peer hashes the same as it did on the node that mined it.
+func (e *ProofOfWork) Hash(b *block.Block) (string, error) {
+	data, err := marshalData(b)
+
+	if err != nil {
+		return "", err
+	}
+
+	return e.hash(b, data), nil
+}
+
+func (e *ProofOfWork) hash(b *block.Block, data []byte) string {
+	return hash.CalculateHash(fmt.Sprintf("%d-%d-%s-%s-%d-%s", b.Index, b.TimeStamp, b.PrevHash, data, b.Nonce, strings.Repeat("0", b.Difficulty)))
+}
+
+// marshalData is the JSON form of the transactions of b that block hashes
+// cover.
+func marshalData(b *block.Block) ([]byte, error) {
+	m, err := json.Marshal(b.Data)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: block %d transactions: %v", ErrInvalidBlock, b.Index, err)
+	}
+
+	return m, nil
+}
+
+func (e *ProofOfWork) difficulty(chain []*block.Block) int {
+	lastBlock := chain[len(chain)-1]
+	interval := e.params.DifficultyAdjustmentIntervalBlockCount
+
+	if interval > 0 && lastBlock.Index%interval == 0 && lastBlock.Index != 0 {
+		return e.params.ClampDifficulty(e.adjustDifficulty(chain))
+	}
+
+	return e.params.ClampDifficulty(lastBlock.Difficulty)
+}
+
+func (e *ProofOfWork) adjustDifficulty(chain []*block.Block) int {
+	var expectedProcessTime int64
+	prevAdjustmentBlock := chain[int64(len(chain))-e.params.DifficultyAdjustmentIntervalBlockCount]
+	expectedProcessTime = e.params.DifficultyAdjustmentIntervalBlockCount * e.params.TargetBlockTimeMillisecond
+	timeTaken := chain[len(chain)-1].TimeStamp - prevAdjustmentBlock.TimeStamp
+
+	if timeTaken < expectedProcessTime/2 {
+		return prevAdjustmentBlock.Difficulty + 1
+	} else if timeTaken > expectedProcessTime*2 {
+		return prevAdjustmentBlock.Difficulty - 1
+	}
+
+	return prevAdjustmentBlock.Difficulty
+}
@@ -0,0 +1,103 @@
+package consensus
+
+import (
+	"../block"
+	"../chain_params"
+	"../signature_scheme"
+	"../utils"
+	"../wallet"
+	"encoding/hex"
+	"fmt"
+)
+
+// signBlock sets the hash of b to its header hash and signs it with
+// producer.
+func signBlock(b *block.Block, producer *wallet.Wallet) error {
+	b.Hash = b.Header().Hash()
+	digest, err := headerDigest(b.Hash)
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", wallet.ErrSigningFailed, err)
+	}
+
+	signature, err := signaturescheme.Sign(producer.PrivateKey(), digest)
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", wallet.ErrSigningFailed, err)
+	}
+
+	b.PublicKey = signaturescheme.FormatPublicKey(producer.PublicKey())
+	b.Signature = signature.String()
+	return nil
+}
+
+// verifySignedHeader checks that h is signed by a key owning h.Producer.
+func verifySignedHeader(h *block.SignedHeader, params *chainparams.Params) error {
+	publicKey, err := signaturescheme.ParseFormattedPublicKey(h.PublicKey)
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSeal, err)
+	}
+
+	if wallet.AddressFromPublicKey(publicKey, params) != h.Producer {
+		return fmt.Errorf("%w: public key does not own %s", ErrInvalidSeal, h.Producer)
+	}
+
+	signature, err := utils.SignatureFromString(h.Signature)
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSeal, err)
+	}
+
+	digest, err := headerDigest(h.Header.Hash())
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSeal, err)
+	}
+
+	if !signaturescheme.Verify(publicKey, digest, signature) {
+		return fmt.Errorf("%w: block %d by %s", ErrInvalidSeal, h.Index, h.Producer)
+	}
+
+	return nil
+}
+
+// verifyEvidence checks that e holds two different headers for one slot,
+// both signed by the same producer. Without signatures the headers are
+// only compared.
+func verifyEvidence(e *block.Evidence, params *chainparams.Params, signatures bool) error {
+	if e == nil || e.First == nil || e.Second == nil {
+		return fmt.Errorf("%w: two signed headers are required", ErrInvalidEvidence)
+	}
+
+	if e.First.Slot != e.Second.Slot || e.First.Producer != e.Second.Producer {
+		return fmt.Errorf("%w: headers are not for the same slot and producer", ErrInvalidEvidence)
+	}
+
+	if e.First.Header.Hash() == e.Second.Header.Hash() {
+		return fmt.Errorf("%w: headers are the same", ErrInvalidEvidence)
+	}
+
+	if !signatures {
+		return nil
+	}
+
+	for _, h := range []*block.SignedHeader{e.First, e.Second} {
+		if err := verifySignedHeader(h, params); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidEvidence, err)
+		}
+	}
+
+	return nil
+}
+
+// headerDigest is the digest producers sign: the header hash as bytes.
+func headerDigest(headerHash string) ([]byte, error) {
+	digest, err := hex.DecodeString(headerHash)
+
+	if err != nil {
+		return nil, fmt.Errorf("header hash: %w: %v", utils.ErrInvalidHex, err)
+	}
+
+	return digest, nil
+}
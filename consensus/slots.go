@@ -0,0 +1,35 @@
+package consensus
+
+import (
+	"../block"
+	"../chain_params"
+	"fmt"
+	"time"
+)
+
+// slotOf returns the slot timestamp falls in; the genesis block opens
+// slot 0.
+func slotOf(params *chainparams.Params, chain []*block.Block, timestamp int64) int64 {
+	return (timestamp - chain[0].TimeStamp) / params.SlotDurationMillisecond
+}
+
+// verifySlot checks that b sits in a later slot than the tip of chain,
+// that its timestamp falls in that slot and that it is not from the
+// future.
+func verifySlot(params *chainparams.Params, chain []*block.Block, b *block.Block) error {
+	parent := chain[len(chain)-1]
+
+	if b.Slot <= parent.Slot {
+		return fmt.Errorf("%w: block %d slot %d does not follow slot %d", ErrInvalidBlock, b.Index, b.Slot, parent.Slot)
+	}
+
+	if slotOf(params, chain, b.TimeStamp) != b.Slot {
+		return fmt.Errorf("%w: block %d timestamp is outside slot %d", ErrInvalidBlock, b.Index, b.Slot)
+	}
+
+	if b.TimeStamp > time.Now().UnixMilli()+params.SlotDurationMillisecond {
+		return fmt.Errorf("%w: block %d is from the future", ErrInvalidBlock, b.Index)
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package events
+
+import (
+	"sync"
+)
+
+type Type string
+
+const (
+	BlockMined           Type = "block_mined"
+	TransactionAccepted  Type = "transaction_accepted"
+	TransactionConfirmed Type = "transaction_confirmed"
+	Reorg                Type = "reorg"
+	BlockFinalized       Type = "block_finalized"
+)
+
+const subscriptionBufferSize = 64
+
+type Event struct {
+	Type      Type        `json:"type"`
+	Addresses []string    `json:"addresses,omitempty"`
+	Data      interface{} `json:"data"`
+}
+
+type Subscription struct {
+	C         chan *Event
+	addresses map[string]bool
+}
+
+type Hub struct {
+	subscriptions map[*Subscription]bool
+	lock          sync.Mutex
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscriptions: make(map[*Subscription]bool),
+	}
+}
+
+// Subscribe registers a listener. When addresses is empty every event is
+// delivered, otherwise only events touching one of the addresses are.
+func (h *Hub) Subscribe(addresses []string) *Subscription {
+	s := &Subscription{
+		C:         make(chan *Event, subscriptionBufferSize),
+		addresses: make(map[string]bool),
+	}
+
+	for _, address := range addresses {
+		if address != "" {
+			s.addresses[address] = true
+		}
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.subscriptions[s] = true
+
+	return s
+}
+
+func (h *Hub) Unsubscribe(s *Subscription) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if _, ok := h.subscriptions[s]; ok {
+		delete(h.subscriptions, s)
+		close(s.C)
+	}
+}
+
+// Publish never blocks the caller; a subscriber that is not keeping up
+// misses events instead of stalling the miner.
+func (h *Hub) Publish(e *Event) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for s := range h.subscriptions {
+		if !s.matches(e) {
+			continue
+		}
+
+		select {
+		case s.C <- e:
+		default:
+		}
+	}
+}
+
+func (s *Subscription) matches(e *Event) bool {
+	if len(s.addresses) == 0 || len(e.Addresses) == 0 {
+		return true
+	}
+
+	for _, address := range e.Addresses {
+		if s.addresses[address] {
+			return true
+		}
+	}
+
+	return false
+}
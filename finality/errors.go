@@ -0,0 +1,9 @@
+package finality
+
+import "errors"
+
+var (
+	ErrInvalidVote  = errors.New("invalid finality vote")
+	ErrNotValidator = errors.New("key is not a finality validator")
+	ErrEquivocation = errors.New("validator already voted for another block")
+)
@@ -0,0 +1,367 @@
+package finality
+
+import (
+	"../block_chain"
+	"../events"
+	"../signature_scheme"
+	"../utils"
+	"../wallet"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+)
+
+const (
+	Prevote   = "prevote"
+	Precommit = "precommit"
+)
+
+// Vote is a validator's signed prevote or precommit for the block with
+// BlockHash at Height.
+type Vote struct {
+	Type      string `json:"type"`
+	Height    int64  `json:"height"`
+	BlockHash string `json:"blockHash"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+// Gadget finalizes blocks of a chain, whatever engine produces them, in the
+// style of Tendermint without rounds. Each validator casts at most one
+// prevote and one precommit per height. It prevotes for the block its chain
+// holds at that height, and precommits once more than two thirds prevoted
+// the same block. A block precommitted by more than two thirds is final.
+//
+// After precommitting, a validator is locked on that block: it only votes
+// on chains that contain it, unless more than two thirds prevoted a higher
+// block. Two conflicting blocks can then only both be final when more than
+// a third of the validators broke these rules.
+type Gadget struct {
+	chain      *blockchain.BlockChain
+	validators map[string]string
+	signer     *wallet.Wallet
+
+	// OnVote is called, outside the gadget's lock, with every vote the
+	// gadget learns for the first time, so it can be passed to peers.
+	OnVote func(v *Vote)
+
+	lock   sync.Mutex
+	votes  map[string]*Vote
+	locked *Vote
+}
+
+// New sets up a gadget for validators, given as formatted public keys.
+// The node votes when signer is one of them.
+func New(chain *blockchain.BlockChain, validators []string, signer *wallet.Wallet) (*Gadget, error) {
+	g := &Gadget{
+		chain:      chain,
+		validators: make(map[string]string),
+		votes:      make(map[string]*Vote),
+	}
+
+	for _, validator := range validators {
+		publicKey, err := signaturescheme.ParseFormattedPublicKey(validator)
+
+		if err != nil {
+			return nil, fmt.Errorf("finality validator: %w", err)
+		}
+
+		g.validators[wallet.AddressFromPublicKey(publicKey, chain.Params)] = signaturescheme.FormatPublicKey(publicKey)
+	}
+
+	if signer != nil {
+		if _, ok := g.validators[signer.BlockchainAddress()]; ok {
+			g.signer = signer
+		}
+	}
+
+	return g, nil
+}
+
+// Run votes and finalizes whenever the chain changes. It does not return.
+func (g *Gadget) Run() {
+	s := g.chain.Events().Subscribe(nil)
+	g.process()
+
+	for e := range s.C {
+		if e.Type == events.BlockMined || e.Type == events.Reorg {
+			g.process()
+		}
+	}
+}
+
+// Validators returns the validator addresses, sorted.
+func (g *Gadget) Validators() []string {
+	addresses := make([]string, 0, len(g.validators))
+	for address := range g.validators {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	return addresses
+}
+
+// IsValidator reports whether this node votes.
+func (g *Gadget) IsValidator() bool {
+	return g.signer != nil
+}
+
+// AddVote checks and records a vote from a peer. It reports false for a
+// vote it already had or one on a block that is already final.
+func (g *Gadget) AddVote(v *Vote) (bool, error) {
+	if err := g.verify(v); err != nil {
+		return false, err
+	}
+
+	if v.Height <= g.chain.FinalizedHeight() {
+		return false, nil
+	}
+
+	g.lock.Lock()
+	added, err := g.add(v)
+	g.lock.Unlock()
+
+	if err != nil || !added {
+		return false, err
+	}
+
+	g.notify([]*Vote{v})
+	g.process()
+	return true, nil
+}
+
+// Votes returns the votes above height, lowest first.
+func (g *Gadget) Votes(above int64) []*Vote {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	votes := []*Vote{}
+	for _, v := range g.votes {
+		if v.Height > above {
+			votes = append(votes, v)
+		}
+	}
+
+	sort.Slice(votes, func(i, j int) bool {
+		if votes[i].Height != votes[j].Height {
+			return votes[i].Height < votes[j].Height
+		}
+
+		return voteKey(votes[i]) < voteKey(votes[j])
+	})
+
+	return votes
+}
+
+// process casts this node's votes on the unfinalized part of the chain,
+// finalizes the highest block with a precommit quorum and forgets the votes
+// on final blocks.
+func (g *Gadget) process() {
+	g.lock.Lock()
+	cast, err := g.vote()
+	height, hash := g.finalizable()
+	g.lock.Unlock()
+
+	g.notify(cast)
+
+	if err != nil {
+		log.Println("finality:", err)
+	}
+
+	if hash != "" {
+		if err := g.chain.Finalize(height, hash); err != nil {
+			log.Println("finality:", err)
+		}
+	}
+
+	g.lock.Lock()
+	g.prune(g.chain.FinalizedHeight())
+	g.lock.Unlock()
+}
+
+// prune drops the votes at or below height. The caller holds g.lock.
+func (g *Gadget) prune(height int64) {
+	for key, v := range g.votes {
+		if v.Height <= height {
+			delete(g.votes, key)
+		}
+	}
+}
+
+// vote signs the prevotes and precommits this node owes and returns them,
+// stopping at the first that cannot be signed. The caller holds g.lock.
+func (g *Gadget) vote() ([]*Vote, error) {
+	if g.signer == nil {
+		return nil, nil
+	}
+
+	var cast []*Vote
+	tip := g.chain.Info().Height
+
+	for h := g.chain.FinalizedHeight() + 1; h <= tip; h++ {
+		b := g.chain.BlockByHeight(h)
+
+		if b == nil || !g.mayVote(h, b.Hash) {
+			continue
+		}
+
+		if g.own(Prevote, h) == nil {
+			v, err := g.sign(Prevote, h, b.Hash)
+
+			if err != nil {
+				return cast, err
+			}
+
+			cast = append(cast, v)
+		}
+
+		if g.own(Precommit, h) == nil && g.quorum(Prevote, h, b.Hash) {
+			v, err := g.sign(Precommit, h, b.Hash)
+
+			if err != nil {
+				return cast, err
+			}
+
+			g.locked = v
+			cast = append(cast, v)
+		}
+	}
+
+	return cast, nil
+}
+
+// mayVote applies the lock: our chain must still hold the block we last
+// precommitted, unless more than two thirds prevoted a higher block.
+func (g *Gadget) mayVote(height int64, hash string) bool {
+	if g.locked == nil {
+		return true
+	}
+
+	if b := g.chain.BlockByHeight(g.locked.Height); b != nil && b.Hash == g.locked.BlockHash {
+		return true
+	}
+
+	return height > g.locked.Height && g.quorum(Prevote, height, hash)
+}
+
+// finalizable returns the highest unfinalized block of the chain with a
+// precommit quorum, or an empty hash. The caller holds g.lock.
+func (g *Gadget) finalizable() (int64, string) {
+	for h := g.chain.Info().Height; h > g.chain.FinalizedHeight(); h-- {
+		if b := g.chain.BlockByHeight(h); b != nil && g.quorum(Precommit, h, b.Hash) {
+			return h, b.Hash
+		}
+	}
+
+	return 0, ""
+}
+
+// quorum reports whether more than two thirds of the validators cast a
+// vote of type for hash at height.
+func (g *Gadget) quorum(voteType string, height int64, hash string) bool {
+	count := 0
+	for _, v := range g.votes {
+		if v.Type == voteType && v.Height == height && v.BlockHash == hash {
+			count++
+		}
+	}
+
+	return 3*count > 2*len(g.validators)
+}
+
+func (g *Gadget) own(voteType string, height int64) *Vote {
+	return g.votes[fmt.Sprintf("%s-%d-%s", voteType, height, signaturescheme.FormatPublicKey(g.signer.PublicKey()))]
+}
+
+func (g *Gadget) sign(voteType string, height int64, hash string) (*Vote, error) {
+	v := &Vote{
+		Type:      voteType,
+		Height:    height,
+		BlockHash: hash,
+		PublicKey: signaturescheme.FormatPublicKey(g.signer.PublicKey()),
+	}
+
+	signature, err := signaturescheme.Sign(g.signer.PrivateKey(), g.digest(v))
+
+	if err != nil {
+		return nil, fmt.Errorf("%s at height %d: %w", voteType, height, err)
+	}
+
+	v.Signature = signature.String()
+	g.votes[voteKey(v)] = v
+	return v, nil
+}
+
+// add stores v unless the validator already cast a vote of its type at its
+// height. The caller holds g.lock.
+func (g *Gadget) add(v *Vote) (bool, error) {
+	if known, ok := g.votes[voteKey(v)]; ok {
+		if known.BlockHash != v.BlockHash {
+			return false, fmt.Errorf("%w: %s %s at height %d", ErrEquivocation, v.PublicKey, v.Type, v.Height)
+		}
+
+		return false, nil
+	}
+
+	g.votes[voteKey(v)] = v
+	return true, nil
+}
+
+// verify checks v and rewrites its public key in canonical form, so each
+// validator has a single vote key.
+func (g *Gadget) verify(v *Vote) error {
+	if v.Type != Prevote && v.Type != Precommit {
+		return fmt.Errorf("%w: type must be %s or %s", ErrInvalidVote, Prevote, Precommit)
+	}
+
+	if v.Height <= 0 || v.BlockHash == "" {
+		return fmt.Errorf("%w: height and blockHash are required", ErrInvalidVote)
+	}
+
+	publicKey, err := signaturescheme.ParseFormattedPublicKey(v.PublicKey)
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidVote, err)
+	}
+
+	if _, ok := g.validators[wallet.AddressFromPublicKey(publicKey, g.chain.Params)]; !ok {
+		return ErrNotValidator
+	}
+
+	v.PublicKey = signaturescheme.FormatPublicKey(publicKey)
+
+	signature, err := utils.SignatureFromString(v.Signature)
+
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidVote, err)
+	}
+
+	if !signaturescheme.Verify(publicKey, g.digest(v), signature) {
+		return fmt.Errorf("%w: signature does not match", ErrInvalidVote)
+	}
+
+	return nil
+}
+
+func (g *Gadget) notify(votes []*Vote) {
+	if g.OnVote == nil {
+		return
+	}
+
+	for _, v := range votes {
+		g.OnVote(v)
+	}
+}
+
+// digest binds a vote to the network through the genesis hash, so it
+// cannot be replayed on another chain.
+func (g *Gadget) digest(v *Vote) []byte {
+	h := sha256.Sum256([]byte(fmt.Sprintf("finality-%s-%s-%d-%s", g.chain.Info().GenesisHash, v.Type, v.Height, v.BlockHash)))
+	return h[:]
+}
+
+func voteKey(v *Vote) string {
+	return fmt.Sprintf("%s-%d-%s", v.Type, v.Height, v.PublicKey)
+}
@@ -0,0 +1,197 @@
+package finality_test
+
+import (
+	"../block_chain"
+	"../chain_params"
+	"../finality"
+	"../signature_scheme"
+	"../wallet"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newValidators(t *testing.T, params *chainparams.Params, n int) ([]*wallet.Wallet, []string) {
+	t.Helper()
+	var wallets []*wallet.Wallet
+	var keys []string
+
+	for i := 0; i < n; i++ {
+		w, err := wallet.NewWallet(params)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wallets = append(wallets, w)
+		keys = append(keys, signaturescheme.FormatPublicKey(w.PublicKey()))
+	}
+
+	return wallets, keys
+}
+
+func mine(t *testing.T, chain *blockchain.BlockChain, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		if err := chain.Mining(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// signVote signs a vote the way a validator's gadget does.
+func signVote(t *testing.T, chain *blockchain.BlockChain, w *wallet.Wallet, voteType string, height int64) *finality.Vote {
+	t.Helper()
+
+	return signVoteFor(t, chain, w, voteType, height, chain.BlockByHeight(height).Hash)
+}
+
+func signVoteFor(t *testing.T, chain *blockchain.BlockChain, w *wallet.Wallet, voteType string, height int64, hash string) *finality.Vote {
+	t.Helper()
+	v := &finality.Vote{
+		Type:      voteType,
+		Height:    height,
+		BlockHash: hash,
+		PublicKey: signaturescheme.FormatPublicKey(w.PublicKey()),
+	}
+
+	digest := sha256.Sum256([]byte(fmt.Sprintf("finality-%s-%s-%d-%s", chain.Info().GenesisHash, v.Type, v.Height, v.BlockHash)))
+	signature, err := signaturescheme.Sign(w.PrivateKey(), digest[:])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v.Signature = signature.String()
+	return v
+}
+
+func addVote(t *testing.T, g *finality.Gadget, v *finality.Vote) {
+	t.Helper()
+
+	if _, err := g.AddVote(v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestQuorumWithEquivocatingValidator(t *testing.T) {
+	params := chainparams.Regtest
+	chain := blockchain.New(&params)
+	validators, keys := newValidators(t, &params, 4)
+	a, b, c, d := validators[0], validators[1], validators[2], validators[3]
+
+	g, err := finality.New(chain, keys, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mine(t, chain, 1)
+
+	addVote(t, g, signVote(t, chain, a, finality.Precommit, 1))
+	addVote(t, g, signVote(t, chain, d, finality.Precommit, 1))
+
+	// d also precommits another block: refused, and its first precommit
+	// still counts once.
+	other := signVoteFor(t, chain, d, finality.Precommit, 1, chain.BlockByHeight(0).Hash)
+
+	if _, err := g.AddVote(other); !errors.Is(err, finality.ErrEquivocation) {
+		t.Fatalf("second precommit: error = %v, want %v", err, finality.ErrEquivocation)
+	}
+
+	if added, err := g.AddVote(signVote(t, chain, d, finality.Precommit, 1)); added || err != nil {
+		t.Fatalf("repeated precommit: added = %v, error = %v", added, err)
+	}
+
+	// Two of four is not more than two thirds.
+	if height := chain.FinalizedHeight(); height != 0 {
+		t.Fatalf("finalized height %d after 2 of 4 precommits", height)
+	}
+
+	addVote(t, g, signVote(t, chain, b, finality.Prevote, 1))
+
+	if height := chain.FinalizedHeight(); height != 0 {
+		t.Fatalf("a prevote finalized height %d", height)
+	}
+
+	addVote(t, g, signVote(t, chain, c, finality.Precommit, 1))
+
+	if height := chain.FinalizedHeight(); height != 1 {
+		t.Fatalf("finalized height %d after 3 of 4 precommits, want 1", height)
+	}
+
+	outsider, _ := newValidators(t, &params, 1)
+
+	if _, err := g.AddVote(signVote(t, chain, outsider[0], finality.Precommit, 1)); !errors.Is(err, finality.ErrNotValidator) {
+		t.Errorf("vote from outside the set: error = %v, want %v", err, finality.ErrNotValidator)
+	}
+}
+
+// votesOf returns the votes of w above height 0, by type and height.
+func votesOf(g *finality.Gadget, w *wallet.Wallet) map[string]string {
+	votes := make(map[string]string)
+	for _, v := range g.Votes(0) {
+		if v.PublicKey == signaturescheme.FormatPublicKey(w.PublicKey()) {
+			votes[fmt.Sprintf("%s-%d", v.Type, v.Height)] = v.BlockHash
+		}
+	}
+
+	return votes
+}
+
+func TestLockHoldsAcrossReorganization(t *testing.T) {
+	params := chainparams.Regtest
+	chain := blockchain.New(&params)
+	validators, keys := newValidators(t, &params, 4)
+	a, b, c, d := validators[0], validators[1], validators[2], validators[3]
+
+	g, err := finality.New(chain, keys, a)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mine(t, chain, 1)
+	locked := chain.BlockByHeight(1).Hash
+
+	// With b and c, a's own prevote makes a quorum: a precommits and locks
+	// on block 1.
+	addVote(t, g, signVote(t, chain, b, finality.Prevote, 1))
+	addVote(t, g, signVote(t, chain, c, finality.Prevote, 1))
+
+	if votes := votesOf(g, a); votes["precommit-1"] != locked {
+		t.Fatalf("a's votes = %v, want a precommit for %s", votes, locked)
+	}
+
+	// A fork without block 1 replaces the chain.
+	time.Sleep(2 * time.Millisecond)
+	fork := blockchain.New(&params)
+	mine(t, fork, 2)
+
+	if fork.BlockByHeight(1).Hash == locked {
+		t.Fatal("the fork shares block 1")
+	}
+
+	if err := chain.ReplaceChain(fork.Blocks()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Locked on a block the chain dropped, a votes on nothing new until
+	// more than two thirds prevoted a higher block.
+	addVote(t, g, signVote(t, chain, b, finality.Prevote, 2))
+	addVote(t, g, signVote(t, chain, c, finality.Prevote, 2))
+
+	if votes := votesOf(g, a); votes["prevote-2"] != "" || votes["precommit-2"] != "" || votes["precommit-1"] != locked {
+		t.Fatalf("a voted against its lock: %v", votes)
+	}
+
+	addVote(t, g, signVote(t, chain, d, finality.Prevote, 2))
+
+	want := chain.BlockByHeight(2).Hash
+	if votes := votesOf(g, a); votes["prevote-2"] != want || votes["precommit-2"] != want {
+		t.Fatalf("a's votes after a prevote quorum at height 2 = %v, want both for %s", votes, want)
+	}
+}
@@ -0,0 +1,10 @@
+package keystore
+
+import "errors"
+
+var (
+	ErrWrongPassphrase = errors.New("wrong passphrase")
+	ErrKeyNotFound     = errors.New("key not found in keystore")
+	ErrInvalidKeyFile  = errors.New("invalid key file")
+	ErrKeyExists       = errors.New("key already in keystore")
+)
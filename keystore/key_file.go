@@ -0,0 +1,192 @@
+package keystore
+
+import (
+	"../chain_params"
+	"../signature_scheme"
+	"../wallet"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"golang.org/x/crypto/scrypt"
+)
+
+const keyFileVersion = 1
+
+const (
+	cipherName = "aes-256-gcm"
+	kdfName    = "scrypt"
+)
+
+// Default scrypt cost; it is stored in every file so it can be raised
+// later without breaking existing keys. Files asking for more are refused,
+// so a planted key file cannot make unlocking take unbounded time or
+// memory.
+const (
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+	saltLength   = 32
+)
+
+// KeyFile is the on-disk form of one encrypted wallet key. Files without a
+// scheme hold P-256 keys.
+type KeyFile struct {
+	Version   int        `json:"version"`
+	Address   string     `json:"address"`
+	Network   string     `json:"network"`
+	Scheme    string     `json:"scheme,omitempty"`
+	PublicKey string     `json:"publicKey"`
+	Crypto    CryptoJSON `json:"crypto"`
+}
+
+type CryptoJSON struct {
+	Cipher     string    `json:"cipher"`
+	CipherText string    `json:"ciphertext"`
+	Nonce      string    `json:"nonce"`
+	KDF        string    `json:"kdf"`
+	KDFParams  KDFParams `json:"kdfparams"`
+}
+
+type KDFParams struct {
+	N      int    `json:"n"`
+	R      int    `json:"r"`
+	P      int    `json:"p"`
+	KeyLen int    `json:"keyLen"`
+	Salt   string `json:"salt"`
+}
+
+// EncryptKey seals the private key of w with a key derived from
+// passphrase. The address is authenticated too, so a file whose address was
+// edited no longer decrypts.
+func EncryptKey(w *wallet.Wallet, passphrase string, params *chainparams.Params) (*KeyFile, error) {
+	salt := make([]byte, saltLength)
+
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	kdfParams := KDFParams{
+		N:      scryptN,
+		R:      scryptR,
+		P:      scryptP,
+		KeyLen: scryptKeyLen,
+		Salt:   hex.EncodeToString(salt),
+	}
+
+	aead, err := newAEAD(passphrase, kdfParams)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	plainText := w.PrivateKey().Bytes()
+	cipherText := aead.Seal(nil, nonce, plainText, []byte(w.BlockchainAddress()))
+
+	return &KeyFile{
+		Version:   keyFileVersion,
+		Address:   w.BlockchainAddress(),
+		Network:   params.Name,
+		Scheme:    w.Scheme().Name(),
+		PublicKey: w.PublicKeyStr(),
+		Crypto: CryptoJSON{
+			Cipher:     cipherName,
+			CipherText: hex.EncodeToString(cipherText),
+			Nonce:      hex.EncodeToString(nonce),
+			KDF:        kdfName,
+			KDFParams:  kdfParams,
+		},
+	}, nil
+}
+
+func (k *KeyFile) Decrypt(passphrase string, params *chainparams.Params) (*wallet.Wallet, error) {
+	if k.Version != keyFileVersion || k.Crypto.Cipher != cipherName || k.Crypto.KDF != kdfName {
+		return nil, fmt.Errorf("%w: unsupported version, cipher or kdf", ErrInvalidKeyFile)
+	}
+
+	if k.Network != params.Name {
+		return nil, fmt.Errorf("%w: %s is a %s key", wallet.ErrWrongNetwork, k.Address, k.Network)
+	}
+
+	scheme, err := signaturescheme.ByName(k.Scheme)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+	}
+
+	cipherText, err := hex.DecodeString(k.Crypto.CipherText)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: ciphertext: %v", ErrInvalidKeyFile, err)
+	}
+
+	nonce, err := hex.DecodeString(k.Crypto.Nonce)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: nonce: %v", ErrInvalidKeyFile, err)
+	}
+
+	aead, err := newAEAD(passphrase, k.Crypto.KDFParams)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("%w: nonce has %d bytes", ErrInvalidKeyFile, len(nonce))
+	}
+
+	plainText, err := aead.Open(nil, nonce, cipherText, []byte(k.Address))
+
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	privateKey, err := scheme.PrivateKeyFromBytes(plainText)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+	}
+
+	w := wallet.FromPrivateKey(privateKey, params)
+
+	if w.BlockchainAddress() != k.Address {
+		return nil, fmt.Errorf("%w: key does not match address %s", ErrInvalidKeyFile, k.Address)
+	}
+
+	return w, nil
+}
+
+func newAEAD(passphrase string, p KDFParams) (cipher.AEAD, error) {
+	if p.N > scryptN || p.R > scryptR || p.P > scryptP || p.KeyLen != scryptKeyLen {
+		return nil, fmt.Errorf("%w: kdfparams n=%d r=%d p=%d keylen=%d exceed n=%d r=%d p=%d keylen=%d", ErrInvalidKeyFile, p.N, p.R, p.P, p.KeyLen, scryptN, scryptR, scryptP, scryptKeyLen)
+	}
+
+	salt, err := hex.DecodeString(p.Salt)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: salt: %v", ErrInvalidKeyFile, err)
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, p.N, p.R, p.P, p.KeyLen)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: kdfparams: %v", ErrInvalidKeyFile, err)
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: kdfparams: %v", ErrInvalidKeyFile, err)
+	}
+
+	return cipher.NewGCM(block)
+}
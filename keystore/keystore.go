@@ -0,0 +1,166 @@
+package keystore
+
+import (
+	"../chain_params"
+	"../signature_scheme"
+	"../wallet"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const keyFileExtension = ".json"
+
+// KeyStore keeps one encrypted key file per address in a directory.
+type KeyStore struct {
+	dir    string
+	params *chainparams.Params
+}
+
+func Open(dir string, params *chainparams.Params) (*KeyStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &KeyStore{
+		dir:    dir,
+		params: params,
+	}, nil
+}
+
+func (ks *KeyStore) Store(w *wallet.Wallet, passphrase string) error {
+	k, err := EncryptKey(w, passphrase, ks.params)
+
+	if err != nil {
+		return err
+	}
+
+	return SaveKeyFile(ks.path(w.BlockchainAddress()), k)
+}
+
+func (ks *KeyStore) Load(address string, passphrase string) (*wallet.Wallet, error) {
+	k, err := LoadKeyFile(ks.path(address))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return k.Decrypt(passphrase, ks.params)
+}
+
+// Addresses lists the keys in the directory that belong to this network.
+// A file that cannot be read is logged and skipped, so one corrupt file
+// does not hide the other keys.
+func (ks *KeyStore) Addresses() ([]string, error) {
+	files, err := ioutil.ReadDir(ks.dir)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), keyFileExtension) {
+			continue
+		}
+
+		k, err := LoadKeyFile(filepath.Join(ks.dir, f.Name()))
+
+		if err != nil {
+			log.Printf("keystore: skipping %s: %v\n", f.Name(), err)
+			continue
+		}
+
+		if k.Network != ks.params.Name {
+			continue
+		}
+
+		addresses = append(addresses, k.Address)
+	}
+
+	sort.Strings(addresses)
+	return addresses, nil
+}
+
+// PublicKey reads the public key of a stored key. It is kept in clear
+// text, so no passphrase is needed, e.g. to set up a multisig policy. The
+// key is written as signaturescheme.FormatPublicKey does.
+func (ks *KeyStore) PublicKey(address string) (string, error) {
+	k, err := LoadKeyFile(ks.path(address))
+
+	if err != nil {
+		return "", err
+	}
+
+	publicKey, err := signaturescheme.ParsePublicKey(k.Scheme, k.PublicKey)
+
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidKeyFile, err)
+	}
+
+	return signaturescheme.FormatPublicKey(publicKey), nil
+}
+
+func (ks *KeyStore) Has(address string) bool {
+	_, err := os.Stat(ks.path(address))
+	return err == nil
+}
+
+// ChangePassphrase re-encrypts a key under a new passphrase with a fresh
+// salt and nonce.
+func (ks *KeyStore) ChangePassphrase(address string, oldPassphrase string, newPassphrase string) error {
+	w, err := ks.Load(address, oldPassphrase)
+
+	if err != nil {
+		return err
+	}
+
+	return ks.Store(w, newPassphrase)
+}
+
+func (ks *KeyStore) path(address string) string {
+	return filepath.Join(ks.dir, filepath.Base(address)+keyFileExtension)
+}
+
+func LoadKeyFile(path string) (*KeyFile, error) {
+	content, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, filepath.Base(path))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	k := &KeyFile{}
+
+	if err := json.Unmarshal(content, k); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidKeyFile, path, err)
+	}
+
+	return k, nil
+}
+
+// SaveKeyFile writes through a temporary file so a crash never leaves a
+// half-written key behind.
+func SaveKeyFile(path string, k *KeyFile) error {
+	content, err := json.MarshalIndent(k, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,153 @@
+package keystore_test
+
+import (
+	"../chain_params"
+	"../keystore"
+	"../signature_scheme"
+	"../wallet"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func newWallet(t *testing.T, params *chainparams.Params) *wallet.Wallet {
+	t.Helper()
+	w, err := wallet.NewWallet(params)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+func TestStoreAndLoad(t *testing.T) {
+	params := chainparams.Regtest
+	ks, err := keystore.Open(t.TempDir(), &params)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := newWallet(t, &params)
+
+	if err := ks.Store(w, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := ks.Load(w.BlockchainAddress(), "correct horse")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.PrivateKeyStr() != w.PrivateKeyStr() || loaded.BlockchainAddress() != w.BlockchainAddress() {
+		t.Error("loaded key differs from the stored one")
+	}
+
+	publicKey, err := ks.PublicKey(w.BlockchainAddress())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if publicKey != signaturescheme.FormatPublicKey(w.PublicKey()) {
+		t.Errorf("PublicKey = %s", publicKey)
+	}
+
+	if _, err := ks.Load(w.BlockchainAddress(), "wrong horse"); !errors.Is(err, keystore.ErrWrongPassphrase) {
+		t.Errorf("wrong passphrase: error = %v, want %v", err, keystore.ErrWrongPassphrase)
+	}
+
+	if err := ks.ChangePassphrase(w.BlockchainAddress(), "correct horse", "battery staple"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ks.Load(w.BlockchainAddress(), "correct horse"); !errors.Is(err, keystore.ErrWrongPassphrase) {
+		t.Errorf("old passphrase after a change: error = %v, want %v", err, keystore.ErrWrongPassphrase)
+	}
+
+	if _, err := ks.Load(newWallet(t, &params).BlockchainAddress(), "correct horse"); !errors.Is(err, keystore.ErrKeyNotFound) {
+		t.Errorf("unknown address: error = %v, want %v", err, keystore.ErrKeyNotFound)
+	}
+}
+
+func TestDecryptRefusesTamperedFiles(t *testing.T) {
+	params := chainparams.Regtest
+	w := newWallet(t, &params)
+	other := newWallet(t, &params)
+
+	tests := []struct {
+		name   string
+		tamper func(k *keystore.KeyFile)
+		err    error
+	}{
+		{"address", func(k *keystore.KeyFile) { k.Address = other.BlockchainAddress() }, keystore.ErrWrongPassphrase},
+		{"network", func(k *keystore.KeyFile) { k.Network = chainparams.Mainnet.Name }, wallet.ErrWrongNetwork},
+		{"scrypt n above the default", func(k *keystore.KeyFile) { k.Crypto.KDFParams.N *= 2 }, keystore.ErrInvalidKeyFile},
+		{"scrypt r above the default", func(k *keystore.KeyFile) { k.Crypto.KDFParams.R *= 2 }, keystore.ErrInvalidKeyFile},
+		{"scrypt p above the default", func(k *keystore.KeyFile) { k.Crypto.KDFParams.P *= 2 }, keystore.ErrInvalidKeyFile},
+		{"key length", func(k *keystore.KeyFile) { k.Crypto.KDFParams.KeyLen = 16 }, keystore.ErrInvalidKeyFile},
+		{"cipher", func(k *keystore.KeyFile) { k.Crypto.Cipher = "aes-128-ctr" }, keystore.ErrInvalidKeyFile},
+		{"nonce", func(k *keystore.KeyFile) { k.Crypto.Nonce = "00" }, keystore.ErrInvalidKeyFile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := keystore.EncryptKey(w, "correct horse", &params)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tt.tamper(k)
+
+			if _, err := k.Decrypt("correct horse", &params); !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestAddressesSkipsOtherFiles(t *testing.T) {
+	params := chainparams.Regtest
+	dir := t.TempDir()
+	ks, err := keystore.Open(dir, &params)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := newWallet(t, &params)
+
+	if err := ks.Store(w, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	mainnet := chainparams.Mainnet
+	other, err := keystore.Open(dir, &mainnet)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := other.Store(newWallet(t, &mainnet), "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "corrupt.json"), []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	addresses, err := ks.Addresses()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{w.BlockchainAddress()}; !reflect.DeepEqual(addresses, want) {
+		t.Errorf("Addresses = %v, want %v", addresses, want)
+	}
+}
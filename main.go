@@ -1,8 +1,8 @@
 package main
 
 import (
-	"./block"
 	"./block_chain"
+	"./chain_params"
 	"./transaction"
 	"./wallet"
 	"fmt"
@@ -17,25 +17,53 @@ func main() {
 func partSecond() {
 	fmt.Printf("%s example part two \n", strings.Repeat("=", 25))
 
-	minerWallet := wallet.NewWallet()
-	walletUserA := wallet.NewWallet()
-	walletUserB := wallet.NewWallet()
-	//walletUserC := wallet.NewWallet()
+	params := chainparams.Testnet
+	minerWallet, err := wallet.NewWallet(&params)
 
-	chain := blockchain.New()
+	if err != nil {
+		panic(err)
+	}
 
-	t := wallet.NewTransaction(minerWallet.PrivateKey(), minerWallet.PublicKey(), minerWallet.BlockchainAddress(), walletUserB.BlockchainAddress(), 1000)
-	t2 := wallet.NewTransaction(walletUserB.PrivateKey(), walletUserB.PublicKey(), walletUserB.BlockchainAddress(), walletUserA.BlockchainAddress(), 100)
+	walletUserA, err := wallet.NewWallet(&params)
+
+	if err != nil {
+		panic(err)
+	}
+
+	walletUserB, err := wallet.NewWallet(&params)
+
+	if err != nil {
+		panic(err)
+	}
+
+	//walletUserC := wallet.NewWallet(&params)
+
+	params.GenesisAllocations = []chainparams.Allocation{{Address: minerWallet.BlockchainAddress(), Value: 3000}}
+	chain := blockchain.New(&params)
+
+	t := wallet.NewTransaction(minerWallet.PrivateKey(), minerWallet.PublicKey(), minerWallet.BlockchainAddress(), walletUserB.BlockchainAddress(), 1000, 1)
+	t2 := wallet.NewTransaction(walletUserB.PrivateKey(), walletUserB.PublicKey(), walletUserB.BlockchainAddress(), walletUserA.BlockchainAddress(), 100, 1)
 
-	chain.BlockList = append(chain.BlockList, block.CreateGenesisBlock([]*transaction.Transaction{transaction.New("genesis", minerWallet.BlockchainAddress(), 3000)}))
 	chain.Mining()
 
-	isOk := chain.AddTransaction(minerWallet.BlockchainAddress(), walletUserB.BlockchainAddress(), 1000, minerWallet.PublicKey(), t.GenerateSignature())
-	fmt.Printf("is ok, %v \n", isOk)
+	signature, err := t.GenerateSignature()
+
+	if err != nil {
+		panic(err)
+	}
+
+	err = chain.AddTransaction(minerWallet.BlockchainAddress(), walletUserB.BlockchainAddress(), 1000, 1, minerWallet.PublicKey(), signature)
+	fmt.Printf("is ok, %v \n", err == nil)
 	chain.Mining()
 
-	isOk = chain.AddTransaction(walletUserB.BlockchainAddress(), walletUserA.BlockchainAddress(), 100, walletUserB.PublicKey(), t2.GenerateSignature())
-	fmt.Printf("is ok, %v \n", isOk)
+	signature, err = t2.GenerateSignature()
+
+	if err != nil {
+		panic(err)
+	}
+
+	err = chain.AddTransaction(walletUserB.BlockchainAddress(), walletUserA.BlockchainAddress(), 100, 1, walletUserB.PublicKey(), signature)
+	fmt.Printf("is ok, %v \n", err == nil)
 
 	chain.Mining()
 	fmt.Printf("A %.1f\n", chain.CalculateTotalAmount(walletUserA.BlockchainAddress()))
@@ -48,9 +76,9 @@ func partSecond() {
 func partOne() {
 	fmt.Printf("%s wallet example part one \n", strings.Repeat("=", 25))
 
-	chain := blockchain.New()
-
-	chain.BlockList = append(chain.BlockList, block.CreateGenesisBlock([]*transaction.Transaction{transaction.New("A", "B", 3)}))
+	params := chainparams.Regtest
+	params.GenesisAllocations = []chainparams.Allocation{{Address: "B", Value: 3}}
+	chain := blockchain.New(&params)
 
 	chain.TransactionPool = append(chain.TransactionPool, transaction.New("B", "B", 3))
 	chain.TransactionPool = append(chain.TransactionPool, transaction.New("B", "B", 2))
@@ -81,15 +109,26 @@ func partOne() {
 
 	fmt.Println("hello")
 
-	w := wallet.NewWallet()
+	w, err := wallet.NewWallet(&params)
+
+	if err != nil {
+		panic(err)
+	}
+
 	fmt.Println(w.PrivateKeyStr())
 	fmt.Println(w.PublicKeyStr())
 
 	fmt.Printf("wallet address -> %s \n", w.BlockchainAddress())
 
-	t := wallet.NewTransaction(w.PrivateKey(), w.PublicKey(), w.BlockchainAddress(), "recipientAddress", 1.42)
+	t := wallet.NewTransaction(w.PrivateKey(), w.PublicKey(), w.BlockchainAddress(), "recipientAddress", 1.42, 1)
+
+	signature, err := t.GenerateSignature()
+
+	if err != nil {
+		panic(err)
+	}
 
-	fmt.Printf("signature %s \n", t.GenerateSignature())
+	fmt.Printf("signature %s \n", signature)
 
 	fmt.Printf("%s wallet example one Done \n", strings.Repeat("=", 25))
 }
@@ -0,0 +1,1183 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: node_rpc/node.proto
+
+package noderpc
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type MempoolEvent_Type int32
+
+const (
+	MempoolEvent_TYPE_UNSPECIFIED      MempoolEvent_Type = 0
+	MempoolEvent_TRANSACTION_ACCEPTED  MempoolEvent_Type = 1
+	MempoolEvent_TRANSACTION_CONFIRMED MempoolEvent_Type = 2
+)
+
+// Enum value maps for MempoolEvent_Type.
+var (
+	MempoolEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TRANSACTION_ACCEPTED",
+		2: "TRANSACTION_CONFIRMED",
+	}
+	MempoolEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED":      0,
+		"TRANSACTION_ACCEPTED":  1,
+		"TRANSACTION_CONFIRMED": 2,
+	}
+)
+
+func (x MempoolEvent_Type) Enum() *MempoolEvent_Type {
+	p := new(MempoolEvent_Type)
+	*p = x
+	return p
+}
+
+func (x MempoolEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MempoolEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_node_rpc_node_proto_enumTypes[0].Descriptor()
+}
+
+func (MempoolEvent_Type) Type() protoreflect.EnumType {
+	return &file_node_rpc_node_proto_enumTypes[0]
+}
+
+func (x MempoolEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MempoolEvent_Type.Descriptor instead.
+func (MempoolEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{5, 0}
+}
+
+type Transaction struct {
+	state                      protoimpl.MessageState `protogen:"open.v1"`
+	SenderBlockchainAddress    string                 `protobuf:"bytes,1,opt,name=sender_blockchain_address,json=senderBlockchainAddress,proto3" json:"sender_blockchain_address,omitempty"`
+	RecipientBlockchainAddress string                 `protobuf:"bytes,2,opt,name=recipient_blockchain_address,json=recipientBlockchainAddress,proto3" json:"recipient_blockchain_address,omitempty"`
+	Value                      float32                `protobuf:"fixed32,3,opt,name=value,proto3" json:"value,omitempty"`
+	// Zero for rewards and genesis allocations, which nobody signs.
+	Nonce         uint64 `protobuf:"varint,4,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_node_rpc_node_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Transaction) GetSenderBlockchainAddress() string {
+	if x != nil {
+		return x.SenderBlockchainAddress
+	}
+	return ""
+}
+
+func (x *Transaction) GetRecipientBlockchainAddress() string {
+	if x != nil {
+		return x.RecipientBlockchainAddress
+	}
+	return ""
+}
+
+func (x *Transaction) GetValue() float32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *Transaction) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+type Block struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Index        int64                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Timestamp    int64                  `protobuf:"varint,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	PreviousHash string                 `protobuf:"bytes,3,opt,name=previous_hash,json=previousHash,proto3" json:"previous_hash,omitempty"`
+	Hash         string                 `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"`
+	Transactions []*Transaction         `protobuf:"bytes,5,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	Nonce        int64                  `protobuf:"varint,6,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Difficulty   int32                  `protobuf:"varint,7,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	// Set on chains whose producers sign blocks.
+	Slot          int64  `protobuf:"varint,8,opt,name=slot,proto3" json:"slot,omitempty"`
+	Producer      string `protobuf:"bytes,9,opt,name=producer,proto3" json:"producer,omitempty"`
+	PublicKey     string `protobuf:"bytes,10,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Signature     string `protobuf:"bytes,11,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Block) Reset() {
+	*x = Block{}
+	mi := &file_node_rpc_node_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Block) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Block) ProtoMessage() {}
+
+func (x *Block) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Block) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Block) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Block) GetPreviousHash() string {
+	if x != nil {
+		return x.PreviousHash
+	}
+	return ""
+}
+
+func (x *Block) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *Block) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+func (x *Block) GetNonce() int64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+func (x *Block) GetDifficulty() int32 {
+	if x != nil {
+		return x.Difficulty
+	}
+	return 0
+}
+
+func (x *Block) GetSlot() int64 {
+	if x != nil {
+		return x.Slot
+	}
+	return 0
+}
+
+func (x *Block) GetProducer() string {
+	if x != nil {
+		return x.Producer
+	}
+	return ""
+}
+
+func (x *Block) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *Block) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+type ChainInfo struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Height              int64                  `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	TipHash             string                 `protobuf:"bytes,2,opt,name=tip_hash,json=tipHash,proto3" json:"tip_hash,omitempty"`
+	GenesisHash         string                 `protobuf:"bytes,3,opt,name=genesis_hash,json=genesisHash,proto3" json:"genesis_hash,omitempty"`
+	Difficulty          int32                  `protobuf:"varint,4,opt,name=difficulty,proto3" json:"difficulty,omitempty"`
+	TransactionPoolSize int32                  `protobuf:"varint,5,opt,name=transaction_pool_size,json=transactionPoolSize,proto3" json:"transaction_pool_size,omitempty"`
+	Consensus           string                 `protobuf:"bytes,6,opt,name=consensus,proto3" json:"consensus,omitempty"`
+	FinalizedHeight     int64                  `protobuf:"varint,7,opt,name=finalized_height,json=finalizedHeight,proto3" json:"finalized_height,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ChainInfo) Reset() {
+	*x = ChainInfo{}
+	mi := &file_node_rpc_node_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChainInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChainInfo) ProtoMessage() {}
+
+func (x *ChainInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChainInfo.ProtoReflect.Descriptor instead.
+func (*ChainInfo) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ChainInfo) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *ChainInfo) GetTipHash() string {
+	if x != nil {
+		return x.TipHash
+	}
+	return ""
+}
+
+func (x *ChainInfo) GetGenesisHash() string {
+	if x != nil {
+		return x.GenesisHash
+	}
+	return ""
+}
+
+func (x *ChainInfo) GetDifficulty() int32 {
+	if x != nil {
+		return x.Difficulty
+	}
+	return 0
+}
+
+func (x *ChainInfo) GetTransactionPoolSize() int32 {
+	if x != nil {
+		return x.TransactionPoolSize
+	}
+	return 0
+}
+
+func (x *ChainInfo) GetConsensus() string {
+	if x != nil {
+		return x.Consensus
+	}
+	return ""
+}
+
+func (x *ChainInfo) GetFinalizedHeight() int64 {
+	if x != nil {
+		return x.FinalizedHeight
+	}
+	return 0
+}
+
+type Balance struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Address string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Balance float32                `protobuf:"fixed32,2,opt,name=balance,proto3" json:"balance,omitempty"`
+	// The nonce to sign the next transaction from address with.
+	NextNonce     uint64 `protobuf:"varint,3,opt,name=next_nonce,json=nextNonce,proto3" json:"next_nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Balance) Reset() {
+	*x = Balance{}
+	mi := &file_node_rpc_node_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Balance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Balance) ProtoMessage() {}
+
+func (x *Balance) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Balance.ProtoReflect.Descriptor instead.
+func (*Balance) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Balance) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Balance) GetBalance() float32 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *Balance) GetNextNonce() uint64 {
+	if x != nil {
+		return x.NextNonce
+	}
+	return 0
+}
+
+type Mempool struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Transactions  []*Transaction         `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Mempool) Reset() {
+	*x = Mempool{}
+	mi := &file_node_rpc_node_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Mempool) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Mempool) ProtoMessage() {}
+
+func (x *Mempool) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Mempool.ProtoReflect.Descriptor instead.
+func (*Mempool) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Mempool) GetTransactions() []*Transaction {
+	if x != nil {
+		return x.Transactions
+	}
+	return nil
+}
+
+type MempoolEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          MempoolEvent_Type      `protobuf:"varint,1,opt,name=type,proto3,enum=node.MempoolEvent_Type" json:"type,omitempty"`
+	Transaction   *Transaction           `protobuf:"bytes,2,opt,name=transaction,proto3" json:"transaction,omitempty"`
+	BlockIndex    int64                  `protobuf:"varint,3,opt,name=block_index,json=blockIndex,proto3" json:"block_index,omitempty"`
+	BlockHash     string                 `protobuf:"bytes,4,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MempoolEvent) Reset() {
+	*x = MempoolEvent{}
+	mi := &file_node_rpc_node_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MempoolEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MempoolEvent) ProtoMessage() {}
+
+func (x *MempoolEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MempoolEvent.ProtoReflect.Descriptor instead.
+func (*MempoolEvent) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MempoolEvent) GetType() MempoolEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return MempoolEvent_TYPE_UNSPECIFIED
+}
+
+func (x *MempoolEvent) GetTransaction() *Transaction {
+	if x != nil {
+		return x.Transaction
+	}
+	return nil
+}
+
+func (x *MempoolEvent) GetBlockIndex() int64 {
+	if x != nil {
+		return x.BlockIndex
+	}
+	return 0
+}
+
+func (x *MempoolEvent) GetBlockHash() string {
+	if x != nil {
+		return x.BlockHash
+	}
+	return ""
+}
+
+type GetBlockByHeightRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Height        int64                  `protobuf:"varint,1,opt,name=height,proto3" json:"height,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBlockByHeightRequest) Reset() {
+	*x = GetBlockByHeightRequest{}
+	mi := &file_node_rpc_node_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBlockByHeightRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBlockByHeightRequest) ProtoMessage() {}
+
+func (x *GetBlockByHeightRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBlockByHeightRequest.ProtoReflect.Descriptor instead.
+func (*GetBlockByHeightRequest) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetBlockByHeightRequest) GetHeight() int64 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+type GetBalanceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Address       string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetBalanceRequest) Reset() {
+	*x = GetBalanceRequest{}
+	mi := &file_node_rpc_node_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetBalanceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetBalanceRequest) ProtoMessage() {}
+
+func (x *GetBalanceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetBalanceRequest.ProtoReflect.Descriptor instead.
+func (*GetBalanceRequest) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetBalanceRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type GetMempoolRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMempoolRequest) Reset() {
+	*x = GetMempoolRequest{}
+	mi := &file_node_rpc_node_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMempoolRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMempoolRequest) ProtoMessage() {}
+
+func (x *GetMempoolRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMempoolRequest.ProtoReflect.Descriptor instead.
+func (*GetMempoolRequest) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{8}
+}
+
+type GetChainInfoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetChainInfoRequest) Reset() {
+	*x = GetChainInfoRequest{}
+	mi := &file_node_rpc_node_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetChainInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetChainInfoRequest) ProtoMessage() {}
+
+func (x *GetChainInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetChainInfoRequest.ProtoReflect.Descriptor instead.
+func (*GetChainInfoRequest) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{9}
+}
+
+// A single-key sender sets sender_public_key and signature; a multisig
+// sender sets multisig and signatures instead.
+type SendTransactionRequest struct {
+	state                      protoimpl.MessageState `protogen:"open.v1"`
+	SenderBlockchainAddress    string                 `protobuf:"bytes,1,opt,name=sender_blockchain_address,json=senderBlockchainAddress,proto3" json:"sender_blockchain_address,omitempty"`
+	RecipientBlockchainAddress string                 `protobuf:"bytes,2,opt,name=recipient_blockchain_address,json=recipientBlockchainAddress,proto3" json:"recipient_blockchain_address,omitempty"`
+	SenderPublicKey            string                 `protobuf:"bytes,3,opt,name=sender_public_key,json=senderPublicKey,proto3" json:"sender_public_key,omitempty"`
+	Value                      float32                `protobuf:"fixed32,4,opt,name=value,proto3" json:"value,omitempty"`
+	Signature                  string                 `protobuf:"bytes,5,opt,name=signature,proto3" json:"signature,omitempty"`
+	Multisig                   *MultisigPolicy        `protobuf:"bytes,6,opt,name=multisig,proto3" json:"multisig,omitempty"`
+	Signatures                 []*PartialSignature    `protobuf:"bytes,7,rep,name=signatures,proto3" json:"signatures,omitempty"`
+	// Signature scheme of sender_public_key; empty means p256.
+	Scheme string `protobuf:"bytes,8,opt,name=scheme,proto3" json:"scheme,omitempty"`
+	// Signed with the transaction; each sender may use a nonce once.
+	Nonce         uint64 `protobuf:"varint,9,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendTransactionRequest) Reset() {
+	*x = SendTransactionRequest{}
+	mi := &file_node_rpc_node_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendTransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendTransactionRequest) ProtoMessage() {}
+
+func (x *SendTransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendTransactionRequest.ProtoReflect.Descriptor instead.
+func (*SendTransactionRequest) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SendTransactionRequest) GetSenderBlockchainAddress() string {
+	if x != nil {
+		return x.SenderBlockchainAddress
+	}
+	return ""
+}
+
+func (x *SendTransactionRequest) GetRecipientBlockchainAddress() string {
+	if x != nil {
+		return x.RecipientBlockchainAddress
+	}
+	return ""
+}
+
+func (x *SendTransactionRequest) GetSenderPublicKey() string {
+	if x != nil {
+		return x.SenderPublicKey
+	}
+	return ""
+}
+
+func (x *SendTransactionRequest) GetValue() float32 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *SendTransactionRequest) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *SendTransactionRequest) GetMultisig() *MultisigPolicy {
+	if x != nil {
+		return x.Multisig
+	}
+	return nil
+}
+
+func (x *SendTransactionRequest) GetSignatures() []*PartialSignature {
+	if x != nil {
+		return x.Signatures
+	}
+	return nil
+}
+
+func (x *SendTransactionRequest) GetScheme() string {
+	if x != nil {
+		return x.Scheme
+	}
+	return ""
+}
+
+func (x *SendTransactionRequest) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+type MultisigPolicy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Threshold     int32                  `protobuf:"varint,1,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	PublicKeys    []string               `protobuf:"bytes,2,rep,name=public_keys,json=publicKeys,proto3" json:"public_keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultisigPolicy) Reset() {
+	*x = MultisigPolicy{}
+	mi := &file_node_rpc_node_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultisigPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultisigPolicy) ProtoMessage() {}
+
+func (x *MultisigPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultisigPolicy.ProtoReflect.Descriptor instead.
+func (*MultisigPolicy) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *MultisigPolicy) GetThreshold() int32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *MultisigPolicy) GetPublicKeys() []string {
+	if x != nil {
+		return x.PublicKeys
+	}
+	return nil
+}
+
+type PartialSignature struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PublicKey     string                 `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Signature     string                 `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`
+	Scheme        string                 `protobuf:"bytes,3,opt,name=scheme,proto3" json:"scheme,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PartialSignature) Reset() {
+	*x = PartialSignature{}
+	mi := &file_node_rpc_node_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PartialSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PartialSignature) ProtoMessage() {}
+
+func (x *PartialSignature) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PartialSignature.ProtoReflect.Descriptor instead.
+func (*PartialSignature) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PartialSignature) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *PartialSignature) GetSignature() string {
+	if x != nil {
+		return x.Signature
+	}
+	return ""
+}
+
+func (x *PartialSignature) GetScheme() string {
+	if x != nil {
+		return x.Scheme
+	}
+	return ""
+}
+
+type SendTransactionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accepted      bool                   `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendTransactionResponse) Reset() {
+	*x = SendTransactionResponse{}
+	mi := &file_node_rpc_node_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendTransactionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendTransactionResponse) ProtoMessage() {}
+
+func (x *SendTransactionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendTransactionResponse.ProtoReflect.Descriptor instead.
+func (*SendTransactionResponse) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SendTransactionResponse) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+type SubscribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Addresses     []string               `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_node_rpc_node_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_node_rpc_node_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_node_rpc_node_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SubscribeRequest) GetAddresses() []string {
+	if x != nil {
+		return x.Addresses
+	}
+	return nil
+}
+
+var File_node_rpc_node_proto protoreflect.FileDescriptor
+
+const file_node_rpc_node_proto_rawDesc = "" +
+	"\n" +
+	"\x13node_rpc/node.proto\x12\x04node\"\xb7\x01\n" +
+	"\vTransaction\x12:\n" +
+	"\x19sender_blockchain_address\x18\x01 \x01(\tR\x17senderBlockchainAddress\x12@\n" +
+	"\x1crecipient_blockchain_address\x18\x02 \x01(\tR\x1arecipientBlockchainAddress\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\x02R\x05value\x12\x14\n" +
+	"\x05nonce\x18\x04 \x01(\x04R\x05nonce\"\xce\x02\n" +
+	"\x05Block\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x03R\x05index\x12\x1c\n" +
+	"\ttimestamp\x18\x02 \x01(\x03R\ttimestamp\x12#\n" +
+	"\rprevious_hash\x18\x03 \x01(\tR\fpreviousHash\x12\x12\n" +
+	"\x04hash\x18\x04 \x01(\tR\x04hash\x125\n" +
+	"\ftransactions\x18\x05 \x03(\v2\x11.node.TransactionR\ftransactions\x12\x14\n" +
+	"\x05nonce\x18\x06 \x01(\x03R\x05nonce\x12\x1e\n" +
+	"\n" +
+	"difficulty\x18\a \x01(\x05R\n" +
+	"difficulty\x12\x12\n" +
+	"\x04slot\x18\b \x01(\x03R\x04slot\x12\x1a\n" +
+	"\bproducer\x18\t \x01(\tR\bproducer\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\n" +
+	" \x01(\tR\tpublicKey\x12\x1c\n" +
+	"\tsignature\x18\v \x01(\tR\tsignature\"\xfe\x01\n" +
+	"\tChainInfo\x12\x16\n" +
+	"\x06height\x18\x01 \x01(\x03R\x06height\x12\x19\n" +
+	"\btip_hash\x18\x02 \x01(\tR\atipHash\x12!\n" +
+	"\fgenesis_hash\x18\x03 \x01(\tR\vgenesisHash\x12\x1e\n" +
+	"\n" +
+	"difficulty\x18\x04 \x01(\x05R\n" +
+	"difficulty\x122\n" +
+	"\x15transaction_pool_size\x18\x05 \x01(\x05R\x13transactionPoolSize\x12\x1c\n" +
+	"\tconsensus\x18\x06 \x01(\tR\tconsensus\x12)\n" +
+	"\x10finalized_height\x18\a \x01(\x03R\x0ffinalizedHeight\"\\\n" +
+	"\aBalance\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12\x18\n" +
+	"\abalance\x18\x02 \x01(\x02R\abalance\x12\x1d\n" +
+	"\n" +
+	"next_nonce\x18\x03 \x01(\x04R\tnextNonce\"@\n" +
+	"\aMempool\x125\n" +
+	"\ftransactions\x18\x01 \x03(\v2\x11.node.TransactionR\ftransactions\"\x83\x02\n" +
+	"\fMempoolEvent\x12+\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x17.node.MempoolEvent.TypeR\x04type\x123\n" +
+	"\vtransaction\x18\x02 \x01(\v2\x11.node.TransactionR\vtransaction\x12\x1f\n" +
+	"\vblock_index\x18\x03 \x01(\x03R\n" +
+	"blockIndex\x12\x1d\n" +
+	"\n" +
+	"block_hash\x18\x04 \x01(\tR\tblockHash\"Q\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14TRANSACTION_ACCEPTED\x10\x01\x12\x19\n" +
+	"\x15TRANSACTION_CONFIRMED\x10\x02\"1\n" +
+	"\x17GetBlockByHeightRequest\x12\x16\n" +
+	"\x06height\x18\x01 \x01(\x03R\x06height\"-\n" +
+	"\x11GetBalanceRequest\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\"\x13\n" +
+	"\x11GetMempoolRequest\"\x15\n" +
+	"\x13GetChainInfoRequest\"\x8e\x03\n" +
+	"\x16SendTransactionRequest\x12:\n" +
+	"\x19sender_blockchain_address\x18\x01 \x01(\tR\x17senderBlockchainAddress\x12@\n" +
+	"\x1crecipient_blockchain_address\x18\x02 \x01(\tR\x1arecipientBlockchainAddress\x12*\n" +
+	"\x11sender_public_key\x18\x03 \x01(\tR\x0fsenderPublicKey\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\x02R\x05value\x12\x1c\n" +
+	"\tsignature\x18\x05 \x01(\tR\tsignature\x120\n" +
+	"\bmultisig\x18\x06 \x01(\v2\x14.node.MultisigPolicyR\bmultisig\x126\n" +
+	"\n" +
+	"signatures\x18\a \x03(\v2\x16.node.PartialSignatureR\n" +
+	"signatures\x12\x16\n" +
+	"\x06scheme\x18\b \x01(\tR\x06scheme\x12\x14\n" +
+	"\x05nonce\x18\t \x01(\x04R\x05nonce\"O\n" +
+	"\x0eMultisigPolicy\x12\x1c\n" +
+	"\tthreshold\x18\x01 \x01(\x05R\tthreshold\x12\x1f\n" +
+	"\vpublic_keys\x18\x02 \x03(\tR\n" +
+	"publicKeys\"g\n" +
+	"\x10PartialSignature\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x01 \x01(\tR\tpublicKey\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\tR\tsignature\x12\x16\n" +
+	"\x06scheme\x18\x03 \x01(\tR\x06scheme\"5\n" +
+	"\x17SendTransactionResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\bR\baccepted\"0\n" +
+	"\x10SubscribeRequest\x12\x1c\n" +
+	"\taddresses\x18\x01 \x03(\tR\taddresses2\xba\x03\n" +
+	"\x04Node\x12>\n" +
+	"\x10GetBlockByHeight\x12\x1d.node.GetBlockByHeightRequest\x1a\v.node.Block\x124\n" +
+	"\n" +
+	"GetBalance\x12\x17.node.GetBalanceRequest\x1a\r.node.Balance\x124\n" +
+	"\n" +
+	"GetMempool\x12\x17.node.GetMempoolRequest\x1a\r.node.Mempool\x12:\n" +
+	"\fGetChainInfo\x12\x19.node.GetChainInfoRequest\x1a\x0f.node.ChainInfo\x12N\n" +
+	"\x0fSendTransaction\x12\x1c.node.SendTransactionRequest\x1a\x1d.node.SendTransactionResponse\x128\n" +
+	"\x0fSubscribeBlocks\x12\x16.node.SubscribeRequest\x1a\v.node.Block0\x01\x12@\n" +
+	"\x10SubscribeMempool\x12\x16.node.SubscribeRequest\x1a\x12.node.MempoolEvent0\x01B\x14Z\x12./node_rpc;noderpcb\x06proto3"
+
+var (
+	file_node_rpc_node_proto_rawDescOnce sync.Once
+	file_node_rpc_node_proto_rawDescData []byte
+)
+
+func file_node_rpc_node_proto_rawDescGZIP() []byte {
+	file_node_rpc_node_proto_rawDescOnce.Do(func() {
+		file_node_rpc_node_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_node_rpc_node_proto_rawDesc), len(file_node_rpc_node_proto_rawDesc)))
+	})
+	return file_node_rpc_node_proto_rawDescData
+}
+
+var file_node_rpc_node_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_node_rpc_node_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_node_rpc_node_proto_goTypes = []any{
+	(MempoolEvent_Type)(0),          // 0: node.MempoolEvent.Type
+	(*Transaction)(nil),             // 1: node.Transaction
+	(*Block)(nil),                   // 2: node.Block
+	(*ChainInfo)(nil),               // 3: node.ChainInfo
+	(*Balance)(nil),                 // 4: node.Balance
+	(*Mempool)(nil),                 // 5: node.Mempool
+	(*MempoolEvent)(nil),            // 6: node.MempoolEvent
+	(*GetBlockByHeightRequest)(nil), // 7: node.GetBlockByHeightRequest
+	(*GetBalanceRequest)(nil),       // 8: node.GetBalanceRequest
+	(*GetMempoolRequest)(nil),       // 9: node.GetMempoolRequest
+	(*GetChainInfoRequest)(nil),     // 10: node.GetChainInfoRequest
+	(*SendTransactionRequest)(nil),  // 11: node.SendTransactionRequest
+	(*MultisigPolicy)(nil),          // 12: node.MultisigPolicy
+	(*PartialSignature)(nil),        // 13: node.PartialSignature
+	(*SendTransactionResponse)(nil), // 14: node.SendTransactionResponse
+	(*SubscribeRequest)(nil),        // 15: node.SubscribeRequest
+}
+var file_node_rpc_node_proto_depIdxs = []int32{
+	1,  // 0: node.Block.transactions:type_name -> node.Transaction
+	1,  // 1: node.Mempool.transactions:type_name -> node.Transaction
+	0,  // 2: node.MempoolEvent.type:type_name -> node.MempoolEvent.Type
+	1,  // 3: node.MempoolEvent.transaction:type_name -> node.Transaction
+	12, // 4: node.SendTransactionRequest.multisig:type_name -> node.MultisigPolicy
+	13, // 5: node.SendTransactionRequest.signatures:type_name -> node.PartialSignature
+	7,  // 6: node.Node.GetBlockByHeight:input_type -> node.GetBlockByHeightRequest
+	8,  // 7: node.Node.GetBalance:input_type -> node.GetBalanceRequest
+	9,  // 8: node.Node.GetMempool:input_type -> node.GetMempoolRequest
+	10, // 9: node.Node.GetChainInfo:input_type -> node.GetChainInfoRequest
+	11, // 10: node.Node.SendTransaction:input_type -> node.SendTransactionRequest
+	15, // 11: node.Node.SubscribeBlocks:input_type -> node.SubscribeRequest
+	15, // 12: node.Node.SubscribeMempool:input_type -> node.SubscribeRequest
+	2,  // 13: node.Node.GetBlockByHeight:output_type -> node.Block
+	4,  // 14: node.Node.GetBalance:output_type -> node.Balance
+	5,  // 15: node.Node.GetMempool:output_type -> node.Mempool
+	3,  // 16: node.Node.GetChainInfo:output_type -> node.ChainInfo
+	14, // 17: node.Node.SendTransaction:output_type -> node.SendTransactionResponse
+	2,  // 18: node.Node.SubscribeBlocks:output_type -> node.Block
+	6,  // 19: node.Node.SubscribeMempool:output_type -> node.MempoolEvent
+	13, // [13:20] is the sub-list for method output_type
+	6,  // [6:13] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_node_rpc_node_proto_init() }
+func file_node_rpc_node_proto_init() {
+	if File_node_rpc_node_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_node_rpc_node_proto_rawDesc), len(file_node_rpc_node_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_node_rpc_node_proto_goTypes,
+		DependencyIndexes: file_node_rpc_node_proto_depIdxs,
+		EnumInfos:         file_node_rpc_node_proto_enumTypes,
+		MessageInfos:      file_node_rpc_node_proto_msgTypes,
+	}.Build()
+	File_node_rpc_node_proto = out.File
+	file_node_rpc_node_proto_goTypes = nil
+	file_node_rpc_node_proto_depIdxs = nil
+}
@@ -0,0 +1,9 @@
+package offlinetx
+
+import "errors"
+
+var (
+	ErrInvalidFile = errors.New("invalid transaction file")
+	ErrKeyMismatch = errors.New("key does not match the sender address")
+	ErrNotSigned   = errors.New("transaction is not signed")
+)
@@ -0,0 +1,340 @@
+package offlinetx
+
+import (
+	"../block_chain"
+	"../chain_params"
+	"../signature_scheme"
+	"../transaction"
+	"../wallet"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+const fileVersion = 1
+
+// File is a transaction on its way from a watch-only machine, through one or
+// more offline signers, to a node. It carries everything a signer needs to
+// show what it is signing, so the signer never has to be online.
+type File struct {
+	Version     int                       `json:"version"`
+	Network     string                    `json:"network"`
+	GenesisHash string                    `json:"genesisHash,omitempty"`
+	CreatedAt   int64                     `json:"createdAt"`
+	Transaction TransactionJSON           `json:"transaction"`
+	Multisig    *wallet.MultisigPolicy    `json:"multisig,omitempty"`
+	Signatures  []wallet.PartialSignature `json:"signatures"`
+}
+
+type TransactionJSON struct {
+	SenderBlockchainAddress    string  `json:"senderBlockchainAddress"`
+	RecipientBlockchainAddress string  `json:"recipientBlockchainAddress"`
+	Value                      float32 `json:"value"`
+	Nonce                      uint64  `json:"nonce"`
+}
+
+// New builds an unsigned file. Addresses are stored in canonical form, so
+// the digest the signers see is the one the node verifies. nonce must be
+// one the sender has not used; the node's /wallet-nonce route tells the
+// next. policy is required when sender is a multisig address and must be
+// nil otherwise.
+func New(sender string, recipient string, value float32, nonce uint64, genesisHash string, policy *wallet.MultisigPolicy, params *chainparams.Params) (*File, error) {
+	senderAddress, err := wallet.DecodeAddress(sender, params)
+
+	if err != nil {
+		return nil, fmt.Errorf("sender: %w", err)
+	}
+
+	canonicalRecipient, err := wallet.CanonicalAddress(recipient, params)
+
+	if err != nil {
+		return nil, fmt.Errorf("recipient: %w", err)
+	}
+
+	if value <= 0 {
+		return nil, fmt.Errorf("%w: value must be positive", ErrInvalidFile)
+	}
+
+	if nonce == 0 {
+		return nil, fmt.Errorf("%w: nonce must be positive", ErrInvalidFile)
+	}
+
+	canonicalSender := senderAddress.String(params)
+
+	if senderAddress.Type == wallet.MultisigAddress && (policy == nil || policy.Address(params) != canonicalSender) {
+		return nil, fmt.Errorf("%w: sender %s needs its multisig policy", ErrInvalidFile, canonicalSender)
+	}
+
+	if senderAddress.Type != wallet.MultisigAddress && policy != nil {
+		return nil, fmt.Errorf("%w: sender %s is not a multisig address", ErrInvalidFile, canonicalSender)
+	}
+
+	return &File{
+		Version:     fileVersion,
+		Network:     params.Name,
+		GenesisHash: genesisHash,
+		CreatedAt:   time.Now().UnixMilli(),
+		Transaction: TransactionJSON{
+			SenderBlockchainAddress:    canonicalSender,
+			RecipientBlockchainAddress: canonicalRecipient,
+			Value:                      value,
+			Nonce:                      nonce,
+		},
+		Multisig: policy,
+	}, nil
+}
+
+func Load(path string) (*File, error) {
+	content, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{}
+
+	if err := json.Unmarshal(content, f); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidFile, path, err)
+	}
+
+	if f.Version != fileVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidFile, f.Version)
+	}
+
+	return f, nil
+}
+
+func (f *File) Save(path string) error {
+	content, err := json.MarshalIndent(f, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// Digest is the SHA-256 hash every signer signs. It is computed the same way
+// the node computes it when it verifies the signatures.
+func (f *File) Digest() ([]byte, error) {
+	m, err := json.Marshal(f.transaction())
+
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(m)
+	return digest[:], nil
+}
+
+// Check validates the addresses and policy against params and every
+// signature already in the file.
+func (f *File) Check(params *chainparams.Params) error {
+	if f.Network != params.Name {
+		return fmt.Errorf("%w: file is for %s, not %s", wallet.ErrWrongNetwork, f.Network, params.Name)
+	}
+
+	if f.Transaction.Nonce == 0 {
+		return fmt.Errorf("%w: transaction has no nonce", ErrInvalidFile)
+	}
+
+	sender, err := wallet.DecodeAddress(f.Transaction.SenderBlockchainAddress, params)
+
+	if err != nil {
+		return fmt.Errorf("sender: %w", err)
+	}
+
+	if err := wallet.ValidateAddress(f.Transaction.RecipientBlockchainAddress, params); err != nil {
+		return fmt.Errorf("recipient: %w", err)
+	}
+
+	if (sender.Type == wallet.MultisigAddress) != (f.Multisig != nil) {
+		return fmt.Errorf("%w: multisig policy and sender address disagree", ErrInvalidFile)
+	}
+
+	if f.Multisig != nil && f.Multisig.Address(params) != f.Transaction.SenderBlockchainAddress {
+		return fmt.Errorf("%w: multisig policy does not hash to the sender address", ErrInvalidFile)
+	}
+
+	digest, err := f.Digest()
+
+	if err != nil {
+		return err
+	}
+
+	for i, s := range f.Signatures {
+		if f.Multisig != nil && !f.Multisig.Contains(s.PublicKey) {
+			return fmt.Errorf("%w: signature %d is from a key outside the policy", ErrInvalidFile, i)
+		}
+
+		if !signaturescheme.Verify(s.PublicKey, digest, s.Signature) {
+			return fmt.Errorf("%w: signature %d does not verify", blockchain.ErrInvalidSignature, i)
+		}
+	}
+
+	return nil
+}
+
+// Sign adds the signature of w. For a single-key sender w must own the
+// sender address; for a multisig sender w must be one of the policy keys,
+// and signing again replaces that key's earlier signature.
+func (f *File) Sign(w *wallet.Wallet, params *chainparams.Params) error {
+	if err := f.Check(params); err != nil {
+		return err
+	}
+
+	if f.Multisig == nil && w.BlockchainAddress() != f.Transaction.SenderBlockchainAddress {
+		return fmt.Errorf("%w: key is %s, sender is %s", ErrKeyMismatch, w.BlockchainAddress(), f.Transaction.SenderBlockchainAddress)
+	}
+
+	if f.Multisig != nil && !f.Multisig.Contains(w.PublicKey()) {
+		return fmt.Errorf("%w: key %s is not part of the multisig policy", ErrKeyMismatch, w.BlockchainAddress())
+	}
+
+	t := wallet.NewTransaction(w.PrivateKey(), w.PublicKey(), f.Transaction.SenderBlockchainAddress, f.Transaction.RecipientBlockchainAddress, f.Transaction.Value, f.Transaction.Nonce)
+	signature, err := t.PartialSignature()
+
+	if err != nil {
+		return err
+	}
+
+	f.addSignature(signature)
+	return nil
+}
+
+// Combine merges the signatures collected in other copies of the same
+// transaction, e.g. files signed by different parties in parallel. Both
+// files must carry the same multisig policy, and every incoming signature
+// must verify against the transaction before any of them is merged.
+func (f *File) Combine(other *File) error {
+	if f.Network != other.Network || f.Transaction != other.Transaction || f.GenesisHash != other.GenesisHash {
+		return fmt.Errorf("%w: files describe different transactions", ErrInvalidFile)
+	}
+
+	if (f.Multisig == nil) != (other.Multisig == nil) || f.Multisig != nil && !bytes.Equal(f.Multisig.Script(), other.Multisig.Script()) {
+		return fmt.Errorf("%w: files carry different multisig policies", ErrInvalidFile)
+	}
+
+	digest, err := f.Digest()
+
+	if err != nil {
+		return err
+	}
+
+	for i, s := range other.Signatures {
+		if f.Multisig != nil && !f.Multisig.Contains(s.PublicKey) {
+			return fmt.Errorf("%w: signature %d is from a key outside the policy", ErrInvalidFile, i)
+		}
+
+		if !signaturescheme.Verify(s.PublicKey, digest, s.Signature) {
+			return fmt.Errorf("%w: signature %d does not verify", blockchain.ErrInvalidSignature, i)
+		}
+	}
+
+	for _, s := range other.Signatures {
+		f.addSignature(s)
+	}
+
+	return nil
+}
+
+// Complete reports whether the file holds enough signatures to broadcast.
+func (f *File) Complete() bool {
+	if f.Multisig == nil {
+		return len(f.Signatures) > 0
+	}
+
+	digest, err := f.Digest()
+
+	if err != nil {
+		return false
+	}
+
+	return f.Multisig.Verify(digest, f.Signatures) == nil
+}
+
+// Request turns a signed file into the body POST /transactions expects.
+func (f *File) Request() (*blockchain.TransactionRequest, error) {
+	if !f.Complete() {
+		return nil, ErrNotSigned
+	}
+
+	t := f.Transaction
+	req := &blockchain.TransactionRequest{
+		SenderBlockchainAddress:    &t.SenderBlockchainAddress,
+		RecipientBlockchainAddress: &t.RecipientBlockchainAddress,
+		Value:                      &t.Value,
+		Nonce:                      &t.Nonce,
+	}
+
+	if f.Multisig != nil {
+		req.Multisig = f.Multisig
+		req.Signatures = f.Signatures
+		return req, nil
+	}
+
+	scheme := f.Signatures[0].PublicKey.Scheme().Name()
+	publicKey := hex.EncodeToString(f.Signatures[0].PublicKey.Bytes())
+	signature := f.Signatures[0].Signature.String()
+	req.Scheme = &scheme
+	req.SenderPublicKey = &publicKey
+	req.Signature = &signature
+
+	return req, nil
+}
+
+// Describe prints what a signature over this file commits to, for a human
+// to compare before signing or broadcasting.
+func (f *File) Describe() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "network         %s\n", f.Network)
+	if f.GenesisHash != "" {
+		fmt.Fprintf(&sb, "genesis         %s\n", f.GenesisHash)
+	}
+	fmt.Fprintf(&sb, "created         %s\n", time.UnixMilli(f.CreatedAt).UTC().Format(time.RFC3339))
+	fmt.Fprintf(&sb, "sender          %s\n", f.Transaction.SenderBlockchainAddress)
+	fmt.Fprintf(&sb, "recipient       %s\n", f.Transaction.RecipientBlockchainAddress)
+	fmt.Fprintf(&sb, "value           %.8g\n", f.Transaction.Value)
+	fmt.Fprintf(&sb, "nonce           %d\n", f.Transaction.Nonce)
+
+	if digest, err := f.Digest(); err == nil {
+		fmt.Fprintf(&sb, "digest          %s\n", hex.EncodeToString(digest))
+	}
+
+	if f.Multisig != nil {
+		fmt.Fprintf(&sb, "multisig        %d of %d\n", f.Multisig.Threshold, len(f.Multisig.PublicKeys))
+		for _, key := range f.Multisig.PublicKeys {
+			fmt.Fprintf(&sb, "  key           %s\n", signaturescheme.FormatPublicKey(key))
+		}
+	}
+
+	fmt.Fprintf(&sb, "signatures      %d\n", len(f.Signatures))
+	for _, s := range f.Signatures {
+		fmt.Fprintf(&sb, "  signed by     %s\n", signaturescheme.FormatPublicKey(s.PublicKey))
+	}
+
+	fmt.Fprintf(&sb, "complete        %v\n", f.Complete())
+
+	return sb.String()
+}
+
+func (f *File) addSignature(signature wallet.PartialSignature) {
+	for i, s := range f.Signatures {
+		if signaturescheme.Equal(s.PublicKey, signature.PublicKey) {
+			f.Signatures[i] = signature
+			return
+		}
+	}
+
+	f.Signatures = append(f.Signatures, signature)
+}
+
+func (f *File) transaction() *transaction.Transaction {
+	return transaction.New(f.Transaction.SenderBlockchainAddress, f.Transaction.RecipientBlockchainAddress, f.Transaction.Value).WithNonce(f.Transaction.Nonce)
+}
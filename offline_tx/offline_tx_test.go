@@ -0,0 +1,186 @@
+package offlinetx_test
+
+import (
+	"../block_chain"
+	"../chain_params"
+	"../offline_tx"
+	"../signature_scheme"
+	"../wallet"
+	"errors"
+	"testing"
+)
+
+func newWallets(t *testing.T, params *chainparams.Params, n int) []*wallet.Wallet {
+	t.Helper()
+	var wallets []*wallet.Wallet
+
+	for i := 0; i < n; i++ {
+		w, err := wallet.NewWallet(params)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wallets = append(wallets, w)
+	}
+
+	return wallets
+}
+
+// newMultisigFile returns an unsigned 2-of-3 file, the three policy
+// wallets and one wallet outside the policy.
+func newMultisigFile(t *testing.T, params *chainparams.Params) (*offlinetx.File, []*wallet.Wallet, *wallet.Wallet) {
+	t.Helper()
+	wallets := newWallets(t, params, 5)
+	parties, outsider, recipient := wallets[:3], wallets[3], wallets[4]
+
+	policy, err := wallet.NewMultisigPolicy(2, []signaturescheme.PublicKey{parties[0].PublicKey(), parties[1].PublicKey(), parties[2].PublicKey()})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := offlinetx.New(policy.Address(params), recipient.BlockchainAddress(), 10, 1, "", policy, params)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return f, parties, outsider
+}
+
+// copyOf returns f with its own signature slice.
+func copyOf(f *offlinetx.File) *offlinetx.File {
+	c := *f
+	c.Signatures = append([]wallet.PartialSignature{}, f.Signatures...)
+	return &c
+}
+
+func TestSignCountsDistinctPolicyKeys(t *testing.T) {
+	params := chainparams.Regtest
+	f, parties, outsider := newMultisigFile(t, &params)
+
+	if err := f.Sign(outsider, &params); !errors.Is(err, offlinetx.ErrKeyMismatch) {
+		t.Errorf("signing with a key outside the policy: error = %v, want %v", err, offlinetx.ErrKeyMismatch)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := f.Sign(parties[0], &params); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(f.Signatures) != 1 || f.Complete() {
+		t.Fatalf("one key signing twice: %d signatures, complete = %v", len(f.Signatures), f.Complete())
+	}
+
+	if _, err := f.Request(); !errors.Is(err, offlinetx.ErrNotSigned) {
+		t.Errorf("Request below the threshold: error = %v, want %v", err, offlinetx.ErrNotSigned)
+	}
+
+	if err := f.Sign(parties[2], &params); err != nil {
+		t.Fatal(err)
+	}
+
+	if !f.Complete() {
+		t.Error("2 of 3 signatures are not complete")
+	}
+
+	if err := f.Check(&params); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCombine(t *testing.T) {
+	params := chainparams.Regtest
+	unsigned, parties, outsider := newMultisigFile(t, &params)
+
+	signedBy := func(w *wallet.Wallet) *offlinetx.File {
+		f := copyOf(unsigned)
+
+		if err := f.Sign(w, &params); err != nil {
+			t.Fatal(err)
+		}
+
+		return f
+	}
+
+	// A signature only a tampered file can carry: an outsider's over the
+	// same transaction, or a party's over another one.
+	outsiderSigned := copyOf(unsigned)
+	outsiderTransaction := wallet.NewTransaction(outsider.PrivateKey(), outsider.PublicKey(), unsigned.Transaction.SenderBlockchainAddress, unsigned.Transaction.RecipientBlockchainAddress, unsigned.Transaction.Value, unsigned.Transaction.Nonce)
+	outsiderSignature, err := outsiderTransaction.PartialSignature()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	outsiderSigned.Signatures = append(outsiderSigned.Signatures, outsiderSignature)
+
+	forged := copyOf(unsigned)
+	otherTransaction := wallet.NewTransaction(parties[1].PrivateKey(), parties[1].PublicKey(), unsigned.Transaction.SenderBlockchainAddress, unsigned.Transaction.RecipientBlockchainAddress, 1000, unsigned.Transaction.Nonce)
+	forgedSignature, err := otherTransaction.PartialSignature()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.Signatures = append(forged.Signatures, forgedSignature)
+
+	otherPolicy := copyOf(unsigned)
+	otherPolicy.Multisig, err = wallet.NewMultisigPolicy(3, unsigned.Multisig.PublicKeys)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherValue := copyOf(unsigned)
+	otherValue.Transaction.Value = 20
+
+	tests := []struct {
+		name       string
+		other      *offlinetx.File
+		err        error
+		signatures int
+		complete   bool
+	}{
+		{"another party", signedBy(parties[1]), nil, 2, true},
+		{"the same party", signedBy(parties[0]), nil, 1, false},
+		{"no signatures", copyOf(unsigned), nil, 1, false},
+		{"key outside the policy", outsiderSigned, offlinetx.ErrInvalidFile, 1, false},
+		{"signature over another transaction", forged, blockchain.ErrInvalidSignature, 1, false},
+		{"another policy", otherPolicy, offlinetx.ErrInvalidFile, 1, false},
+		{"another transaction", otherValue, offlinetx.ErrInvalidFile, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := signedBy(parties[0])
+			err := f.Combine(tt.other)
+
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+
+			if len(f.Signatures) != tt.signatures || f.Complete() != tt.complete {
+				t.Errorf("%d signatures, complete = %v; want %d, %v", len(f.Signatures), f.Complete(), tt.signatures, tt.complete)
+			}
+		})
+	}
+}
+
+func TestNewRefusesMismatchedPolicy(t *testing.T) {
+	params := chainparams.Regtest
+	f, parties, _ := newMultisigFile(t, &params)
+	single := parties[0].BlockchainAddress()
+
+	if _, err := offlinetx.New(f.Transaction.SenderBlockchainAddress, single, 10, 1, "", nil, &params); !errors.Is(err, offlinetx.ErrInvalidFile) {
+		t.Errorf("multisig sender without its policy: error = %v, want %v", err, offlinetx.ErrInvalidFile)
+	}
+
+	if _, err := offlinetx.New(single, f.Transaction.SenderBlockchainAddress, 10, 1, "", f.Multisig, &params); !errors.Is(err, offlinetx.ErrInvalidFile) {
+		t.Errorf("single-key sender with a policy: error = %v, want %v", err, offlinetx.ErrInvalidFile)
+	}
+
+	if _, err := offlinetx.New(single, f.Transaction.SenderBlockchainAddress, 10, 0, "", nil, &params); !errors.Is(err, offlinetx.ErrInvalidFile) {
+		t.Errorf("nonce 0: error = %v, want %v", err, offlinetx.ErrInvalidFile)
+	}
+}
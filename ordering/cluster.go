@@ -0,0 +1,170 @@
+package ordering
+
+import (
+	"../block_chain"
+	"../chain_params"
+	"../consensus"
+	"fmt"
+	"github.com/hashicorp/raft"
+	"time"
+)
+
+// Cluster runs Raft nodes in one process over in-memory transports. It is
+// the harness for trying ordering and leader failover without a network:
+// nodes can be crashed and restarted, and a restarted node keeps its Raft
+// log but rebuilds its chain from it, as after a process restart.
+type Cluster struct {
+	Params *chainparams.Params
+	// Nodes and Chains are indexed by node; a crashed node is nil.
+	Nodes  []*Node
+	Chains []*blockchain.BlockChain
+
+	members    []raft.Server
+	stores     []Stores
+	transports []*raft.InmemTransport
+}
+
+// ClusterBatchInterval is how often a Cluster leader batches its pool.
+const ClusterBatchInterval = 20 * time.Millisecond
+
+// NewCluster starts size nodes on a fresh chain of params and bootstraps
+// them into one cluster.
+func NewCluster(params *chainparams.Params, size int) (*Cluster, error) {
+	c := &Cluster{
+		Params:     params,
+		Nodes:      make([]*Node, size),
+		Chains:     make([]*blockchain.BlockChain, size),
+		stores:     make([]Stores, size),
+		transports: make([]*raft.InmemTransport, size),
+	}
+
+	for i := 0; i < size; i++ {
+		id := raft.ServerID(fmt.Sprintf("node%d", i))
+		c.members = append(c.members, raft.Server{ID: id, Address: raft.ServerAddress(id)})
+		c.stores[i] = InmemStores()
+	}
+
+	for i := 0; i < size; i++ {
+		if err := c.start(i); err != nil {
+			c.Shutdown()
+			return nil, err
+		}
+
+		if err := c.Nodes[i].Bootstrap(c.members); err != nil {
+			c.Shutdown()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+func (c *Cluster) start(i int) error {
+	member := c.members[i]
+	_, transport := raft.NewInmemTransport(member.Address)
+
+	cfg := raft.DefaultConfig()
+	cfg.LocalID = member.ID
+	cfg.HeartbeatTimeout = 50 * time.Millisecond
+	cfg.ElectionTimeout = 50 * time.Millisecond
+	cfg.LeaderLeaseTimeout = 50 * time.Millisecond
+	cfg.CommitTimeout = 5 * time.Millisecond
+	cfg.LogLevel = "OFF"
+
+	chain := blockchain.NewWithEngine(c.Params, consensus.NewRaft(c.Params))
+	chain.MinerAddress = string(member.ID)
+
+	node, err := New(chain, cfg, transport, c.stores[i], ClusterBatchInterval)
+
+	if err != nil {
+		return err
+	}
+
+	for j, peer := range c.transports {
+		if peer != nil && c.Nodes[j] != nil {
+			transport.Connect(c.members[j].Address, peer)
+			peer.Connect(member.Address, transport)
+		}
+	}
+
+	c.transports[i] = transport
+	c.Nodes[i] = node
+	c.Chains[i] = chain
+	go node.Run()
+	return nil
+}
+
+// Leader waits up to timeout for a running node to lead and returns its
+// index.
+func (c *Cluster) Leader(timeout time.Duration) (int, error) {
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		for i, node := range c.Nodes {
+			if node != nil && node.IsLeader() {
+				return i, nil
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return -1, ErrNoLeader
+}
+
+// WaitForHeight waits up to timeout for every running node to reach
+// height.
+func (c *Cluster) WaitForHeight(height int64, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for i, chain := range c.Chains {
+		for c.Nodes[i] != nil && chain.Info().Height < height {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("node%d is at height %d, want %d", i, chain.Info().Height, height)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	return nil
+}
+
+// Crash stops node i and cuts it off from the others.
+func (c *Cluster) Crash(i int) error {
+	if c.Nodes[i] == nil {
+		return fmt.Errorf("node%d is not running", i)
+	}
+
+	err := c.Nodes[i].Shutdown()
+
+	for j, peer := range c.transports {
+		if j != i && peer != nil {
+			peer.Disconnect(c.members[i].Address)
+		}
+	}
+	c.transports[i].DisconnectAll()
+
+	c.Nodes[i] = nil
+	c.Chains[i] = nil
+	c.transports[i] = nil
+	return err
+}
+
+// Restart starts a crashed node i again with its Raft log and an empty
+// chain.
+func (c *Cluster) Restart(i int) error {
+	if c.Nodes[i] != nil {
+		return fmt.Errorf("node%d is running", i)
+	}
+
+	return c.start(i)
+}
+
+func (c *Cluster) Shutdown() {
+	for i, node := range c.Nodes {
+		if node != nil {
+			c.Crash(i)
+		}
+	}
+}
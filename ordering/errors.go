@@ -0,0 +1,9 @@
+package ordering
+
+import "errors"
+
+var (
+	ErrNotLeader    = errors.New("node is not the Raft leader")
+	ErrNoLeader     = errors.New("Raft cluster has no leader")
+	ErrInvalidEntry = errors.New("Raft log entry is not a block")
+)
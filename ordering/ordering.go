@@ -0,0 +1,237 @@
+package ordering
+
+import (
+	"../block"
+	"../block_chain"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/raft"
+	"io"
+	"log"
+	"time"
+)
+
+// ApplyTimeout bounds how long the leader waits for a block to commit.
+const ApplyTimeout = 5 * time.Second
+
+// Node replicates a chain through Raft. Only the leader produces blocks:
+// every batch interval it turns the transaction pool into a block and
+// appends it to the Raft log. Once a majority stores the entry, every node,
+// the leader included, adds the block with BlockChain.AppendBlock. A block
+// is committed when it is in the log of a majority, so a new leader always
+// has it.
+type Node struct {
+	ID            string
+	chain         *blockchain.BlockChain
+	raft          *raft.Raft
+	batchInterval time.Duration
+	done          chan struct{}
+}
+
+// Status is what a node knows about the cluster.
+type Status struct {
+	ID            string   `json:"id"`
+	State         string   `json:"state"`
+	Leader        string   `json:"leader"`
+	LeaderAddress string   `json:"leaderAddress"`
+	Members       []string `json:"members"`
+	AppliedIndex  uint64   `json:"appliedIndex"`
+	LastIndex     uint64   `json:"lastIndex"`
+}
+
+// New starts a Raft node for chain, whose engine must be consensus.Raft.
+// cfg.LocalID names the node within the cluster.
+func New(chain *blockchain.BlockChain, cfg *raft.Config, transport raft.Transport, stores Stores, batchInterval time.Duration) (*Node, error) {
+	r, err := raft.NewRaft(cfg, &fsm{chain: chain}, stores.Log, stores.Stable, stores.Snapshots, transport)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Node{
+		ID:            string(cfg.LocalID),
+		chain:         chain,
+		raft:          r,
+		batchInterval: batchInterval,
+		done:          make(chan struct{}),
+	}, nil
+}
+
+// Bootstrap makes members the first cluster configuration. Every member
+// may call it with the same members; on a node that already has Raft state
+// it does nothing.
+func (n *Node) Bootstrap(members []raft.Server) error {
+	err := n.raft.BootstrapCluster(raft.Configuration{Servers: members}).Error()
+
+	if errors.Is(err, raft.ErrCantBootstrap) {
+		return nil
+	}
+
+	return err
+}
+
+// Run produces a block from the pool every batch interval while this node
+// leads. It returns after Shutdown.
+func (n *Node) Run() {
+	ticker := time.NewTicker(n.batchInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-n.done:
+			return
+		case <-ticker.C:
+		}
+
+		if !n.IsLeader() || len(n.chain.GetTransactionPool()) == 0 {
+			continue
+		}
+
+		if err := n.Propose(); err != nil {
+			log.Printf("raft %s: %v\n", n.ID, err)
+		}
+	}
+}
+
+// Propose turns the transaction pool into a block and waits until the
+// cluster commits it and this node has added it.
+func (n *Node) Propose() error {
+	if !n.IsLeader() {
+		return ErrNotLeader
+	}
+
+	// A new leader may not have applied every entry of earlier terms yet;
+	// the barrier waits for them, so the block follows the committed tip.
+	if err := n.raft.Barrier(ApplyTimeout).Error(); err != nil {
+		return err
+	}
+
+	b, err := n.chain.NextBlock()
+
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(b)
+
+	if err != nil {
+		return err
+	}
+
+	f := n.raft.Apply(data, ApplyTimeout)
+
+	if err := f.Error(); err != nil {
+		return err
+	}
+
+	if err, ok := f.Response().(error); ok {
+		return err
+	}
+
+	return nil
+}
+
+func (n *Node) IsLeader() bool {
+	return n.raft.State() == raft.Leader
+}
+
+// Leader returns the ID and transport address of the current leader, or
+// ErrNoLeader during an election.
+func (n *Node) Leader() (string, string, error) {
+	address, id := n.raft.LeaderWithID()
+
+	if id == "" {
+		return "", "", ErrNoLeader
+	}
+
+	return string(id), string(address), nil
+}
+
+func (n *Node) Status() Status {
+	address, id := n.raft.LeaderWithID()
+	status := Status{
+		ID:            n.ID,
+		State:         n.raft.State().String(),
+		Leader:        string(id),
+		LeaderAddress: string(address),
+		Members:       []string{},
+		AppliedIndex:  n.raft.AppliedIndex(),
+		LastIndex:     n.raft.LastIndex(),
+	}
+
+	if f := n.raft.GetConfiguration(); f.Error() == nil {
+		for _, server := range f.Configuration().Servers {
+			status.Members = append(status.Members, string(server.ID))
+		}
+	}
+
+	return status
+}
+
+// Shutdown stops producing blocks and leaves the cluster until the node is
+// started again with the same stores.
+func (n *Node) Shutdown() error {
+	close(n.done)
+	return n.raft.Shutdown().Error()
+}
+
+// fsm applies committed blocks to the chain. Raft calls it from one
+// goroutine, in log order, on every node.
+type fsm struct {
+	chain *blockchain.BlockChain
+}
+
+// Apply returns the error of a block that does not follow the tip, such
+// as one proposed by a leader that had not caught up. Every node rejects
+// it the same way, so the chains stay equal.
+func (f *fsm) Apply(l *raft.Log) interface{} {
+	b := &block.Block{}
+
+	if err := json.Unmarshal(l.Data, b); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidEntry, err)
+	}
+
+	if err := f.chain.AppendBlock(b); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	return &snapshot{blocks: f.chain.Blocks()}, nil
+}
+
+// Restore catches the chain up to a snapshot, when a node starts or falls
+// too far behind the leader's log.
+func (f *fsm) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+
+	var blocks []*block.Block
+
+	if err := json.NewDecoder(rc).Decode(&blocks); err != nil {
+		return err
+	}
+
+	if int64(len(blocks)) <= f.chain.Info().Height+1 {
+		return nil
+	}
+
+	return f.chain.ReplaceChain(blocks)
+}
+
+type snapshot struct {
+	blocks []*block.Block
+}
+
+func (s *snapshot) Persist(sink raft.SnapshotSink) error {
+	if err := json.NewEncoder(sink).Encode(s.blocks); err != nil {
+		sink.Cancel()
+		return err
+	}
+
+	return sink.Close()
+}
+
+func (s *snapshot) Release() {}
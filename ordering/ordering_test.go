@@ -0,0 +1,164 @@
+package ordering_test
+
+import (
+	"../block_chain"
+	"../chain_params"
+	"../ordering"
+	"../signature_scheme"
+	"../wallet"
+	"testing"
+	"time"
+)
+
+const timeout = 10 * time.Second
+
+type account struct {
+	key     signaturescheme.PrivateKey
+	address string
+}
+
+func newAccount(t *testing.T, params *chainparams.Params) account {
+	t.Helper()
+	key, err := signaturescheme.P256.GenerateKey()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return account{key: key, address: wallet.AddressFromPublicKey(key.PublicKey(), params)}
+}
+
+// submit signs a transfer and pools it on chain, which must be the
+// leader's.
+func submit(t *testing.T, chain *blockchain.BlockChain, from account, to string, value float32) {
+	t.Helper()
+	nonce := chain.NextNonce(from.address)
+	signature, err := wallet.NewTransaction(from.key, from.key.PublicKey(), from.address, to, value, nonce).GenerateSignature()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chain.AddTransaction(from.address, to, value, nonce, from.key.PublicKey(), signature); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterSurvivesLeaderCrash(t *testing.T) {
+	params := chainparams.Regtest
+	params.Consensus = chainparams.Raft
+	sender := newAccount(t, &params)
+	recipient := newAccount(t, &params)
+	params.GenesisAllocations = []chainparams.Allocation{{Address: sender.address, Value: 100}}
+
+	c, err := ordering.NewCluster(&params, 3)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Shutdown()
+
+	// committed holds the hash of every block at its height, as the
+	// leader that committed it saw it.
+	committed := []string{c.Chains[0].BlockByHeight(0).Hash}
+	commit := func(leader int, value float32) {
+		t.Helper()
+		height := int64(len(committed))
+		submit(t, c.Chains[leader], sender, recipient.address, value)
+
+		if err := c.WaitForHeight(height, timeout); err != nil {
+			t.Fatal(err)
+		}
+
+		committed = append(committed, c.Chains[leader].BlockByHeight(height).Hash)
+	}
+
+	leader, err := c.Leader(timeout)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commit(leader, 10)
+
+	if err := c.Crash(leader); err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := c.Leader(timeout)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next == leader {
+		t.Fatalf("node%d still leads after crashing", leader)
+	}
+
+	commit(next, 20)
+
+	if err := c.Restart(leader); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.WaitForHeight(int64(len(committed)-1), timeout); err != nil {
+		t.Fatal(err)
+	}
+
+	// The restarted node takes part in ordering again.
+	next, err = c.Leader(timeout)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commit(next, 30)
+
+	for i, chain := range c.Chains {
+		if chain == nil {
+			t.Fatalf("node%d is not running", i)
+		}
+
+		if height := chain.Info().Height; height != int64(len(committed)-1) {
+			t.Errorf("node%d is at height %d, want %d", i, height, len(committed)-1)
+		}
+
+		for height, hash := range committed {
+			if b := chain.BlockByHeight(int64(height)); b == nil || b.Hash != hash {
+				t.Errorf("node%d does not hold committed block %d %s", i, height, hash)
+			}
+		}
+
+		if amount := chain.CalculateTotalAmount(recipient.address); amount != 60 {
+			t.Errorf("node%d credits the recipient %.1f, want 60", i, amount)
+		}
+
+		if !chain.Validate() {
+			t.Errorf("node%d holds an invalid chain", i)
+		}
+	}
+}
+
+func TestProposeOnFollower(t *testing.T) {
+	params := chainparams.Regtest
+	params.Consensus = chainparams.Raft
+
+	c, err := ordering.NewCluster(&params, 3)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Shutdown()
+
+	leader, err := c.Leader(timeout)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	follower := (leader + 1) % len(c.Nodes)
+
+	if err := c.Nodes[follower].Propose(); err != ordering.ErrNotLeader {
+		t.Errorf("Propose on a follower: error = %v, want %v", err, ordering.ErrNotLeader)
+	}
+}
@@ -0,0 +1,57 @@
+package ordering
+
+import (
+	"github.com/hashicorp/raft"
+	"github.com/hashicorp/raft-boltdb/v2"
+	"os"
+	"path/filepath"
+)
+
+// SnapshotsRetained is how many chain snapshots FileStores keeps.
+const SnapshotsRetained = 2
+
+// Stores are where a node keeps its Raft log, its term and vote, and
+// snapshots of the chain.
+type Stores struct {
+	Log       raft.LogStore
+	Stable    raft.StableStore
+	Snapshots raft.SnapshotStore
+}
+
+// InmemStores keeps everything in memory. A node restarted with the same
+// stores rejoins with its log, as after a crash of the process only.
+func InmemStores() Stores {
+	store := raft.NewInmemStore()
+
+	return Stores{
+		Log:       store,
+		Stable:    store,
+		Snapshots: raft.NewInmemSnapshotStore(),
+	}
+}
+
+// FileStores keeps the log in a BoltDB file and snapshots beside it in
+// dir, so committed blocks survive a restart of the whole cluster.
+func FileStores(dir string) (Stores, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return Stores{}, err
+	}
+
+	store, err := raftboltdb.NewBoltStore(filepath.Join(dir, "raft.db"))
+
+	if err != nil {
+		return Stores{}, err
+	}
+
+	snapshots, err := raft.NewFileSnapshotStore(dir, SnapshotsRetained, os.Stderr)
+
+	if err != nil {
+		return Stores{}, err
+	}
+
+	return Stores{
+		Log:       store,
+		Stable:    store,
+		Snapshots: snapshots,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package signaturescheme
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+)
+
+// Ed25519 signs the transaction digest as its message. Private keys are the
+// 32-byte RFC 8032 seed. crypto/ed25519 rejects a non-canonical S, so its
+// signatures cannot be malleated the way ECDSA ones can.
+var Ed25519 Scheme = ed25519Scheme{}
+
+type ed25519Scheme struct{}
+
+type ed25519PublicKey struct {
+	key ed25519.PublicKey
+}
+
+type ed25519PrivateKey struct {
+	key ed25519.PrivateKey
+}
+
+func (ed25519Scheme) ID() ID {
+	return Ed25519ID
+}
+
+func (ed25519Scheme) Name() string {
+	return "ed25519"
+}
+
+func (ed25519Scheme) GenerateKey() (PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ed25519PrivateKey{key: key}, nil
+}
+
+func (ed25519Scheme) PrivateKeyFromBytes(b []byte) (PrivateKey, error) {
+	if len(b) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%w: ed25519 seeds have %d bytes, got %d", ErrInvalidPrivateKey, ed25519.SeedSize, len(b))
+	}
+
+	return ed25519PrivateKey{key: ed25519.NewKeyFromSeed(b)}, nil
+}
+
+func (ed25519Scheme) PublicKeyFromBytes(b []byte) (PublicKey, error) {
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: ed25519 keys have %d bytes, got %d", ErrInvalidPublicKey, ed25519.PublicKeySize, len(b))
+	}
+
+	return ed25519PublicKey{key: ed25519.PublicKey(append([]byte(nil), b...))}, nil
+}
+
+func (k ed25519PublicKey) Scheme() Scheme {
+	return Ed25519
+}
+
+func (k ed25519PublicKey) Bytes() []byte {
+	return append([]byte(nil), k.key...)
+}
+
+func (k ed25519PublicKey) Verify(digest []byte, signature []byte) bool {
+	return len(signature) == signatureLength && ed25519.Verify(k.key, digest, signature)
+}
+
+func (k ed25519PrivateKey) Scheme() Scheme {
+	return Ed25519
+}
+
+func (k ed25519PrivateKey) PublicKey() PublicKey {
+	return ed25519PublicKey{key: k.key.Public().(ed25519.PublicKey)}
+}
+
+func (k ed25519PrivateKey) Bytes() []byte {
+	return k.key.Seed()
+}
+
+func (k ed25519PrivateKey) Sign(digest []byte) ([]byte, error) {
+	return ed25519.Sign(k.key, digest), nil
+}
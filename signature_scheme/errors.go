@@ -0,0 +1,9 @@
+package signaturescheme
+
+import "errors"
+
+var (
+	ErrUnknownScheme     = errors.New("unknown signature scheme")
+	ErrInvalidPublicKey  = errors.New("invalid public key")
+	ErrInvalidPrivateKey = errors.New("invalid private key")
+)
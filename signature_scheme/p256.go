@@ -0,0 +1,118 @@
+package signaturescheme
+
+import (
+	"../utils"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// P256 is ECDSA over NIST P-256, the scheme wallets have always used.
+// Public keys are written as the 32-byte X and Y coordinates; SEC1
+// compressed and uncompressed keys are read too. Signatures are made and
+// only accepted with a low S.
+var P256 Scheme = p256Scheme{}
+
+type p256Scheme struct{}
+
+type p256PublicKey struct {
+	key *ecdsa.PublicKey
+}
+
+type p256PrivateKey struct {
+	key *ecdsa.PrivateKey
+}
+
+func (p256Scheme) ID() ID {
+	return P256ID
+}
+
+func (p256Scheme) Name() string {
+	return "p256"
+}
+
+func (p256Scheme) GenerateKey() (PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return p256PrivateKey{key: key}, nil
+}
+
+func (p256Scheme) PrivateKeyFromBytes(b []byte) (PrivateKey, error) {
+	curve := elliptic.P256()
+	d := new(big.Int).SetBytes(b)
+
+	if len(b) != 32 || d.Sign() == 0 || d.Cmp(curve.Params().N) >= 0 {
+		return nil, fmt.Errorf("%w: not a p256 scalar", ErrInvalidPrivateKey)
+	}
+
+	x, y := curve.ScalarBaseMult(b)
+
+	return p256PrivateKey{key: &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: curve, X: x, Y: y},
+		D:         d,
+	}}, nil
+}
+
+func (p256Scheme) PublicKeyFromBytes(b []byte) (PublicKey, error) {
+	key, err := utils.ParsePublicKey(elliptic.P256(), b)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+
+	return p256PublicKey{key: key}, nil
+}
+
+func (k p256PublicKey) Scheme() Scheme {
+	return P256
+}
+
+func (k p256PublicKey) Bytes() []byte {
+	b := make([]byte, 64)
+	k.key.X.FillBytes(b[:32])
+	k.key.Y.FillBytes(b[32:])
+	return b
+}
+
+func (k p256PublicKey) Verify(digest []byte, signature []byte) bool {
+	if len(signature) != signatureLength {
+		return false
+	}
+
+	sig := &utils.Signature{
+		R: new(big.Int).SetBytes(signature[:32]),
+		S: new(big.Int).SetBytes(signature[32:]),
+	}
+
+	return sig.IsLowS(elliptic.P256()) && ecdsa.Verify(k.key, digest, sig.R, sig.S)
+}
+
+func (k p256PrivateKey) Scheme() Scheme {
+	return P256
+}
+
+func (k p256PrivateKey) PublicKey() PublicKey {
+	return p256PublicKey{key: &k.key.PublicKey}
+}
+
+func (k p256PrivateKey) Bytes() []byte {
+	return k.key.D.FillBytes(make([]byte, 32))
+}
+
+func (k p256PrivateKey) Sign(digest []byte) ([]byte, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, k.key, digest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sig := &utils.Signature{R: r, S: s}
+	sig.NormalizeS(elliptic.P256())
+	return sig.Bytes(), nil
+}
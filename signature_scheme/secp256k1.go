@@ -0,0 +1,111 @@
+package signaturescheme
+
+import (
+	"fmt"
+	"github.com/btcsuite/btcd/btcec/v2"
+	btcecdsa "github.com/btcsuite/btcd/btcec/v2/ecdsa"
+)
+
+// Secp256k1 is ECDSA over the Bitcoin curve with RFC 6979 nonces. Public
+// keys are written as the 32-byte X and Y coordinates, like P256, and SEC1
+// compressed and uncompressed keys are read too. Sign always gives a low
+// S, and Verify rejects a high one.
+var Secp256k1 Scheme = secp256k1Scheme{}
+
+type secp256k1Scheme struct{}
+
+type secp256k1PublicKey struct {
+	key *btcec.PublicKey
+}
+
+type secp256k1PrivateKey struct {
+	key *btcec.PrivateKey
+}
+
+func (secp256k1Scheme) ID() ID {
+	return Secp256k1ID
+}
+
+func (secp256k1Scheme) Name() string {
+	return "secp256k1"
+}
+
+func (secp256k1Scheme) GenerateKey() (PrivateKey, error) {
+	key, err := btcec.NewPrivateKey()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return secp256k1PrivateKey{key: key}, nil
+}
+
+func (secp256k1Scheme) PrivateKeyFromBytes(b []byte) (PrivateKey, error) {
+	var scalar btcec.ModNScalar
+
+	if len(b) != 32 || scalar.SetByteSlice(b) || scalar.IsZero() {
+		return nil, fmt.Errorf("%w: not a secp256k1 scalar", ErrInvalidPrivateKey)
+	}
+
+	return secp256k1PrivateKey{key: btcec.PrivKeyFromScalar(&scalar)}, nil
+}
+
+func (secp256k1Scheme) PublicKeyFromBytes(b []byte) (PublicKey, error) {
+	if len(b) == 64 {
+		b = append([]byte{0x04}, b...)
+	}
+
+	key, err := btcec.ParsePubKey(b)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPublicKey, err)
+	}
+
+	return secp256k1PublicKey{key: key}, nil
+}
+
+func (k secp256k1PublicKey) Scheme() Scheme {
+	return Secp256k1
+}
+
+func (k secp256k1PublicKey) Bytes() []byte {
+	return k.key.SerializeUncompressed()[1:]
+}
+
+func (k secp256k1PublicKey) Verify(digest []byte, signature []byte) bool {
+	if len(signature) != signatureLength {
+		return false
+	}
+
+	var r, s btcec.ModNScalar
+
+	if r.SetByteSlice(signature[:32]) || s.SetByteSlice(signature[32:]) || s.IsOverHalfOrder() {
+		return false
+	}
+
+	return btcecdsa.NewSignature(&r, &s).Verify(digest, k.key)
+}
+
+func (k secp256k1PrivateKey) Scheme() Scheme {
+	return Secp256k1
+}
+
+func (k secp256k1PrivateKey) PublicKey() PublicKey {
+	return secp256k1PublicKey{key: k.key.PubKey()}
+}
+
+func (k secp256k1PrivateKey) Bytes() []byte {
+	return k.key.Serialize()
+}
+
+func (k secp256k1PrivateKey) Sign(digest []byte) ([]byte, error) {
+	sig := btcecdsa.Sign(k.key, digest)
+	r := sig.R()
+	s := sig.S()
+
+	signature := make([]byte, 0, signatureLength)
+	rBytes := r.Bytes()
+	sBytes := s.Bytes()
+	signature = append(signature, rBytes[:]...)
+	return append(signature, sBytes[:]...), nil
+}
@@ -0,0 +1,148 @@
+package signaturescheme
+
+import (
+	"../utils"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// ID identifies a scheme inside addresses and multisig scripts, so it must
+// never be reused for a different scheme.
+type ID byte
+
+const (
+	P256ID      ID = 0
+	Secp256k1ID ID = 1
+	Ed25519ID   ID = 2
+)
+
+// Scheme creates and decodes keys of one signature algorithm. Every scheme
+// signs a 32-byte digest and produces a 64-byte signature, which travels as
+// a utils.Signature.
+type Scheme interface {
+	ID() ID
+	Name() string
+	GenerateKey() (PrivateKey, error)
+	PrivateKeyFromBytes(b []byte) (PrivateKey, error)
+	PublicKeyFromBytes(b []byte) (PublicKey, error)
+}
+
+type PublicKey interface {
+	Scheme() Scheme
+	Bytes() []byte
+	Verify(digest []byte, signature []byte) bool
+}
+
+type PrivateKey interface {
+	Scheme() Scheme
+	PublicKey() PublicKey
+	Bytes() []byte
+	Sign(digest []byte) ([]byte, error)
+}
+
+const signatureLength = 64
+
+// Default is the scheme used when none is named, which keeps requests and
+// key files written before schemes existed working.
+var Default Scheme = P256
+
+var schemes = []Scheme{P256, Secp256k1, Ed25519}
+
+func ByID(id ID) (Scheme, error) {
+	for _, s := range schemes {
+		if s.ID() == id {
+			return s, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: id %d", ErrUnknownScheme, id)
+}
+
+// ByName looks a scheme up by name; the empty name is Default.
+func ByName(name string) (Scheme, error) {
+	if name == "" {
+		return Default, nil
+	}
+
+	for _, s := range schemes {
+		if s.Name() == strings.ToLower(name) {
+			return s, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownScheme, name)
+}
+
+func Names() []string {
+	var names []string
+	for _, s := range schemes {
+		names = append(names, s.Name())
+	}
+
+	return names
+}
+
+func Equal(a PublicKey, b PublicKey) bool {
+	if a == nil || b == nil {
+		return false
+	}
+
+	return a.Scheme().ID() == b.Scheme().ID() && hex.EncodeToString(a.Bytes()) == hex.EncodeToString(b.Bytes())
+}
+
+// ParsePublicKey decodes a hex public key of the named scheme.
+func ParsePublicKey(schemeName string, publicKeyHex string) (PublicKey, error) {
+	scheme, err := ByName(schemeName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := hex.DecodeString(publicKeyHex)
+
+	if err != nil {
+		return nil, fmt.Errorf("public key: %w: %v", utils.ErrInvalidHex, err)
+	}
+
+	return scheme.PublicKeyFromBytes(b)
+}
+
+// FormatPublicKey writes a key as "<scheme>:<hex>", or plain hex for the
+// default scheme, for places that hold keys without a separate scheme
+// field, like multisig policies.
+func FormatPublicKey(publicKey PublicKey) string {
+	if publicKey.Scheme() == Default {
+		return hex.EncodeToString(publicKey.Bytes())
+	}
+
+	return publicKey.Scheme().Name() + ":" + hex.EncodeToString(publicKey.Bytes())
+}
+
+// ParseFormattedPublicKey reverses FormatPublicKey.
+func ParseFormattedPublicKey(s string) (PublicKey, error) {
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		return ParsePublicKey(s[:i], s[i+1:])
+	}
+
+	return ParsePublicKey("", s)
+}
+
+// Sign signs digest and packs the result into a utils.Signature.
+func Sign(privateKey PrivateKey, digest []byte) (*utils.Signature, error) {
+	signature, err := privateKey.Sign(digest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return utils.SignatureFromBytes(signature)
+}
+
+func Verify(publicKey PublicKey, digest []byte, signature *utils.Signature) bool {
+	if publicKey == nil || signature == nil || signature.R == nil || signature.S == nil {
+		return false
+	}
+
+	return publicKey.Verify(digest, signature.Bytes())
+}
@@ -10,6 +10,23 @@ type Transaction struct {
 	senderAddress    string
 	recipientAddress string
 	value            float32
+	nonce            uint64
+	witness          *Witness
+}
+
+// Witness is what authorized a transaction: a signature by the key that
+// owns the sender address, or a multisig policy with enough partial
+// signatures. Blocks carry it so that nodes syncing the chain can check
+// every transfer again.
+type Witness struct {
+	// Sender and Recipient are the addresses as the signer spelled them,
+	// set only when that is not the canonical form kept in the transaction.
+	Sender     string          `json:"sender,omitempty"`
+	Recipient  string          `json:"recipient,omitempty"`
+	PublicKey  string          `json:"publicKey,omitempty"`
+	Signature  string          `json:"signature,omitempty"`
+	Multisig   json.RawMessage `json:"multisig,omitempty"`
+	Signatures json.RawMessage `json:"signatures,omitempty"`
 }
 
 func New(senderAddress string, recipientAddress string, value float32) *Transaction {
@@ -25,6 +42,7 @@ func (t *Transaction) Print() {
 	fmt.Printf(" sender_blockchain_address      %s\n", t.senderAddress)
 	fmt.Printf(" recipient_blockchain_address   %s\n", t.recipientAddress)
 	fmt.Printf(" value                          %.1f\n", t.value)
+	fmt.Printf(" nonce                          %d\n", t.nonce)
 }
 
 func (t *Transaction) GetValue() float32 {
@@ -39,14 +57,74 @@ func (t *Transaction) GetSenderAddress() string {
 	return t.senderAddress
 }
 
+// GetNonce returns the number the signer picked to make t unique among its
+// transactions, so that a copy of t cannot be sent again. Rewards and
+// genesis allocations, which nobody signs, have none.
+func (t *Transaction) GetNonce() uint64 {
+	return t.nonce
+}
+
+// WithNonce returns t carrying nonce.
+func (t *Transaction) WithNonce(nonce uint64) *Transaction {
+	t.nonce = nonce
+	return t
+}
+
+func (t *Transaction) GetWitness() *Witness {
+	return t.witness
+}
+
+// WithWitness returns t carrying w.
+func (t *Transaction) WithWitness(w *Witness) *Transaction {
+	t.witness = w
+	return t
+}
+
+// Signed returns the transaction the witness signed: t with the addresses
+// spelled as the signer spelled them, and no witness.
+func (t *Transaction) Signed() *Transaction {
+	signed := New(t.senderAddress, t.recipientAddress, t.value).WithNonce(t.nonce)
+
+	if t.witness != nil && t.witness.Sender != "" {
+		signed.senderAddress = t.witness.Sender
+	}
+
+	if t.witness != nil && t.witness.Recipient != "" {
+		signed.recipientAddress = t.witness.Recipient
+	}
+
+	return signed
+}
+
+type transactionJSON struct {
+	SenderAddress    string   `json:"senderBlockchainAddress"`
+	RecipientAddress string   `json:"recipientBlockchainAddress"`
+	Value            float32  `json:"value"`
+	Nonce            uint64   `json:"nonce,omitempty"`
+	Witness          *Witness `json:"witness,omitempty"`
+}
+
 func (t *Transaction) MarshalJSON() ([]byte, error) {
-	return json.Marshal(struct {
-		SenderAddress    string  `json:"senderBlockchainAddress"`
-		RecipientAddress string  `json:"recipientBlockchainAddress"`
-		Value            float32 `json:"value"`
-	}{
+	return json.Marshal(transactionJSON{
 		SenderAddress:    t.senderAddress,
 		RecipientAddress: t.recipientAddress,
 		Value:            t.value,
+		Nonce:            t.nonce,
+		Witness:          t.witness,
 	})
 }
+
+func (t *Transaction) UnmarshalJSON(data []byte) error {
+	v := transactionJSON{}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	t.senderAddress = v.SenderAddress
+	t.recipientAddress = v.RecipientAddress
+	t.value = v.Value
+	t.nonce = v.Nonce
+	t.witness = v.Witness
+	return nil
+}
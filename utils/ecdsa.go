@@ -8,67 +8,124 @@ import (
 	"math/big"
 )
 
-type Signature struct {
-	R *big.Int
-	S *big.Int
+// MarshalPublicKey encodes a key in SEC1 uncompressed form, 0x04||X||Y.
+func MarshalPublicKey(publicKey *ecdsa.PublicKey) []byte {
+	byteLen := (publicKey.Curve.Params().BitSize + 7) / 8
+
+	b := make([]byte, 1+2*byteLen)
+	b[0] = 0x04
+	publicKey.X.FillBytes(b[1 : 1+byteLen])
+	publicKey.Y.FillBytes(b[1+byteLen:])
+	return b
 }
 
-func (s *Signature) String() string {
-	return fmt.Sprintf("%064x%064x", s.R, s.S)
+// MarshalCompressedPublicKey encodes a key in SEC1 compressed form, the
+// parity of Y (0x02 or 0x03) followed by X.
+func MarshalCompressedPublicKey(publicKey *ecdsa.PublicKey) []byte {
+	byteLen := (publicKey.Curve.Params().BitSize + 7) / 8
+
+	b := make([]byte, 1+byteLen)
+	b[0] = 0x02 | byte(publicKey.Y.Bit(0))
+	publicKey.X.FillBytes(b[1:])
+	return b
+}
+
+// ParsePublicKey reads a SEC1 compressed or uncompressed key, or the bare
+// X||Y this repo has always written, and checks that the point is on the
+// curve. Compressed keys are only supported on the NIST curves, as for
+// elliptic.UnmarshalCompressed.
+func ParsePublicKey(curve elliptic.Curve, b []byte) (*ecdsa.PublicKey, error) {
+	byteLen := (curve.Params().BitSize + 7) / 8
+
+	var x, y *big.Int
+	switch {
+	case len(b) == 1+byteLen && (b[0] == 0x02 || b[0] == 0x03):
+		x, y = elliptic.UnmarshalCompressed(curve, b)
+	case len(b) == 1+2*byteLen && b[0] == 0x04:
+		x, y = unmarshalPoint(curve, b[1:])
+	case len(b) == 2*byteLen:
+		x, y = unmarshalPoint(curve, b)
+	default:
+		return nil, fmt.Errorf("public key: %w: %d bytes", ErrInvalidLength, len(b))
+	}
+
+	if x == nil {
+		return nil, fmt.Errorf("public key: %w", ErrInvalidPoint)
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
 }
 
-func StringToBigIntTuple(s string) (big.Int, big.Int) {
+func unmarshalPoint(curve elliptic.Curve, b []byte) (*big.Int, *big.Int) {
+	p := curve.Params().P
+	x := new(big.Int).SetBytes(b[:len(b)/2])
+	y := new(big.Int).SetBytes(b[len(b)/2:])
+
+	if x.Cmp(p) >= 0 || y.Cmp(p) >= 0 || !curve.IsOnCurve(x, y) {
+		return nil, nil
+	}
+
+	return x, y
+}
+
+func StringToBigIntTuple(s string) (big.Int, big.Int, error) {
+	var bix big.Int
+	var biy big.Int
+
+	if len(s) != 128 {
+		return bix, biy, fmt.Errorf("%w: expected 128 hex characters, got %d", ErrInvalidLength, len(s))
+	}
+
 	bx, err := hex.DecodeString(s[:64])
 
 	if err != nil {
-		panic(err)
+		return bix, biy, fmt.Errorf("%w: %v", ErrInvalidHex, err)
 	}
 
 	by, err := hex.DecodeString(s[64:])
 
 	if err != nil {
-		panic(err)
+		return bix, biy, fmt.Errorf("%w: %v", ErrInvalidHex, err)
 	}
 
-	var bix big.Int
-	var biy big.Int
-
 	bix.SetBytes(bx)
 	biy.SetBytes(by)
-	return bix, biy
+	return bix, biy, nil
 }
 
-func PublicKeyFromString(publicKeyStr string) *ecdsa.PublicKey {
-	x, y := StringToBigIntTuple(publicKeyStr)
+// PublicKeyFromString reads a hex P-256 key in any form ParsePublicKey
+// accepts.
+func PublicKeyFromString(publicKeyStr string) (*ecdsa.PublicKey, error) {
+	b, err := hex.DecodeString(publicKeyStr)
 
-	return &ecdsa.PublicKey{
-		Curve: elliptic.P256(),
-		X:     &x,
-		Y:     &y,
+	if err != nil {
+		return nil, fmt.Errorf("public key: %w: %v", ErrInvalidHex, err)
 	}
+
+	return ParsePublicKey(elliptic.P256(), b)
 }
 
-func PrivateKeyFromString(privateKeyStr string, publicKey *ecdsa.PublicKey) *ecdsa.PrivateKey {
-	b, err := hex.DecodeString(privateKeyStr[:])
+func PrivateKeyFromString(privateKeyStr string, publicKey *ecdsa.PublicKey) (*ecdsa.PrivateKey, error) {
+	b, err := hex.DecodeString(privateKeyStr)
 
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("private key: %w: %v", ErrInvalidHex, err)
 	}
 
-	var bi big.Int
-	bi.SetBytes(b)
+	d := new(big.Int).SetBytes(b)
 
-	return &ecdsa.PrivateKey{
-		PublicKey: *publicKey,
-		D:         &bi,
+	if d.Sign() == 0 || d.Cmp(publicKey.Curve.Params().N) >= 0 {
+		return nil, fmt.Errorf("private key: %w: not a scalar of the curve", ErrInvalidLength)
 	}
-}
 
-func SignatureFromString(signatureStr string) *Signature {
-	x, y := StringToBigIntTuple(signatureStr)
+	x, y := publicKey.Curve.ScalarBaseMult(b)
 
-	return &Signature{
-		R: &x,
-		S: &y,
+	if x.Cmp(publicKey.X) != 0 || y.Cmp(publicKey.Y) != 0 {
+		return nil, fmt.Errorf("private key: %w: does not match the public key", ErrInvalidPoint)
 	}
+
+	return &ecdsa.PrivateKey{
+		PublicKey: *publicKey,
+		D:         d,
+	}, nil
 }
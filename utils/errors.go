@@ -0,0 +1,10 @@
+package utils
+
+import "errors"
+
+var (
+	ErrInvalidHex       = errors.New("value is not valid hex")
+	ErrInvalidLength    = errors.New("value has invalid length")
+	ErrInvalidPoint     = errors.New("value is not a point on the curve")
+	ErrInvalidSignature = errors.New("value is not a valid signature encoding")
+)
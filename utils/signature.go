@@ -0,0 +1,252 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+)
+
+type Signature struct {
+	R *big.Int
+	S *big.Int
+}
+
+// String is the hex compact form, R||S as two 32-byte halves.
+func (s *Signature) String() string {
+	return hex.EncodeToString(s.Bytes())
+}
+
+// Bytes returns the 64-byte compact form, R and S as two 32-byte halves.
+// Ed25519 signatures use the same layout, so they travel as a Signature
+// too.
+func (s *Signature) Bytes() []byte {
+	b := make([]byte, 64)
+	s.R.FillBytes(b[:32])
+	s.S.FillBytes(b[32:])
+	return b
+}
+
+func SignatureFromBytes(b []byte) (*Signature, error) {
+	if len(b) != 64 {
+		return nil, fmt.Errorf("signature: %w: expected 64 bytes, got %d", ErrInvalidLength, len(b))
+	}
+
+	return &Signature{
+		R: new(big.Int).SetBytes(b[:32]),
+		S: new(big.Int).SetBytes(b[32:]),
+	}, nil
+}
+
+// IsLowS reports whether S is at most half the order of curve. For ECDSA
+// (r, s) and (r, n−s) both verify, so only the low form is accepted;
+// otherwise anyone could change a valid signature without the key.
+func (s *Signature) IsLowS(curve elliptic.Curve) bool {
+	halfOrder := new(big.Int).Rsh(curve.Params().N, 1)
+	return s.S.Cmp(halfOrder) <= 0
+}
+
+// NormalizeS replaces a high S with n−S and reports whether it did.
+func (s *Signature) NormalizeS(curve elliptic.Curve) bool {
+	if s.IsLowS(curve) {
+		return false
+	}
+
+	s.S = new(big.Int).Sub(curve.Params().N, s.S)
+	return true
+}
+
+type derSignature struct {
+	R *big.Int
+	S *big.Int
+}
+
+// DER encodes the signature as the ASN.1 SEQUENCE of two INTEGERs that
+// OpenSSL and most other tools read. A signature missing R or S has no
+// encoding.
+func (s *Signature) DER() ([]byte, error) {
+	b, err := asn1.Marshal(derSignature{R: s.R, S: s.S})
+
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w: %v", ErrInvalidSignature, err)
+	}
+
+	return b, nil
+}
+
+// SignatureFromDER reads a strict DER signature: trailing bytes, negative
+// or zero values and any encoding that does not re-encode to the same bytes
+// are rejected.
+func SignatureFromDER(b []byte) (*Signature, error) {
+	var v derSignature
+	rest, err := asn1.Unmarshal(b, &v)
+
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w: %v", ErrInvalidSignature, err)
+	}
+
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("signature: %w: %d trailing bytes", ErrInvalidSignature, len(rest))
+	}
+
+	if v.R.Sign() <= 0 || v.S.Sign() <= 0 || v.R.BitLen() > 256 || v.S.BitLen() > 256 {
+		return nil, fmt.Errorf("signature: %w: r and s must be positive 256-bit values", ErrInvalidSignature)
+	}
+
+	s := &Signature{R: v.R, S: v.S}
+	canonical, err := s.DER()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if hex.EncodeToString(canonical) != hex.EncodeToString(b) {
+		return nil, fmt.Errorf("signature: %w: not in canonical DER", ErrInvalidSignature)
+	}
+
+	return s, nil
+}
+
+// SignatureFromString reads a hex signature in compact or DER form.
+func SignatureFromString(signatureStr string) (*Signature, error) {
+	b, err := hex.DecodeString(signatureStr)
+
+	if err != nil {
+		return nil, fmt.Errorf("signature: %w: %v", ErrInvalidHex, err)
+	}
+
+	if len(b) == 64 {
+		return SignatureFromBytes(b)
+	}
+
+	return SignatureFromDER(b)
+}
+
+// RecoverableSignature carries the recovery id V (0 to 3) next to R and S,
+// so the signer's public key can be rebuilt from the signature and digest.
+type RecoverableSignature struct {
+	Signature
+	V byte
+}
+
+// Bytes returns the 65-byte form R||S||V.
+func (s *RecoverableSignature) Bytes() []byte {
+	return append(s.Signature.Bytes(), s.V)
+}
+
+func RecoverableSignatureFromBytes(b []byte) (*RecoverableSignature, error) {
+	if len(b) != 65 {
+		return nil, fmt.Errorf("signature: %w: expected 65 bytes, got %d", ErrInvalidLength, len(b))
+	}
+
+	if b[64] > 3 {
+		return nil, fmt.Errorf("signature: %w: recovery id %d", ErrInvalidSignature, b[64])
+	}
+
+	s, err := SignatureFromBytes(b[:64])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecoverableSignature{Signature: *s, V: b[64]}, nil
+}
+
+// SignRecoverable signs digest with a low S and works out the recovery id
+// by trying each candidate until one rebuilds the signer's key.
+func SignRecoverable(privateKey *ecdsa.PrivateKey, digest []byte) (*RecoverableSignature, error) {
+	r, s, err := ecdsa.Sign(rand.Reader, privateKey, digest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	signature := Signature{R: r, S: s}
+	signature.NormalizeS(privateKey.Curve)
+
+	for v := byte(0); v < 4; v++ {
+		sig := &RecoverableSignature{Signature: signature, V: v}
+		publicKey, err := RecoverPublicKey(privateKey.Curve, digest, sig)
+
+		if err == nil && publicKey.X.Cmp(privateKey.X) == 0 && publicKey.Y.Cmp(privateKey.Y) == 0 {
+			return sig, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no recovery id matches the key", ErrInvalidSignature)
+}
+
+// RecoverPublicKey rebuilds the key that made signature over digest, as
+// Q = r⁻¹(sR − eG), where R is the point whose X is r (plus n when V has
+// bit 1 set) and whose Y parity is bit 0 of V. Like ParsePublicKey it is
+// limited to the NIST curves.
+func RecoverPublicKey(curve elliptic.Curve, digest []byte, signature *RecoverableSignature) (*ecdsa.PublicKey, error) {
+	params := curve.Params()
+	n := params.N
+	r := signature.R
+	s := signature.S
+
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 || signature.V > 3 {
+		return nil, fmt.Errorf("signature: %w: r, s or recovery id out of range", ErrInvalidSignature)
+	}
+
+	x := new(big.Int).Set(r)
+	if signature.V&2 != 0 {
+		x.Add(x, n)
+	}
+
+	byteLen := (params.BitSize + 7) / 8
+
+	if x.Cmp(params.P) >= 0 {
+		return nil, fmt.Errorf("signature: %w: r does not name a point", ErrInvalidSignature)
+	}
+
+	compressed := make([]byte, 1+byteLen)
+	compressed[0] = 0x02 | signature.V&1
+	x.FillBytes(compressed[1:])
+	rx, ry := elliptic.UnmarshalCompressed(curve, compressed)
+
+	if rx == nil {
+		return nil, fmt.Errorf("signature: %w: r does not name a point", ErrInvalidSignature)
+	}
+
+	rInv := new(big.Int).ModInverse(r, n)
+	e := hashToInt(digest, curve)
+
+	// u1 = −e·r⁻¹ and u2 = s·r⁻¹, both mod n.
+	u1 := new(big.Int).Mul(new(big.Int).Neg(e), rInv)
+	u1.Mod(u1, n)
+	u2 := new(big.Int).Mul(s, rInv)
+	u2.Mod(u2, n)
+
+	x1, y1 := curve.ScalarBaseMult(u1.FillBytes(make([]byte, byteLen)))
+	x2, y2 := curve.ScalarMult(rx, ry, u2.FillBytes(make([]byte, byteLen)))
+	qx, qy := curve.Add(x1, y1, x2, y2)
+
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return nil, fmt.Errorf("signature: %w: recovers the point at infinity", ErrInvalidSignature)
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: qx, Y: qy}, nil
+}
+
+// hashToInt truncates digest to the bit length of the curve order, the
+// same way crypto/ecdsa does before signing.
+func hashToInt(digest []byte, curve elliptic.Curve) *big.Int {
+	orderBits := curve.Params().N.BitLen()
+	orderBytes := (orderBits + 7) / 8
+
+	if len(digest) > orderBytes {
+		digest = digest[:orderBytes]
+	}
+
+	e := new(big.Int).SetBytes(digest)
+	if excess := len(digest)*8 - orderBits; excess > 0 {
+		e.Rsh(e, uint(excess))
+	}
+
+	return e
+}
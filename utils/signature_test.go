@@ -0,0 +1,298 @@
+package utils_test
+
+import (
+	"../signature_scheme"
+	"../utils"
+	"../wallet"
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func newKey(t *testing.T) signaturescheme.PrivateKey {
+	t.Helper()
+	privateKey, err := signaturescheme.P256.GenerateKey()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return privateKey
+}
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+func TestVerifyRejectsHighS(t *testing.T) {
+	privateKey := newKey(t)
+	digest := sha256.Sum256([]byte("transfer"))
+	signature, err := signaturescheme.Sign(privateKey, digest[:])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !signaturescheme.Verify(privateKey.PublicKey(), digest[:], signature) {
+		t.Fatal("low-S signature does not verify")
+	}
+
+	n := elliptic.P256().Params().N
+	highS := &utils.Signature{R: signature.R, S: new(big.Int).Sub(n, signature.S)}
+
+	if highS.IsLowS(elliptic.P256()) {
+		t.Fatal("(r, n-s) is reported as low-S")
+	}
+
+	// crypto/ecdsa accepts (r, n-s) as well; the scheme must not.
+	publicKey, err := utils.ParsePublicKey(elliptic.P256(), privateKey.PublicKey().Bytes())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ecdsa.Verify(publicKey, digest[:], highS.R, highS.S) {
+		t.Fatal("(r, n-s) is not a valid ECDSA signature, the test proves nothing")
+	}
+
+	if signaturescheme.Verify(privateKey.PublicKey(), digest[:], highS) {
+		t.Error("(r, n-s) verifies")
+	}
+
+	parsed, err := utils.SignatureFromString(highS.String())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if signaturescheme.Verify(privateKey.PublicKey(), digest[:], parsed) {
+		t.Error("(r, n-s) read from hex verifies")
+	}
+}
+
+func TestNormalizeS(t *testing.T) {
+	n := elliptic.P256().Params().N
+	half := new(big.Int).Rsh(n, 1)
+
+	low := &utils.Signature{R: big.NewInt(1), S: new(big.Int).Set(half)}
+
+	if low.NormalizeS(elliptic.P256()) || low.S.Cmp(half) != 0 {
+		t.Errorf("NormalizeS changed a low S")
+	}
+
+	high := &utils.Signature{R: big.NewInt(1), S: new(big.Int).Add(half, big.NewInt(1))}
+
+	if !high.NormalizeS(elliptic.P256()) {
+		t.Fatal("NormalizeS left a high S")
+	}
+
+	if !high.IsLowS(elliptic.P256()) || high.S.Cmp(half) != 0 {
+		t.Errorf("NormalizeS gave S = %s, want n/2", high.S)
+	}
+}
+
+func TestGenerateSignatureIsLowS(t *testing.T) {
+	privateKey := newKey(t)
+
+	for i := 0; i < 64; i++ {
+		tx := wallet.NewTransaction(privateKey, privateKey.PublicKey(), "sender", "recipient", float32(i+1), uint64(i+1))
+		signature, err := tx.GenerateSignature()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !signature.IsLowS(elliptic.P256()) {
+			t.Fatalf("signature %d has a high S: %s", i, signature)
+		}
+	}
+}
+
+func TestSignatureFromDER(t *testing.T) {
+	signature := &utils.Signature{R: big.NewInt(1), S: big.NewInt(1)}
+	der, err := signature.DER()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := hex.EncodeToString(der); got != "3006020101020101" {
+		t.Fatalf("DER() = %s, want 3006020101020101", got)
+	}
+
+	parsed, err := utils.SignatureFromDER(der)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.R.Cmp(signature.R) != 0 || parsed.S.Cmp(signature.S) != 0 {
+		t.Errorf("SignatureFromDER(DER()) = (%s, %s), want (1, 1)", parsed.R, parsed.S)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha256.Sum256([]byte("der"))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	full := &utils.Signature{R: r, S: s}
+	der, err = full.DER()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err = utils.SignatureFromString(hex.EncodeToString(der))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.R.Cmp(r) != 0 || parsed.S.Cmp(s) != 0 {
+		t.Error("DER round trip changed the signature")
+	}
+
+	if _, err := (&utils.Signature{R: r}).DER(); !errors.Is(err, utils.ErrInvalidSignature) {
+		t.Errorf("DER without S: error = %v, want %v", err, utils.ErrInvalidSignature)
+	}
+}
+
+func TestSignatureFromDERRejectsNonCanonical(t *testing.T) {
+	tests := []struct {
+		name string
+		der  string
+	}{
+		{"padded r", "300702020001020101"},
+		{"padded s", "300702010102020001"},
+		{"long-form sequence length", "308106020101020101"},
+		{"long-form integer length", "300702810101020101"},
+		{"trailing bytes", "300602010102010100"},
+		{"sequence length too long", "3007020101020101"},
+		{"negative r", "3006020181020101"},
+		{"zero s", "3006020101020100"},
+		{"r wider than 256 bits", "302702220100" + "00000000000000000000000000000000000000000000000000000000000000" + "01" + "020101"},
+		{"three integers", "3009020101020101020101"},
+		{"not a sequence", "3106020101020101"},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := utils.SignatureFromDER(mustDecodeHex(t, tt.der)); !errors.Is(err, utils.ErrInvalidSignature) {
+				t.Errorf("SignatureFromDER(%s) error = %v, want %v", tt.der, err, utils.ErrInvalidSignature)
+			}
+		})
+	}
+}
+
+func TestSignatureFromCompact(t *testing.T) {
+	signature := &utils.Signature{R: big.NewInt(0x0102), S: big.NewInt(0x0304)}
+	compact := signature.Bytes()
+
+	if len(compact) != 64 || compact[30] != 0x01 || compact[31] != 0x02 || compact[62] != 0x03 || compact[63] != 0x04 {
+		t.Fatalf("Bytes() = %x, want R and S as two 32-byte halves", compact)
+	}
+
+	parsed, err := utils.SignatureFromString(signature.String())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(parsed.Bytes(), compact) {
+		t.Errorf("SignatureFromString(String()) = %x, want %x", parsed.Bytes(), compact)
+	}
+
+	if _, err := utils.SignatureFromBytes(compact[:63]); !errors.Is(err, utils.ErrInvalidLength) {
+		t.Errorf("63 bytes: error = %v, want %v", err, utils.ErrInvalidLength)
+	}
+
+	if _, err := utils.SignatureFromString("zz"); !errors.Is(err, utils.ErrInvalidHex) {
+		t.Errorf("bad hex: error = %v, want %v", err, utils.ErrInvalidHex)
+	}
+
+	// Anything but 64 bytes is read as DER and must be canonical.
+	if _, err := utils.SignatureFromString(hex.EncodeToString(append(compact, 0))); !errors.Is(err, utils.ErrInvalidSignature) {
+		t.Errorf("65 bytes: error = %v, want %v", err, utils.ErrInvalidSignature)
+	}
+}
+
+func TestRecoverableSignature(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha256.Sum256([]byte("recover"))
+	signature, err := utils.SignRecoverable(key, digest[:])
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !signature.IsLowS(elliptic.P256()) {
+		t.Error("SignRecoverable gave a high S")
+	}
+
+	parsed, err := utils.RecoverableSignatureFromBytes(signature.Bytes())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, err := utils.RecoverPublicKey(elliptic.P256(), digest[:], parsed)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if publicKey.X.Cmp(key.X) != 0 || publicKey.Y.Cmp(key.Y) != 0 {
+		t.Error("RecoverPublicKey rebuilt another key")
+	}
+
+	other := sha256.Sum256([]byte("other"))
+
+	if publicKey, err := utils.RecoverPublicKey(elliptic.P256(), other[:], parsed); err == nil && publicKey.X.Cmp(key.X) == 0 {
+		t.Error("the key was recovered from another digest")
+	}
+
+	b := signature.Bytes()
+	b[64] = 4
+
+	if _, err := utils.RecoverableSignatureFromBytes(b); !errors.Is(err, utils.ErrInvalidSignature) {
+		t.Errorf("recovery id 4: error = %v, want %v", err, utils.ErrInvalidSignature)
+	}
+
+	if _, err := utils.RecoverableSignatureFromBytes(b[:64]); !errors.Is(err, utils.ErrInvalidLength) {
+		t.Errorf("64 bytes: error = %v, want %v", err, utils.ErrInvalidLength)
+	}
+
+	n := elliptic.P256().Params().N
+	outOfRange := &utils.RecoverableSignature{Signature: utils.Signature{R: new(big.Int).Set(n), S: big.NewInt(1)}}
+
+	if _, err := utils.RecoverPublicKey(elliptic.P256(), digest[:], outOfRange); !errors.Is(err, utils.ErrInvalidSignature) {
+		t.Errorf("r = n: error = %v, want %v", err, utils.ErrInvalidSignature)
+	}
+}
@@ -0,0 +1,164 @@
+package wallet
+
+import (
+	"../chain_params"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"strings"
+)
+
+const (
+	addressLength       = 25
+	checksumLength      = 4
+	publicKeyHashLength = 20
+)
+
+// AddressType tells which kind of policy an address hash commits to. It
+// picks the Base58Check version byte and is the first 5-bit group of a
+// Bech32m address.
+type AddressType byte
+
+const (
+	// PublicKeyHashAddress is controlled by a single key.
+	PublicKeyHashAddress AddressType = iota
+	// MultisigAddress is controlled by an M-of-N MultisigPolicy.
+	MultisigAddress
+)
+
+type Address struct {
+	Type AddressType
+	Hash []byte
+}
+
+// DecodeAddress decodes a Base58Check or Bech32m address, checks its
+// checksum and network, and reports what kind of address it is.
+func DecodeAddress(address string, params *chainparams.Params) (*Address, error) {
+	if IsBech32mAddress(address, params) {
+		return decodeBech32mAddress(address, params)
+	}
+
+	decoded := base58.Decode(address)
+
+	if len(decoded) != addressLength {
+		if hrp, _, err := DecodeBech32m(address); err == nil {
+			return nil, fmt.Errorf("%w: %q has prefix %q, not %q", ErrWrongNetwork, address, hrp, params.Bech32HRP)
+		}
+
+		return nil, fmt.Errorf("%w: %q is not a base58 or bech32m address", ErrInvalidAddress, address)
+	}
+
+	payload := decoded[:addressLength-checksumLength]
+	first := sha256.Sum256(payload)
+	second := sha256.Sum256(first[:])
+
+	if !bytes.Equal(second[:checksumLength], decoded[addressLength-checksumLength:]) {
+		return nil, fmt.Errorf("%w: %q has a bad checksum", ErrInvalidAddress, address)
+	}
+
+	switch payload[0] {
+	case params.AddressVersion:
+		return &Address{Type: PublicKeyHashAddress, Hash: payload[1:]}, nil
+	case params.MultisigAddressVersion:
+		return &Address{Type: MultisigAddress, Hash: payload[1:]}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q is not a %s address", ErrWrongNetwork, address, params.Name)
+}
+
+// ParseAddress returns the RIPEMD-160 hash a valid address commits to.
+func ParseAddress(address string, params *chainparams.Params) ([]byte, error) {
+	a, err := DecodeAddress(address, params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return a.Hash, nil
+}
+
+// ValidateAddress rejects addresses that are malformed, mistyped or that
+// belong to a different network than params.
+func ValidateAddress(address string, params *chainparams.Params) error {
+	_, err := DecodeAddress(address, params)
+	return err
+}
+
+// CanonicalAddress returns the Base58Check form of address. The chain keys
+// balances by address string, so both spellings of an address are stored
+// as this one.
+func CanonicalAddress(address string, params *chainparams.Params) (string, error) {
+	a, err := DecodeAddress(address, params)
+
+	if err != nil {
+		return "", err
+	}
+
+	return a.String(params), nil
+}
+
+// Bech32mAddress returns the Bech32m form of any valid address.
+func Bech32mAddress(address string, params *chainparams.Params) (string, error) {
+	a, err := DecodeAddress(address, params)
+
+	if err != nil {
+		return "", err
+	}
+
+	return a.Bech32m(params)
+}
+
+// IsBech32mAddress reports whether address is spelled with this network's
+// human-readable prefix. It does not check the rest of it.
+func IsBech32mAddress(address string, params *chainparams.Params) bool {
+	return strings.HasPrefix(strings.ToLower(address), params.Bech32HRP+"1")
+}
+
+// String returns the Base58Check form of a.
+func (a *Address) String(params *chainparams.Params) string {
+	version := params.AddressVersion
+	if a.Type == MultisigAddress {
+		version = params.MultisigAddressVersion
+	}
+
+	return base58CheckAddress(version, a.Hash)
+}
+
+func (a *Address) Bech32m(params *chainparams.Params) (string, error) {
+	data, err := convertBits(a.Hash, 8, 5, true)
+
+	if err != nil {
+		return "", err
+	}
+
+	return EncodeBech32m(params.Bech32HRP, append([]byte{byte(a.Type)}, data...))
+}
+
+func decodeBech32mAddress(address string, params *chainparams.Params) (*Address, error) {
+	hrp, data, err := DecodeBech32m(address)
+
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", address, err)
+	}
+
+	if hrp != params.Bech32HRP {
+		return nil, fmt.Errorf("%w: %q is not a %s address", ErrWrongNetwork, address, params.Name)
+	}
+
+	if len(data) == 0 || (AddressType(data[0]) != PublicKeyHashAddress && AddressType(data[0]) != MultisigAddress) {
+		return nil, fmt.Errorf("%w: %q has an unknown address version", ErrInvalidAddress, address)
+	}
+
+	hash, err := convertBits(data[1:], 5, 8, false)
+
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", address, err)
+	}
+
+	if len(hash) != publicKeyHashLength {
+		return nil, fmt.Errorf("%w: %q has a %d-byte payload", ErrInvalidAddress, address, len(hash))
+	}
+
+	return &Address{Type: AddressType(data[0]), Hash: hash}, nil
+}
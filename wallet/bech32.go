@@ -0,0 +1,213 @@
+package wallet
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	bech32Charset      = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	bech32mConst       = 0x2bc830a3
+	bech32MaxLength    = 90
+	bech32ChecksumSize = 6
+)
+
+var bech32Generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+// EncodeBech32m encodes 5-bit groups under hrp and appends a Bech32m
+// checksum (BIP-350).
+func EncodeBech32m(hrp string, data []byte) (string, error) {
+	if len(hrp)+1+len(data)+bech32ChecksumSize > bech32MaxLength {
+		return "", fmt.Errorf("%w: bech32m string longer than %d characters", ErrInvalidAddress, bech32MaxLength)
+	}
+
+	hrp = strings.ToLower(hrp)
+	values := append(bech32HRPExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, make([]byte, bech32ChecksumSize)...)) ^ bech32mConst
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+
+	for _, d := range data {
+		if d >= 32 {
+			return "", fmt.Errorf("%w: data value %d does not fit in 5 bits", ErrInvalidAddress, d)
+		}
+		sb.WriteByte(bech32Charset[d])
+	}
+
+	for i := 0; i < bech32ChecksumSize; i++ {
+		sb.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+
+	return sb.String(), nil
+}
+
+// DecodeBech32m splits s into its human-readable part and 5-bit data,
+// dropping the checksum. A checksum failure names the positions that
+// LocateBech32mErrors could pin down.
+func DecodeBech32m(s string) (string, []byte, error) {
+	if len(s) > bech32MaxLength {
+		return "", nil, fmt.Errorf("%w: bech32m string longer than %d characters", ErrInvalidAddress, bech32MaxLength)
+	}
+
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, fmt.Errorf("%w: bech32m string mixes upper and lower case", ErrInvalidAddress)
+	}
+
+	s = strings.ToLower(s)
+	separator := strings.LastIndexByte(s, '1')
+
+	if separator < 1 || separator+bech32ChecksumSize+1 > len(s) {
+		return "", nil, fmt.Errorf("%w: bech32m separator missing or misplaced", ErrInvalidAddress)
+	}
+
+	hrp := s[:separator]
+
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, fmt.Errorf("%w: invalid character at position %d", ErrInvalidAddress, i)
+		}
+	}
+
+	data := make([]byte, 0, len(s)-separator-1)
+
+	for i := separator + 1; i < len(s); i++ {
+		d := strings.IndexByte(bech32Charset, s[i])
+
+		if d < 0 {
+			return "", nil, fmt.Errorf("%w: invalid character %q at position %d", ErrInvalidAddress, s[i], i)
+		}
+
+		data = append(data, byte(d))
+	}
+
+	if !bech32mVerify(hrp, data) {
+		if positions := LocateBech32mErrors(s); len(positions) > 0 {
+			return "", nil, fmt.Errorf("%w: bech32m checksum mismatch, check position %v", ErrInvalidAddress, positions)
+		}
+
+		return "", nil, fmt.Errorf("%w: bech32m checksum mismatch", ErrInvalidAddress)
+	}
+
+	return hrp, data[:len(data)-bech32ChecksumSize], nil
+}
+
+// LocateBech32mErrors returns the position of a single mistyped character
+// in the data part of s, found by trying every substitution. Bech32m
+// detects more errors than that but can only locate them this cheaply. It
+// returns nil when s is valid or the error could not be located.
+func LocateBech32mErrors(s string) []int {
+	s = strings.ToLower(s)
+	separator := strings.LastIndexByte(s, '1')
+
+	if separator < 1 {
+		return nil
+	}
+
+	hrp := s[:separator]
+	data := make([]byte, 0, len(s)-separator-1)
+
+	for i := separator + 1; i < len(s); i++ {
+		d := strings.IndexByte(bech32Charset, s[i])
+
+		if d < 0 {
+			return []int{i}
+		}
+
+		data = append(data, byte(d))
+	}
+
+	if bech32mVerify(hrp, data) {
+		return nil
+	}
+
+	var positions []int
+	for i := range data {
+		original := data[i]
+
+		for c := byte(0); c < 32; c++ {
+			if c == original {
+				continue
+			}
+
+			data[i] = c
+			if bech32mVerify(hrp, data) {
+				positions = append(positions, separator+1+i)
+			}
+		}
+
+		data[i] = original
+	}
+
+	return positions
+}
+
+func bech32mVerify(hrp string, data []byte) bool {
+	return bech32Polymod(append(bech32HRPExpand(hrp), data...)) == bech32mConst
+}
+
+func bech32Polymod(values []byte) uint32 {
+	chk := uint32(1)
+
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				chk ^= bech32Generator[i]
+			}
+		}
+	}
+
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+
+	expanded = append(expanded, 0)
+
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+
+	return expanded
+}
+
+// convertBits regroups data from fromBits-wide to toBits-wide values.
+func convertBits(data []byte, fromBits uint, toBits uint, pad bool) ([]byte, error) {
+	var acc uint32
+	var bits uint
+	var out []byte
+	maxValue := uint32(1)<<toBits - 1
+
+	for _, d := range data {
+		if uint32(d)>>fromBits != 0 {
+			return nil, fmt.Errorf("%w: value %d does not fit in %d bits", ErrInvalidAddress, d, fromBits)
+		}
+
+		acc = acc<<fromBits | uint32(d)
+		bits += fromBits
+
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxValue))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&maxValue))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxValue != 0 {
+		return nil, fmt.Errorf("%w: invalid padding", ErrInvalidAddress)
+	}
+
+	return out, nil
+}
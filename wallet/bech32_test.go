@@ -0,0 +1,124 @@
+package wallet_test
+
+import (
+	"../chain_params"
+	"../wallet"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// The valid and invalid Bech32m strings of BIP-350.
+var validBech32m = []string{
+	"A1LQFN3A",
+	"a1lqfn3a",
+	"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6",
+	"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx",
+	"11llllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllllludsr8",
+	"split1checkupstagehandshakeupstreamerranterredcaperredlc445v",
+	"?1v759aa",
+}
+
+var invalidBech32m = []struct {
+	name string
+	s    string
+}{
+	{"hrp character 0x20", "\x201xj0phk"},
+	{"hrp character 0x7f", "\x7f1g6xzxy"},
+	{"hrp character 0x80", "\x801vctc34"},
+	{"longer than 90 characters", "an84characterslonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11d6pts4"},
+	{"no separator", "qyrz8wqd2c9m"},
+	{"empty hrp", "1qyrz8wqd2c9m"},
+	{"invalid data character", "y1b0jsk6g"},
+	{"invalid data character i", "lt1igcx5c0"},
+	{"checksum too short", "in1muywd"},
+	{"invalid checksum character", "mm1crxm3i"},
+	{"invalid checksum character o", "au1s5cgom"},
+	{"checksum over upper case hrp", "M1VUXWEZ"},
+	{"empty hrp, short", "16plkw9"},
+	{"empty hrp, no data", "1p2gdwpf"},
+	// Not in BIP-350, but what this decoder must also refuse.
+	{"mixed case", "A1lqfn3a"},
+	{"bech32 checksum", "a12uel5l"},
+}
+
+func TestBech32mValidVectors(t *testing.T) {
+	for _, s := range validBech32m {
+		t.Run(s, func(t *testing.T) {
+			hrp, data, err := wallet.DecodeBech32m(s)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			encoded, err := wallet.EncodeBech32m(hrp, data)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if encoded != strings.ToLower(s) {
+				t.Errorf("re-encoded as %q", encoded)
+			}
+		})
+	}
+}
+
+func TestBech32mInvalidVectors(t *testing.T) {
+	for _, tt := range invalidBech32m {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := wallet.DecodeBech32m(tt.s); !errors.Is(err, wallet.ErrInvalidAddress) {
+				t.Errorf("DecodeBech32m(%q): error = %v, want %v", tt.s, err, wallet.ErrInvalidAddress)
+			}
+		})
+	}
+}
+
+func TestBech32mAddressPayload(t *testing.T) {
+	params := chainparams.Mainnet
+	hash := bytes.Repeat([]byte{0xab}, 20)
+
+	// 20 bytes fill 32 groups exactly, so there is no padding to spare.
+	groups := make([]byte, 0, 33)
+	var acc, bits uint
+	for _, b := range hash {
+		acc, bits = acc<<8|uint(b), bits+8
+		for bits >= 5 {
+			bits -= 5
+			groups = append(groups, byte(acc>>bits&31))
+		}
+	}
+
+	tests := []struct {
+		name   string
+		groups []byte
+		valid  bool
+	}{
+		{"20-byte hash", groups, true},
+		{"more than four padding bits", append(append([]byte{}, groups...), 0), false},
+		{"non-zero padding", append(append([]byte{}, groups[:30]...), 1), false},
+		{"19-byte hash", append(append([]byte{}, groups[:30]...), 0), false},
+		{"no hash", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, err := wallet.EncodeBech32m(params.Bech32HRP, append([]byte{byte(wallet.PublicKeyHashAddress)}, tt.groups...))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = wallet.DecodeAddress(address, &params)
+
+			if tt.valid && err != nil {
+				t.Errorf("DecodeAddress(%q): %v", address, err)
+			}
+
+			if !tt.valid && !errors.Is(err, wallet.ErrInvalidAddress) {
+				t.Errorf("DecodeAddress(%q): error = %v, want %v", address, err, wallet.ErrInvalidAddress)
+			}
+		})
+	}
+}
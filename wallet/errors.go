@@ -0,0 +1,16 @@
+package wallet
+
+import "errors"
+
+var (
+	ErrSigningFailed           = errors.New("could not sign transaction")
+	ErrKeyGenerationFailed     = errors.New("could not generate key")
+	ErrInvalidAddress          = errors.New("invalid blockchain address")
+	ErrWrongNetwork            = errors.New("address belongs to another network")
+	ErrInvalidMnemonic         = errors.New("invalid mnemonic")
+	ErrInvalidDerivationPath   = errors.New("invalid derivation path")
+	ErrIndexOutOfRange         = errors.New("derivation index out of range")
+	ErrInvalidMultisigPolicy   = errors.New("invalid multisig policy")
+	ErrNotEnoughSignatures     = errors.New("not enough valid signatures")
+	ErrInvalidMessageSignature = errors.New("invalid message signature")
+)
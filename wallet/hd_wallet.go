@@ -0,0 +1,271 @@
+package wallet
+
+import (
+	"../chain_params"
+	"../signature_scheme"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"github.com/tyler-smith/go-bip39"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// HardenedOffset marks a BIP-32 child index as hardened.
+const HardenedOffset uint32 = 0x80000000
+
+const DefaultGapLimit = 20
+
+const (
+	ExternalChain uint32 = 0
+	ChangeChain   uint32 = 1
+)
+
+// Keys are derived with SLIP-10, the BIP-32 scheme generalised to the NIST
+// P-256 curve that wallets use.
+var masterKeySalt = []byte("Nist256p1 seed")
+
+// UsageChecker reports whether an address has appeared in the chain. It
+// can be backed by a local BlockChain or by a remote node.
+type UsageChecker func(address string) (bool, error)
+
+type HDWallet struct {
+	mnemonic string
+	master   *extendedKey
+	params   *chainparams.Params
+}
+
+type extendedKey struct {
+	key       *big.Int
+	chainCode []byte
+}
+
+type DerivedAddress struct {
+	Path           string `json:"path"`
+	Address        string `json:"address"`
+	Bech32mAddress string `json:"bech32mAddress"`
+	Used           bool   `json:"used"`
+}
+
+// NewHDWallet creates a wallet from a fresh 24-word BIP-39 mnemonic.
+func NewHDWallet(passphrase string, params *chainparams.Params) (*HDWallet, error) {
+	entropy, err := bip39.NewEntropy(256)
+
+	if err != nil {
+		return nil, err
+	}
+
+	mnemonic, err := bip39.NewMnemonic(entropy)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return RestoreHDWallet(mnemonic, passphrase, params)
+}
+
+// RestoreHDWallet rebuilds the wallet that mnemonic and passphrase were
+// created with. Any passphrase is accepted; a wrong one simply yields a
+// different, empty wallet.
+func RestoreHDWallet(mnemonic string, passphrase string, params *chainparams.Params) (*HDWallet, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidMnemonic, err)
+	}
+
+	return &HDWallet{
+		mnemonic: mnemonic,
+		master:   newMasterKey(seed),
+		params:   params,
+	}, nil
+}
+
+func (w *HDWallet) Mnemonic() string {
+	return w.mnemonic
+}
+
+// AccountPath returns the BIP-44 path m/44'/coin'/account'/chain/index.
+func (w *HDWallet) AccountPath(account uint32, chain uint32, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'/%d/%d", w.params.HDCoinType, account, chain, index)
+}
+
+// Derive returns the wallet at a path such as m/44'/0'/0'/0/5.
+func (w *HDWallet) Derive(path string) (*Wallet, error) {
+	indexes, err := ParseDerivationPath(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	k := w.master
+	for _, i := range indexes {
+		k = k.child(i)
+	}
+
+	privateKey, err := k.privateKey()
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidDerivationPath, path, err)
+	}
+
+	return FromPrivateKey(privateKey, w.params), nil
+}
+
+// Addresses lists count addresses of an account chain starting at from.
+// Account, chain and every index must be below HardenedOffset.
+func (w *HDWallet) Addresses(account uint32, chain uint32, from uint32, count uint32) ([]DerivedAddress, error) {
+	if account >= HardenedOffset || chain >= HardenedOffset || from >= HardenedOffset || count > HardenedOffset-from {
+		return nil, fmt.Errorf("%w: account %d, chain %d, %d addresses from index %d", ErrIndexOutOfRange, account, chain, count, from)
+	}
+
+	var addresses []DerivedAddress
+
+	for i := from; i < from+count; i++ {
+		path := w.AccountPath(account, chain, i)
+		derived, err := w.Derive(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		bech32mAddress, err := derived.Bech32mAddress()
+
+		if err != nil {
+			return nil, err
+		}
+
+		addresses = append(addresses, DerivedAddress{Path: path, Address: derived.BlockchainAddress(), Bech32mAddress: bech32mAddress})
+	}
+
+	return addresses, nil
+}
+
+// Scan walks the external and change chains of an account and returns
+// every derived address up to gapLimit consecutive unused ones past the
+// last used address.
+func (w *HDWallet) Scan(checker UsageChecker, account uint32, gapLimit uint32) ([]DerivedAddress, error) {
+	var addresses []DerivedAddress
+
+	for _, chain := range []uint32{ExternalChain, ChangeChain} {
+		unused := uint32(0)
+
+		for i := uint32(0); unused < gapLimit; i++ {
+			derived, err := w.Addresses(account, chain, i, 1)
+
+			if err != nil {
+				return nil, err
+			}
+
+			derived[0].Used, err = checker(derived[0].Address)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if derived[0].Used {
+				unused = 0
+			} else {
+				unused++
+			}
+
+			addresses = append(addresses, derived[0])
+		}
+	}
+
+	return addresses, nil
+}
+
+// ParseDerivationPath turns m/44'/0'/0'/0/1 into child indexes; both ' and
+// h mark hardened levels.
+func ParseDerivationPath(path string) ([]uint32, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, fmt.Errorf("%w: %q must start with m", ErrInvalidDerivationPath, path)
+	}
+
+	var indexes []uint32
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+
+		i, err := strconv.ParseUint(part, 10, 32)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidDerivationPath, path)
+		}
+
+		if uint32(i) >= HardenedOffset {
+			return nil, fmt.Errorf("%w: %q: %d is not below %d", ErrIndexOutOfRange, path, i, HardenedOffset)
+		}
+
+		if hardened {
+			i += uint64(HardenedOffset)
+		}
+
+		indexes = append(indexes, uint32(i))
+	}
+
+	return indexes, nil
+}
+
+func newMasterKey(seed []byte) *extendedKey {
+	n := elliptic.P256().Params().N
+	data := seed
+
+	for {
+		mac := hmac.New(sha512.New, masterKeySalt)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		key := new(big.Int).SetBytes(sum[:32])
+		if key.Sign() != 0 && key.Cmp(n) < 0 {
+			return &extendedKey{key: key, chainCode: sum[32:]}
+		}
+
+		data = sum
+	}
+}
+
+func (k *extendedKey) child(index uint32) *extendedKey {
+	curve := elliptic.P256()
+	n := curve.Params().N
+
+	var data []byte
+	if index >= HardenedOffset {
+		data = append([]byte{0x00}, k.key.FillBytes(make([]byte, 32))...)
+	} else {
+		x, y := curve.ScalarBaseMult(k.key.FillBytes(make([]byte, 32)))
+		data = elliptic.MarshalCompressed(curve, x, y)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	for {
+		mac := hmac.New(sha512.New, k.chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		il := new(big.Int).SetBytes(sum[:32])
+		key := new(big.Int).Add(il, k.key)
+		key.Mod(key, n)
+
+		if il.Cmp(n) < 0 && key.Sign() != 0 {
+			return &extendedKey{key: key, chainCode: sum[32:]}
+		}
+
+		data = binary.BigEndian.AppendUint32(append([]byte{0x01}, sum[32:]...), index)
+	}
+}
+
+// privateKey is always a P-256 key: the derivation follows SLIP-10 for
+// nist256p1, so HD wallets do not offer the other schemes.
+func (k *extendedKey) privateKey() (signaturescheme.PrivateKey, error) {
+	return signaturescheme.P256.PrivateKeyFromBytes(k.key.FillBytes(make([]byte, 32)))
+}
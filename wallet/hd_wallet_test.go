@@ -0,0 +1,151 @@
+package wallet
+
+import (
+	"../chain_params"
+	"encoding/hex"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return b
+}
+
+// TestSLIP10Vectors derives test vector 1 for nist256p1 from SLIP-10.
+func TestSLIP10Vectors(t *testing.T) {
+	seed := decodeHex(t, "000102030405060708090a0b0c0d0e0f")
+
+	tests := []struct {
+		path      string
+		chainCode string
+		key       string
+	}{
+		{"m", "beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea", "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2"},
+		{"m/0'", "3460cea53e6a6bb5fb391eeef3237ffd8724bf0a40e94943c98b83825342ee11", "6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c"},
+		{"m/0'/1", "4187afff1aafa8445010097fb99d23aee9f599450c7bd140b6826ac22ba21d0c", "284e9d38d07d21e4e281b645089a94f4cf5a5a81369acf151a1c3a57f18b2129"},
+		{"m/0'/1/2'", "98c7514f562e64e74170cc3cf304ee1ce54d6b6da4f880f313e8204c2a185318", "694596e8a54f252c960eb771a3c41e7e32496d03b954aeb90f61635b8e092aa7"},
+		{"m/0'/1/2'/2", "ba96f776a5c3907d7fd48bde5620ee374d4acfd540378476019eab70790c63a0", "5996c37fd3dd2679039b23ed6f70b506c6b56b3cb5e424681fb0fa64caf82aaa"},
+		{"m/0'/1/2'/2/1000000000", "b9b7b82d326bb9cb5b5b121066feea4eb93d5241103c9e7a18aad40f1dde8059", "21c4f269ef0a5fd1badf47eeacebeeaa3de22eb8e5b0adcd0f27dd99d34d0119"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			indexes, err := ParseDerivationPath(tt.path)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			k := newMasterKey(seed)
+			for _, i := range indexes {
+				k = k.child(i)
+			}
+
+			if chainCode := hex.EncodeToString(k.chainCode); chainCode != tt.chainCode {
+				t.Errorf("chain code = %s, want %s", chainCode, tt.chainCode)
+			}
+
+			if key := hex.EncodeToString(k.key.FillBytes(make([]byte, 32))); key != tt.key {
+				t.Errorf("private key = %s, want %s", key, tt.key)
+			}
+		})
+	}
+}
+
+// TestBIP39Vectors restores wallets from the English BIP-39 vectors, all
+// with passphrase TREZOR, and checks their master key against the seed.
+func TestBIP39Vectors(t *testing.T) {
+	tests := []struct {
+		mnemonic string
+		seed     string
+	}{
+		{"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"},
+		{"legal winner thank year wave sausage worth useful legal winner thank yellow", "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607"},
+		{"letter advice cage absurd amount doctor acoustic avoid letter advice cage above", "d71de856f81a8acc65e6fc851a38d4d7ec216fd0796d0a6827a3ad6ed5511a30fa280f12eb2e47ed2ac03b5c462a0358d18d69fe4f985ec81778c1b370b652a8"},
+		{"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong", "ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mnemonic, func(t *testing.T) {
+			w, err := RestoreHDWallet(tt.mnemonic, "TREZOR", &chainparams.Regtest)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := newMasterKey(decodeHex(t, tt.seed)); !reflect.DeepEqual(w.master, want) {
+				t.Error("master key does not match the vector seed")
+			}
+		})
+	}
+
+	if _, err := RestoreHDWallet("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon", "", &chainparams.Regtest); !errors.Is(err, ErrInvalidMnemonic) {
+		t.Errorf("bad checksum: error = %v, want %v", err, ErrInvalidMnemonic)
+	}
+}
+
+func TestParseDerivationPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		indexes []uint32
+		err     error
+	}{
+		{"m", nil, nil},
+		{"m/44'/0h/0'/0/5", []uint32{44 + HardenedOffset, HardenedOffset, HardenedOffset, 0, 5}, nil},
+		{" m/2147483647 ", []uint32{HardenedOffset - 1}, nil},
+		{"m/2147483647'", []uint32{HardenedOffset - 1 + HardenedOffset}, nil},
+		{"44'/0'", nil, ErrInvalidDerivationPath},
+		{"m/", nil, ErrInvalidDerivationPath},
+		{"m/x", nil, ErrInvalidDerivationPath},
+		{"m/-1", nil, ErrInvalidDerivationPath},
+		{"m/0''", nil, ErrInvalidDerivationPath},
+		{"m/0'h", nil, ErrInvalidDerivationPath},
+		{"m/0hh", nil, ErrInvalidDerivationPath},
+		{"m/4294967296", nil, ErrInvalidDerivationPath},
+		{"m/2147483648", nil, ErrIndexOutOfRange},
+		{"m/2147483648'", nil, ErrIndexOutOfRange},
+		{"m/4294967295h", nil, ErrIndexOutOfRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			indexes, err := ParseDerivationPath(tt.path)
+
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error = %v, want %v", err, tt.err)
+			}
+
+			if !reflect.DeepEqual(indexes, tt.indexes) {
+				t.Errorf("indexes = %v, want %v", indexes, tt.indexes)
+			}
+		})
+	}
+}
+
+func TestAddressesRejectsHardenedIndexes(t *testing.T) {
+	w, err := RestoreHDWallet("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "", &chainparams.Regtest)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Addresses(HardenedOffset, ExternalChain, 0, 1); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("hardened account: error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+
+	if _, err := w.Addresses(0, ExternalChain, HardenedOffset-1, 2); !errors.Is(err, ErrIndexOutOfRange) {
+		t.Errorf("count past the last index: error = %v, want %v", err, ErrIndexOutOfRange)
+	}
+
+	if addresses, err := w.Addresses(0, ExternalChain, HardenedOffset-1, 1); err != nil || len(addresses) != 1 {
+		t.Errorf("last index: %d addresses, error = %v", len(addresses), err)
+	}
+}
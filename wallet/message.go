@@ -0,0 +1,92 @@
+package wallet
+
+import (
+	"../chain_params"
+	"../signature_scheme"
+	"../utils"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+// messagePrefix keeps message signatures apart from transaction ones: a
+// signed message digest can never equal the digest of a transaction.
+const messagePrefix = "Blockchain Signed Message:\n"
+
+// SignedMessage proves that the holder of the key behind Address signed
+// Message. The public key is carried along and checked against the address,
+// since not every scheme can recover it from the signature.
+type SignedMessage struct {
+	Address   string `json:"address"`
+	Message   string `json:"message"`
+	Scheme    string `json:"scheme,omitempty"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+// MessageDigest hashes the prefix, the message length and the message
+// twice with SHA-256.
+func MessageDigest(message string) []byte {
+	b := []byte(messagePrefix)
+	b = binary.AppendUvarint(b, uint64(len(message)))
+	b = append(b, message...)
+
+	first := sha256.Sum256(b)
+	digest := sha256.Sum256(first[:])
+	return digest[:]
+}
+
+func (wallet *Wallet) SignMessage(message string) (*SignedMessage, error) {
+	signature, err := signaturescheme.Sign(wallet.privateKey, MessageDigest(message))
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSigningFailed, err)
+	}
+
+	return &SignedMessage{
+		Address:   wallet.blockchainAddress,
+		Message:   message,
+		Scheme:    wallet.Scheme().Name(),
+		PublicKey: wallet.PublicKeyStr(),
+		Signature: signature.String(),
+	}, nil
+}
+
+// Verify checks the signature and that the public key owns Address, and
+// returns the address in canonical form. Multisig addresses cannot sign
+// messages.
+func (m *SignedMessage) Verify(params *chainparams.Params) (string, error) {
+	a, err := DecodeAddress(m.Address, params)
+
+	if err != nil {
+		return "", err
+	}
+
+	if a.Type != PublicKeyHashAddress {
+		return "", fmt.Errorf("%w: only single-key addresses sign messages", ErrInvalidMessageSignature)
+	}
+
+	publicKey, err := signaturescheme.ParsePublicKey(m.Scheme, m.PublicKey)
+
+	if err != nil {
+		return "", err
+	}
+
+	signature, err := utils.SignatureFromString(m.Signature)
+
+	if err != nil {
+		return "", err
+	}
+
+	if !signaturescheme.Verify(publicKey, MessageDigest(m.Message), signature) {
+		return "", ErrInvalidMessageSignature
+	}
+
+	address := a.String(params)
+
+	if AddressFromPublicKey(publicKey, params) != address {
+		return "", fmt.Errorf("%w: public key does not own %s", ErrInvalidMessageSignature, address)
+	}
+
+	return address, nil
+}
@@ -0,0 +1,217 @@
+package wallet
+
+import (
+	"../chain_params"
+	"../signature_scheme"
+	"../utils"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/crypto/ripemd160"
+	"sort"
+)
+
+const MaxMultisigKeys = 15
+
+// MultisigPolicy is an M-of-N spending rule. Keys are kept sorted, so the
+// same set of keys and threshold always gives the same address no matter
+// the order the parties listed them in.
+type MultisigPolicy struct {
+	Threshold  int
+	PublicKeys []signaturescheme.PublicKey
+}
+
+type multisigPolicyJSON struct {
+	Threshold  int      `json:"threshold"`
+	PublicKeys []string `json:"publicKeys"`
+}
+
+// PartialSignature is one party's signature over a transaction digest.
+type PartialSignature struct {
+	PublicKey signaturescheme.PublicKey
+	Signature *utils.Signature
+}
+
+type partialSignatureJSON struct {
+	Scheme    string `json:"scheme,omitempty"`
+	PublicKey string `json:"publicKey"`
+	Signature string `json:"signature"`
+}
+
+func NewMultisigPolicy(threshold int, publicKeys []signaturescheme.PublicKey) (*MultisigPolicy, error) {
+	if len(publicKeys) == 0 || len(publicKeys) > MaxMultisigKeys {
+		return nil, fmt.Errorf("%w: needs 1 to %d keys, got %d", ErrInvalidMultisigPolicy, MaxMultisigKeys, len(publicKeys))
+	}
+
+	if threshold < 1 || threshold > len(publicKeys) {
+		return nil, fmt.Errorf("%w: threshold must be between 1 and %d", ErrInvalidMultisigPolicy, len(publicKeys))
+	}
+
+	sorted := make([]signaturescheme.PublicKey, len(publicKeys))
+	copy(sorted, publicKeys)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(encodePublicKey(sorted[i]), encodePublicKey(sorted[j])) < 0
+	})
+
+	for i := 1; i < len(sorted); i++ {
+		if bytes.Equal(encodePublicKey(sorted[i-1]), encodePublicKey(sorted[i])) {
+			return nil, fmt.Errorf("%w: duplicate public key", ErrInvalidMultisigPolicy)
+		}
+	}
+
+	return &MultisigPolicy{
+		Threshold:  threshold,
+		PublicKeys: sorted,
+	}, nil
+}
+
+// ParseMultisigPolicy builds a policy from public keys as written by
+// signaturescheme.FormatPublicKey: plain hex for P-256 and "<scheme>:<hex>"
+// otherwise. The keys of one policy may mix schemes.
+func ParseMultisigPolicy(threshold int, publicKeys []string) (*MultisigPolicy, error) {
+	var keys []signaturescheme.PublicKey
+	for _, s := range publicKeys {
+		key, err := signaturescheme.ParseFormattedPublicKey(s)
+
+		if err != nil {
+			return nil, fmt.Errorf("%w: public key %q: %v", ErrInvalidMultisigPolicy, s, err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return NewMultisigPolicy(threshold, keys)
+}
+
+// Script is the byte string the address commits to: the threshold, the key
+// count and every key as its scheme id followed by its bytes.
+func (p *MultisigPolicy) Script() []byte {
+	script := []byte{byte(p.Threshold), byte(len(p.PublicKeys))}
+	for _, key := range p.PublicKeys {
+		script = append(script, encodePublicKey(key)...)
+	}
+
+	return script
+}
+
+func (p *MultisigPolicy) Hash() []byte {
+	digest := sha256.Sum256(p.Script())
+
+	h := ripemd160.New()
+	h.Write(digest[:])
+	return h.Sum(nil)
+}
+
+func (p *MultisigPolicy) Address(params *chainparams.Params) string {
+	a := &Address{Type: MultisigAddress, Hash: p.Hash()}
+	return a.String(params)
+}
+
+func (p *MultisigPolicy) Bech32mAddress(params *chainparams.Params) (string, error) {
+	a := &Address{Type: MultisigAddress, Hash: p.Hash()}
+	return a.Bech32m(params)
+}
+
+func (p *MultisigPolicy) Contains(publicKey signaturescheme.PublicKey) bool {
+	for _, key := range p.PublicKeys {
+		if bytes.Equal(encodePublicKey(key), encodePublicKey(publicKey)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Verify checks that at least Threshold distinct keys of the policy signed
+// digest. Signatures from keys outside the policy, repeated keys and
+// signatures that do not verify are not counted.
+func (p *MultisigPolicy) Verify(digest []byte, signatures []PartialSignature) error {
+	signers := make(map[string]bool)
+
+	for _, s := range signatures {
+		if s.PublicKey == nil || s.Signature == nil || !p.Contains(s.PublicKey) {
+			continue
+		}
+
+		if signaturescheme.Verify(s.PublicKey, digest, s.Signature) {
+			signers[string(encodePublicKey(s.PublicKey))] = true
+		}
+	}
+
+	if len(signers) < p.Threshold {
+		return fmt.Errorf("%w: %d of %d required signers", ErrNotEnoughSignatures, len(signers), p.Threshold)
+	}
+
+	return nil
+}
+
+func (p *MultisigPolicy) MarshalJSON() ([]byte, error) {
+	v := multisigPolicyJSON{Threshold: p.Threshold}
+	for _, key := range p.PublicKeys {
+		v.PublicKeys = append(v.PublicKeys, signaturescheme.FormatPublicKey(key))
+	}
+
+	return json.Marshal(v)
+}
+
+func (p *MultisigPolicy) UnmarshalJSON(data []byte) error {
+	v := multisigPolicyJSON{}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	policy, err := ParseMultisigPolicy(v.Threshold, v.PublicKeys)
+
+	if err != nil {
+		return err
+	}
+
+	*p = *policy
+	return nil
+}
+
+func (s PartialSignature) MarshalJSON() ([]byte, error) {
+	v := partialSignatureJSON{}
+
+	if s.PublicKey != nil {
+		v.Scheme = s.PublicKey.Scheme().Name()
+		v.PublicKey = hex.EncodeToString(s.PublicKey.Bytes())
+	}
+
+	if s.Signature != nil {
+		v.Signature = s.Signature.String()
+	}
+
+	return json.Marshal(v)
+}
+
+func (s *PartialSignature) UnmarshalJSON(data []byte) error {
+	v := partialSignatureJSON{}
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	publicKey, err := signaturescheme.ParsePublicKey(v.Scheme, v.PublicKey)
+
+	if err != nil {
+		return fmt.Errorf("publicKey: %w", err)
+	}
+
+	signature, err := utils.SignatureFromString(v.Signature)
+
+	if err != nil {
+		return fmt.Errorf("signature: %w", err)
+	}
+
+	s.PublicKey = publicKey
+	s.Signature = signature
+	return nil
+}
+
+func encodePublicKey(publicKey signaturescheme.PublicKey) []byte {
+	return append([]byte{byte(publicKey.Scheme().ID())}, publicKey.Bytes()...)
+}
@@ -0,0 +1,156 @@
+package wallet_test
+
+import (
+	"../chain_params"
+	"../signature_scheme"
+	"../wallet"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func newKeys(t *testing.T, n int) []signaturescheme.PrivateKey {
+	t.Helper()
+	var keys []signaturescheme.PrivateKey
+
+	for i := 0; i < n; i++ {
+		key, err := signaturescheme.P256.GenerateKey()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func publicKeys(keys []signaturescheme.PrivateKey) []signaturescheme.PublicKey {
+	var publicKeys []signaturescheme.PublicKey
+	for _, key := range keys {
+		publicKeys = append(publicKeys, key.PublicKey())
+	}
+
+	return publicKeys
+}
+
+func sign(t *testing.T, key signaturescheme.PrivateKey, digest []byte) wallet.PartialSignature {
+	t.Helper()
+	signature, err := signaturescheme.Sign(key, digest)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return wallet.PartialSignature{PublicKey: key.PublicKey(), Signature: signature}
+}
+
+func TestNewMultisigPolicy(t *testing.T) {
+	keys := publicKeys(newKeys(t, wallet.MaxMultisigKeys+1))
+
+	tests := []struct {
+		name      string
+		threshold int
+		keys      []signaturescheme.PublicKey
+		valid     bool
+	}{
+		{"1 of 1", 1, keys[:1], true},
+		{"2 of 3", 2, keys[:3], true},
+		{"3 of 3", 3, keys[:3], true},
+		{"15 of 15", wallet.MaxMultisigKeys, keys[:wallet.MaxMultisigKeys], true},
+		{"no keys", 1, nil, false},
+		{"threshold 0", 0, keys[:3], false},
+		{"threshold above the key count", 4, keys[:3], false},
+		{"more than 15 keys", 1, keys, false},
+		{"duplicate key", 2, []signaturescheme.PublicKey{keys[0], keys[1], keys[0]}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := wallet.NewMultisigPolicy(tt.threshold, tt.keys)
+
+			if tt.valid && err != nil {
+				t.Fatal(err)
+			}
+
+			if !tt.valid && !errors.Is(err, wallet.ErrInvalidMultisigPolicy) {
+				t.Errorf("error = %v, want %v", err, wallet.ErrInvalidMultisigPolicy)
+			}
+		})
+	}
+}
+
+func TestMultisigAddressIgnoresKeyOrder(t *testing.T) {
+	keys := publicKeys(newKeys(t, 3))
+	params := chainparams.Regtest
+
+	forward, err := wallet.NewMultisigPolicy(2, keys)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backward, err := wallet.NewMultisigPolicy(2, []signaturescheme.PublicKey{keys[2], keys[1], keys[0]})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if forward.Address(&params) != backward.Address(&params) {
+		t.Error("the same keys in another order give another address")
+	}
+
+	other, err := wallet.NewMultisigPolicy(3, keys)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if forward.Address(&params) == other.Address(&params) {
+		t.Error("another threshold gives the same address")
+	}
+}
+
+func TestMultisigVerify(t *testing.T) {
+	keys := newKeys(t, 4)
+	a, b, c, outsider := keys[0], keys[1], keys[2], keys[3]
+	policy, err := wallet.NewMultisigPolicy(2, publicKeys(keys[:3]))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	digest := sha256.Sum256([]byte("transaction"))
+	otherDigest := sha256.Sum256([]byte("another transaction"))
+
+	tests := []struct {
+		name       string
+		signatures []wallet.PartialSignature
+		valid      bool
+	}{
+		{"2 of 3", []wallet.PartialSignature{sign(t, a, digest[:]), sign(t, c, digest[:])}, true},
+		{"3 of 3", []wallet.PartialSignature{sign(t, a, digest[:]), sign(t, b, digest[:]), sign(t, c, digest[:])}, true},
+		{"no signatures", nil, false},
+		{"1 of 3", []wallet.PartialSignature{sign(t, b, digest[:])}, false},
+		{"one key twice", []wallet.PartialSignature{sign(t, a, digest[:]), sign(t, a, digest[:])}, false},
+		{"key outside the policy", []wallet.PartialSignature{sign(t, a, digest[:]), sign(t, outsider, digest[:])}, false},
+		{"signature over another digest", []wallet.PartialSignature{sign(t, a, digest[:]), sign(t, b, otherDigest[:])}, false},
+		{"signature without a key", []wallet.PartialSignature{sign(t, a, digest[:]), {Signature: sign(t, b, digest[:]).Signature}}, false},
+		{"invalid ones alongside 2 of 3", []wallet.PartialSignature{sign(t, outsider, digest[:]), sign(t, a, digest[:]), sign(t, a, digest[:]), sign(t, b, digest[:])}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Verify(digest[:], tt.signatures)
+
+			if tt.valid && err != nil {
+				t.Fatal(err)
+			}
+
+			if !tt.valid && !errors.Is(err, wallet.ErrNotEnoughSignatures) {
+				t.Errorf("error = %v, want %v", err, wallet.ErrNotEnoughSignatures)
+			}
+		})
+	}
+}
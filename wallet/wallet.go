@@ -1,60 +1,97 @@
 package wallet
 
 import (
+	"../chain_params"
+	"../signature_scheme"
 	"../utils"
-	"crypto/ecdsa"
-	"crypto/elliptic"
-	"crypto/rand"
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/btcsuite/btcd/btcutil/base58"
 	"golang.org/x/crypto/ripemd160"
+	"math/big"
 )
 
 type Wallet struct {
-	privateKey        *ecdsa.PrivateKey
-	publicKey         *ecdsa.PublicKey
+	privateKey        signaturescheme.PrivateKey
+	publicKey         signaturescheme.PublicKey
 	blockchainAddress string
+	params            *chainparams.Params
 }
 
 type Transaction struct {
-	senderPrivateKey           *ecdsa.PrivateKey
-	senderPublicKey            *ecdsa.PublicKey
+	senderPrivateKey           signaturescheme.PrivateKey
+	senderPublicKey            signaturescheme.PublicKey
 	senderBlockchainAddress    string
 	recipientBlockchainAddress string
 	value                      float32
+	nonce                      uint64
 }
 
-const mainNetPrefix = 0x00
+func NewWallet(params *chainparams.Params) (*Wallet, error) {
+	return NewWalletWithScheme(signaturescheme.Default, params)
+}
 
-func NewWallet() *Wallet {
-	// 1 Creating ECDSA private  and public keys
-	wallet := Wallet{}
-	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+func NewWalletWithScheme(scheme signaturescheme.Scheme, params *chainparams.Params) (*Wallet, error) {
+	// 1 Creating private and public keys
+	privateKey, err := scheme.GenerateKey()
 
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("%w: %v", ErrKeyGenerationFailed, err)
 	}
 
-	wallet.privateKey = privateKey
-	wallet.publicKey = &privateKey.PublicKey
+	return FromPrivateKey(privateKey, params), nil
+}
+
+// FromPrivateKey wraps an existing key, e.g. one derived from a mnemonic or
+// loaded from a keystore.
+func FromPrivateKey(privateKey signaturescheme.PrivateKey, params *chainparams.Params) *Wallet {
+	return &Wallet{
+		privateKey:        privateKey,
+		publicKey:         privateKey.PublicKey(),
+		blockchainAddress: AddressFromPublicKey(privateKey.PublicKey(), params),
+		params:            params,
+	}
+}
+
+func AddressFromPublicKey(publicKey signaturescheme.PublicKey, params *chainparams.Params) string {
+	return base58CheckAddress(params.AddressVersion, PublicKeyHash(publicKey))
+}
 
+// Bech32mAddressFromPublicKey is the Bech32m spelling of the same address.
+func Bech32mAddressFromPublicKey(publicKey signaturescheme.PublicKey, params *chainparams.Params) (string, error) {
+	a := &Address{Type: PublicKeyHashAddress, Hash: PublicKeyHash(publicKey)}
+	return a.Bech32m(params)
+}
+
+// PublicKeyHash commits to the key's scheme as well as its bytes, so the
+// same bytes read under another scheme give another address. P-256 keeps
+// the original preimage, the unpadded X and Y, so existing addresses hold.
+func PublicKeyHash(publicKey signaturescheme.PublicKey) []byte {
 	// 2 Perform SHA-256 hashing on the public key
+	b := publicKey.Bytes()
 
 	hash2 := sha256.New()
-	hash2.Write(wallet.publicKey.X.Bytes())
-	hash2.Write(wallet.publicKey.Y.Bytes())
+	if publicKey.Scheme().ID() == signaturescheme.P256ID {
+		hash2.Write(new(big.Int).SetBytes(b[:32]).Bytes())
+		hash2.Write(new(big.Int).SetBytes(b[32:]).Bytes())
+	} else {
+		hash2.Write([]byte{byte(publicKey.Scheme().ID())})
+		hash2.Write(b)
+	}
 	digest2 := hash2.Sum(nil)
 
 	// 3 Perform RIPEMD-160 hashing on the result of SHA-256
 	hash3 := ripemd160.New()
 	hash3.Write(digest2)
-	digest3 := hash3.Sum(nil)
+	return hash3.Sum(nil)
+}
 
-	// 4 Add version byte in front of RIPEMD-160 hash (0x00 for main net)
+func base58CheckAddress(version byte, digest3 []byte) string {
+	// 4 Add the network version byte in front of RIPEMD-160 hash
 	vd4 := make([]byte, 21)
-	vd4[0] = mainNetPrefix
+	vd4[0] = version
 
 	copy(vd4[1:], digest3[:])
 
@@ -77,61 +114,80 @@ func NewWallet() *Wallet {
 	copy(dc8[21:], chsum)
 
 	// 9 Convert the result from a byte string into base58
-	address := base58.Encode(dc8)
-	wallet.blockchainAddress = address
-
-	return &wallet
+	return base58.Encode(dc8)
 }
 
 func (wallet *Wallet) BlockchainAddress() string {
 	return wallet.blockchainAddress
 }
 
-func (wallet *Wallet) PrivateKey() *ecdsa.PrivateKey {
+func (wallet *Wallet) Bech32mAddress() (string, error) {
+	return Bech32mAddressFromPublicKey(wallet.publicKey, wallet.params)
+}
+
+func (wallet *Wallet) Scheme() signaturescheme.Scheme {
+	return wallet.privateKey.Scheme()
+}
+
+func (wallet *Wallet) PrivateKey() signaturescheme.PrivateKey {
 	return wallet.privateKey
 }
 
 func (wallet *Wallet) PrivateKeyStr() string {
-	return fmt.Sprintf("%x", wallet.privateKey.D.Bytes())
+	return hex.EncodeToString(wallet.privateKey.Bytes())
 }
 
-func (wallet *Wallet) PublicKey() *ecdsa.PublicKey {
+func (wallet *Wallet) PublicKey() signaturescheme.PublicKey {
 	return wallet.publicKey
 }
 
 func (wallet *Wallet) PublicKeyStr() string {
-	return fmt.Sprintf("%064x%064x", wallet.publicKey.X.Bytes(), wallet.publicKey.Y.Bytes())
+	return hex.EncodeToString(wallet.publicKey.Bytes())
 }
 
-func NewTransaction(privateKey *ecdsa.PrivateKey, publicKey *ecdsa.PublicKey, senderAddress string, recipientAddress string, value float32) *Transaction {
+// NewTransaction prepares a transfer for signing. nonce must be one the
+// sender has not used before; the node refuses the transfer otherwise, so a
+// signed transaction cannot be sent twice.
+func NewTransaction(privateKey signaturescheme.PrivateKey, publicKey signaturescheme.PublicKey, senderAddress string, recipientAddress string, value float32, nonce uint64) *Transaction {
 	return &Transaction{
 		senderPrivateKey:           privateKey,
 		senderPublicKey:            publicKey,
 		senderBlockchainAddress:    senderAddress,
 		recipientBlockchainAddress: recipientAddress,
 		value:                      value,
+		nonce:                      nonce,
 	}
 }
 
-func (t *Transaction) GenerateSignature() *utils.Signature {
+func (t *Transaction) GenerateSignature() (*utils.Signature, error) {
 	m, err := json.Marshal(t)
 
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("%w: %v", ErrSigningFailed, err)
 	}
 
 	hash := sha256.Sum256(m)
-	r, s, err := ecdsa.Sign(rand.Reader, t.senderPrivateKey, hash[:])
+	signature, err := signaturescheme.Sign(t.senderPrivateKey, hash[:])
 
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("%w: %v", ErrSigningFailed, err)
 	}
 
-	return &utils.Signature{
-		R: r,
-		S: s,
+	return signature, nil
+}
+
+// PartialSignature signs t as one party of a multisig sender.
+func (t *Transaction) PartialSignature() (PartialSignature, error) {
+	signature, err := t.GenerateSignature()
+
+	if err != nil {
+		return PartialSignature{}, err
 	}
 
+	return PartialSignature{
+		PublicKey: t.senderPublicKey,
+		Signature: signature,
+	}, nil
 }
 
 func (t *Transaction) MarshalJSON() ([]byte, error) {
@@ -139,21 +195,11 @@ func (t *Transaction) MarshalJSON() ([]byte, error) {
 		SenderAddress    string  `json:"senderBlockchainAddress"`
 		RecipientAddress string  `json:"recipientBlockchainAddress"`
 		Value            float32 `json:"value"`
+		Nonce            uint64  `json:"nonce,omitempty"`
 	}{
 		SenderAddress:    t.senderBlockchainAddress,
 		RecipientAddress: t.recipientBlockchainAddress,
 		Value:            t.value,
-	})
-}
-
-func (wallet *Wallet) MarshallJson() ([]byte, error) {
-	return json.Marshal(struct {
-		PrivateKey        string `json:"privateKey"`
-		PublicKey         string `json:"publicKey"`
-		BlockchainAddress string `json:"blockchainAddress"`
-	}{
-		PrivateKey:        wallet.PrivateKeyStr(),
-		PublicKey:         wallet.PublicKeyStr(),
-		BlockchainAddress: wallet.blockchainAddress,
+		Nonce:            t.nonce,
 	})
 }
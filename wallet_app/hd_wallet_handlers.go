@@ -0,0 +1,120 @@
+package main
+
+import (
+	"../api_error"
+	"../wallet"
+	"./requests/hd_wallet_request"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo"
+	"net/http"
+	"net/url"
+)
+
+func HandleCreateHDWallet(c echo.Context) error {
+	req := hd_wallet_request.CreateRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	w, err := wallet.NewHDWallet(req.Passphrase, params)
+
+	if err != nil {
+		return err
+	}
+
+	addresses, err := w.Addresses(0, wallet.ExternalChain, 0, 1)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, struct {
+		Mnemonic  string                  `json:"mnemonic"`
+		Addresses []wallet.DerivedAddress `json:"addresses"`
+	}{
+		Mnemonic:  w.Mnemonic(),
+		Addresses: addresses,
+	})
+}
+
+func HandleListHDAddresses(c echo.Context) error {
+	req := hd_wallet_request.AddressesRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	w, err := wallet.RestoreHDWallet(*req.Mnemonic, req.Passphrase, params)
+
+	if err != nil {
+		return err
+	}
+
+	addresses, err := w.Addresses(req.Account, req.Chain, req.From, req.Count)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, addresses)
+}
+
+// HandleScanHDWallet asks the node which derived addresses have been used.
+func HandleScanHDWallet(c echo.Context) error {
+	req := hd_wallet_request.ScanRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if req.GapLimit == 0 {
+		req.GapLimit = wallet.DefaultGapLimit
+	}
+
+	w, err := wallet.RestoreHDWallet(*req.Mnemonic, req.Passphrase, params)
+
+	if err != nil {
+		return err
+	}
+
+	addresses, err := w.Scan(nodeUsageChecker, req.Account, req.GapLimit)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, addresses)
+}
+
+func nodeUsageChecker(address string) (bool, error) {
+	res, err := http.Get(cfg.NodeURL + "/wallet-activity/" + url.PathEscape(address))
+
+	if err != nil {
+		return false, upstreamError(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return false, upstreamError(fmt.Errorf("node answered %d", res.StatusCode))
+	}
+
+	activity := struct {
+		Used bool `json:"used"`
+	}{}
+
+	if err := json.NewDecoder(res.Body).Decode(&activity); err != nil {
+		return false, upstreamError(err)
+	}
+
+	return activity.Used, nil
+}
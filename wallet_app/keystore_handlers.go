@@ -0,0 +1,186 @@
+package main
+
+import (
+	"../api_error"
+	"../keystore"
+	"../signature_scheme"
+	"../wallet"
+	"./requests/keystore_request"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/labstack/echo"
+	"log"
+	"net/http"
+)
+
+func HandleListKeys(c echo.Context) error {
+	addresses, err := keys.Addresses()
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, struct {
+		Addresses []string `json:"addresses"`
+	}{
+		Addresses: addresses,
+	})
+}
+
+// HandleCreateKey generates a wallet and stores it encrypted. Only the
+// address and public key are answered; the private key never leaves the
+// keystore.
+func HandleCreateKey(c echo.Context) error {
+	req := keystore_request.CreateRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	scheme, err := signaturescheme.ByName(req.Scheme)
+
+	if err != nil {
+		return err
+	}
+
+	w, err := wallet.NewWalletWithScheme(scheme, params)
+
+	if err != nil {
+		return err
+	}
+
+	if err := keys.Store(w, *req.Passphrase); err != nil {
+		return err
+	}
+
+	return storedKeyResponse(c, w)
+}
+
+// HandleImportKey restores a key from a mnemonic or a key file into the
+// keystore. An address that is already there is refused rather than
+// overwritten under a new passphrase.
+func HandleImportKey(c echo.Context) error {
+	req := keystore_request.ImportRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	var w *wallet.Wallet
+	if req.Mnemonic != nil {
+		hd, err := wallet.RestoreHDWallet(*req.Mnemonic, req.MnemonicPassphrase, params)
+
+		if err != nil {
+			return err
+		}
+
+		path := req.Path
+		if path == "" {
+			path = hd.AccountPath(0, wallet.ExternalChain, 0)
+		}
+
+		if w, err = hd.Derive(path); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if w, err = req.KeyFile.Decrypt(*req.KeyFilePassphrase, params); err != nil {
+			return err
+		}
+	}
+
+	if keys.Has(w.BlockchainAddress()) {
+		return fmt.Errorf("%w: %s", keystore.ErrKeyExists, w.BlockchainAddress())
+	}
+
+	if err := keys.Store(w, *req.Passphrase); err != nil {
+		return err
+	}
+
+	return storedKeyResponse(c, w)
+}
+
+type keyResponse struct {
+	BlockchainAddress string `json:"blockchainAddress"`
+	Bech32mAddress    string `json:"bech32mAddress"`
+	Scheme            string `json:"scheme"`
+	PublicKey         string `json:"publicKey"`
+	Registered        bool   `json:"registered"`
+}
+
+// storedKeyResponse registers the new address with the node and answers
+// with the key. Only the address is sent to the node; a node that cannot be
+// reached leaves the key stored but unregistered.
+func storedKeyResponse(c echo.Context, w *wallet.Wallet) error {
+	bech32mAddress, err := w.Bech32mAddress()
+
+	if err != nil {
+		return err
+	}
+
+	err = registerAddress(w.BlockchainAddress())
+
+	if err != nil {
+		log.Printf("could not register %s with the node: %v", w.BlockchainAddress(), err)
+	}
+
+	return c.JSON(http.StatusCreated, keyResponse{
+		BlockchainAddress: w.BlockchainAddress(),
+		Bech32mAddress:    bech32mAddress,
+		Scheme:            w.Scheme().Name(),
+		PublicKey:         w.PublicKeyStr(),
+		Registered:        err == nil,
+	})
+}
+
+func registerAddress(address string) error {
+	m, err := json.Marshal(struct {
+		Address string `json:"address"`
+	}{
+		Address: address,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	res, err := http.Post(cfg.NodeURL+"/addresses", "application/json", bytes.NewBuffer(m))
+
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return fmt.Errorf("node answered %s", res.Status)
+	}
+
+	return nil
+}
+
+func HandleChangePassphrase(c echo.Context) error {
+	req := keystore_request.ChangePassphraseRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := keys.ChangePassphrase(c.Param("address"), *req.OldPassphrase, *req.NewPassphrase); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
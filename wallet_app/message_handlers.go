@@ -0,0 +1,65 @@
+package main
+
+import (
+	"../api_error"
+	"../wallet"
+	"./requests/message_request"
+	"github.com/labstack/echo"
+	"net/http"
+)
+
+// HandleSignMessage signs a message with a keystore key, e.g. to prove
+// ownership of an address to a dashboard login without moving funds.
+func HandleSignMessage(c echo.Context) error {
+	req := message_request.SignRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	address, err := wallet.CanonicalAddress(*req.Address, params)
+
+	if err != nil {
+		return err
+	}
+
+	w, err := keys.Load(address, *req.Passphrase)
+
+	if err != nil {
+		return err
+	}
+
+	signed, err := w.SignMessage(*req.Message)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, signed)
+}
+
+func HandleVerifyMessage(c echo.Context) error {
+	signed := wallet.SignedMessage{}
+
+	if err := c.Bind(&signed); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	address, err := signed.Verify(params)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, struct {
+		Valid   bool   `json:"valid"`
+		Address string `json:"address"`
+	}{
+		Valid:   true,
+		Address: address,
+	})
+}
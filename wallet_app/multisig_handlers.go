@@ -0,0 +1,177 @@
+package main
+
+import (
+	"../api_error"
+	"../offline_tx"
+	"../wallet"
+	"./requests/multisig_request"
+	"bytes"
+	"encoding/json"
+	"github.com/labstack/echo"
+	"io/ioutil"
+	"net/http"
+)
+
+// The multisig handlers pass the same transaction file around that
+// wallet_cli uses, so parties can mix the API and offline signing.
+
+func HandleCreateMultisigAddress(c echo.Context) error {
+	req := multisig_request.AddressRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	policy, err := wallet.ParseMultisigPolicy(req.Threshold, req.PublicKeys)
+
+	if err != nil {
+		return err
+	}
+
+	bech32mAddress, err := policy.Bech32mAddress(params)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, struct {
+		BlockchainAddress string                 `json:"blockchainAddress"`
+		Bech32mAddress    string                 `json:"bech32mAddress"`
+		Multisig          *wallet.MultisigPolicy `json:"multisig"`
+	}{
+		BlockchainAddress: policy.Address(params),
+		Bech32mAddress:    bech32mAddress,
+		Multisig:          policy,
+	})
+}
+
+func HandleCreateMultisigTransaction(c echo.Context) error {
+	req := multisig_request.CreateRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	// Without a nonce the file takes the next one the node knows of.
+	if req.Nonce == nil {
+		nonce, err := nextNonce(*req.SenderBlockchainAddress)
+
+		if err != nil {
+			return err
+		}
+		req.Nonce = &nonce
+	}
+
+	f, err := offlinetx.New(*req.SenderBlockchainAddress, *req.RecipientBlockchainAddress, *req.Value, *req.Nonce, "", req.Multisig, params)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, f)
+}
+
+// HandleSignMultisigTransaction adds the signature of one keystore key.
+func HandleSignMultisigTransaction(c echo.Context) error {
+	req := multisig_request.SignRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	w, err := keys.Load(*req.SignerAddress, *req.Passphrase)
+
+	if err != nil {
+		return err
+	}
+
+	if err := req.Transaction.Sign(w, params); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, req.Transaction)
+}
+
+func HandleCombineMultisigTransactions(c echo.Context) error {
+	req := multisig_request.CombineRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	f := req.Transactions[0]
+	for _, other := range req.Transactions[1:] {
+		if err := f.Combine(other); err != nil {
+			return err
+		}
+	}
+
+	if err := f.Check(params); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, f)
+}
+
+func HandleBroadcastMultisigTransaction(c echo.Context) error {
+	req := multisig_request.BroadcastRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Transaction.Check(params); err != nil {
+		return err
+	}
+
+	bt, err := req.Transaction.Request()
+
+	if err != nil {
+		return err
+	}
+
+	m, err := json.Marshal(bt)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := http.Post(cfg.NodeURL+"/transactions", "application/json", bytes.NewBuffer(m))
+
+	if err != nil {
+		return upstreamError(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		body, err := ioutil.ReadAll(res.Body)
+
+		if err != nil {
+			return upstreamError(err)
+		}
+
+		return c.JSONBlob(res.StatusCode, body)
+	}
+
+	return c.JSON(http.StatusOK, bt)
+}
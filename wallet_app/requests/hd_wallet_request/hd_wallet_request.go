@@ -0,0 +1,73 @@
+package hd_wallet_request
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	maxAddressCount = 1000
+	// maxIndex is the highest non-hardened BIP-32 index.
+	maxIndex = 1<<31 - 1
+)
+
+var (
+	ErrMissingField = errors.New("missing hd wallet field")
+	ErrOutOfRange   = errors.New("hd wallet field out of range")
+)
+
+type CreateRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+type AddressesRequest struct {
+	Mnemonic   *string `json:"mnemonic"`
+	Passphrase string  `json:"passphrase"`
+	Account    uint32  `json:"account"`
+	Chain      uint32  `json:"chain"`
+	From       uint32  `json:"from"`
+	Count      uint32  `json:"count"`
+}
+
+type ScanRequest struct {
+	Mnemonic   *string `json:"mnemonic"`
+	Passphrase string  `json:"passphrase"`
+	Account    uint32  `json:"account"`
+	GapLimit   uint32  `json:"gapLimit"`
+}
+
+func (r AddressesRequest) Validate() error {
+	if r.Mnemonic == nil {
+		return fmt.Errorf("%w: mnemonic is required", ErrMissingField)
+	}
+
+	if r.Count == 0 || r.Count > maxAddressCount {
+		return fmt.Errorf("%w: count must be between 1 and %d", ErrOutOfRange, maxAddressCount)
+	}
+
+	if uint64(r.From)+uint64(r.Count) > maxIndex+1 {
+		return fmt.Errorf("%w: from + count must not exceed %d", ErrOutOfRange, uint64(maxIndex)+1)
+	}
+
+	if r.Account > maxIndex || r.Chain > maxIndex {
+		return fmt.Errorf("%w: account and chain must not exceed %d", ErrOutOfRange, maxIndex)
+	}
+
+	return nil
+}
+
+func (r ScanRequest) Validate() error {
+	if r.Mnemonic == nil {
+		return fmt.Errorf("%w: mnemonic is required", ErrMissingField)
+	}
+
+	if r.GapLimit > maxAddressCount {
+		return fmt.Errorf("%w: gapLimit must not exceed %d", ErrOutOfRange, maxAddressCount)
+	}
+
+	if r.Account > maxIndex {
+		return fmt.Errorf("%w: account must not exceed %d", ErrOutOfRange, maxIndex)
+	}
+
+	return nil
+}
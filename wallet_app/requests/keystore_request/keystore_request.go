@@ -0,0 +1,68 @@
+package keystore_request
+
+import (
+	"../../../keystore"
+	"errors"
+	"fmt"
+)
+
+var ErrMissingField = errors.New("missing keystore field")
+
+// CreateRequest may name a signature scheme; without one the key is P-256.
+type CreateRequest struct {
+	Passphrase *string `json:"passphrase"`
+	Scheme     string  `json:"scheme"`
+}
+
+// ImportRequest restores a key either from a mnemonic, at Path or the
+// first receiving address, or from a key file written by another keystore.
+// The key is stored under Passphrase.
+type ImportRequest struct {
+	Passphrase         *string           `json:"passphrase"`
+	Mnemonic           *string           `json:"mnemonic"`
+	MnemonicPassphrase string            `json:"mnemonicPassphrase"`
+	Path               string            `json:"path"`
+	KeyFile            *keystore.KeyFile `json:"keyFile"`
+	KeyFilePassphrase  *string           `json:"keyFilePassphrase"`
+}
+
+type ChangePassphraseRequest struct {
+	OldPassphrase *string `json:"oldPassphrase"`
+	NewPassphrase *string `json:"newPassphrase"`
+}
+
+func (r CreateRequest) Validate() error {
+	if r.Passphrase == nil || *r.Passphrase == "" {
+		return fmt.Errorf("%w: passphrase is required", ErrMissingField)
+	}
+
+	return nil
+}
+
+func (r ImportRequest) Validate() error {
+	if r.Passphrase == nil || *r.Passphrase == "" {
+		return fmt.Errorf("%w: passphrase is required", ErrMissingField)
+	}
+
+	if (r.Mnemonic == nil) == (r.KeyFile == nil) {
+		return fmt.Errorf("%w: exactly one of mnemonic and keyFile is required", ErrMissingField)
+	}
+
+	if r.KeyFile != nil && r.KeyFilePassphrase == nil {
+		return fmt.Errorf("%w: keyFilePassphrase is required with keyFile", ErrMissingField)
+	}
+
+	return nil
+}
+
+func (r ChangePassphraseRequest) Validate() error {
+	if r.OldPassphrase == nil {
+		return fmt.Errorf("%w: oldPassphrase is required", ErrMissingField)
+	}
+
+	if r.NewPassphrase == nil || *r.NewPassphrase == "" {
+		return fmt.Errorf("%w: newPassphrase is required", ErrMissingField)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package message_request
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrMissingField = errors.New("missing message field")
+
+// SignRequest names a keystore key by its address, like a transaction
+// request does.
+type SignRequest struct {
+	Address    *string `json:"address"`
+	Passphrase *string `json:"passphrase"`
+	Message    *string `json:"message"`
+}
+
+func (r SignRequest) Validate() error {
+	if r.Address == nil || r.Passphrase == nil || r.Message == nil {
+		return fmt.Errorf("%w: address, passphrase and message are required", ErrMissingField)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package multisig_request
+
+import (
+	"../../../offline_tx"
+	"../../../wallet"
+	"errors"
+	"fmt"
+)
+
+var ErrMissingField = errors.New("missing multisig field")
+
+type AddressRequest struct {
+	Threshold  int      `json:"threshold"`
+	PublicKeys []string `json:"publicKeys"`
+}
+
+type CreateRequest struct {
+	SenderBlockchainAddress    *string                `json:"senderBlockchainAddress"`
+	RecipientBlockchainAddress *string                `json:"recipientBlockchainAddress"`
+	Value                      *float32               `json:"value"`
+	Nonce                      *uint64                `json:"nonce,omitempty"`
+	Multisig                   *wallet.MultisigPolicy `json:"multisig"`
+}
+
+type SignRequest struct {
+	Transaction   *offlinetx.File `json:"transaction"`
+	SignerAddress *string         `json:"signerAddress"`
+	Passphrase    *string         `json:"passphrase"`
+}
+
+type CombineRequest struct {
+	Transactions []*offlinetx.File `json:"transactions"`
+}
+
+type BroadcastRequest struct {
+	Transaction *offlinetx.File `json:"transaction"`
+}
+
+func (r AddressRequest) Validate() error {
+	if r.Threshold == 0 || len(r.PublicKeys) == 0 {
+		return fmt.Errorf("%w: threshold and publicKeys are required", ErrMissingField)
+	}
+
+	return nil
+}
+
+func (r CreateRequest) Validate() error {
+	if r.SenderBlockchainAddress == nil ||
+		r.RecipientBlockchainAddress == nil ||
+		r.Value == nil ||
+		r.Multisig == nil {
+		return fmt.Errorf("%w: senderBlockchainAddress, recipientBlockchainAddress, value and multisig are required", ErrMissingField)
+	}
+
+	return nil
+}
+
+func (r SignRequest) Validate() error {
+	if r.Transaction == nil || r.SignerAddress == nil || r.Passphrase == nil {
+		return fmt.Errorf("%w: transaction, signerAddress and passphrase are required", ErrMissingField)
+	}
+
+	return nil
+}
+
+func (r CombineRequest) Validate() error {
+	if len(r.Transactions) == 0 {
+		return fmt.Errorf("%w: transactions are required", ErrMissingField)
+	}
+
+	return nil
+}
+
+func (r BroadcastRequest) Validate() error {
+	if r.Transaction == nil {
+		return fmt.Errorf("%w: transaction is required", ErrMissingField)
+	}
+
+	return nil
+}
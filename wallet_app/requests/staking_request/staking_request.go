@@ -0,0 +1,24 @@
+package staking_request
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrMissingField = errors.New("missing staking field")
+
+// StakingRequest stakes or unstakes value for a keystore key; the key signs
+// both directions.
+type StakingRequest struct {
+	Address    *string  `json:"address"`
+	Passphrase *string  `json:"passphrase"`
+	Value      *float32 `json:"value"`
+}
+
+func (r StakingRequest) Validate() error {
+	if r.Address == nil || r.Passphrase == nil || r.Value == nil {
+		return fmt.Errorf("%w: address, passphrase and value are required", ErrMissingField)
+	}
+
+	return nil
+}
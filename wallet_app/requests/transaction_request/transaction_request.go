@@ -1,9 +1,28 @@
 package transaction_request
 
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrMissingField = errors.New("missing transaction field")
+
+// TransactionRequest names a keystore key by its address; the passphrase
+// unlocks it inside the wallet app, so no key material is sent.
 type TransactionRequest struct {
-	SenderPrivateKey           *string  `json:"senderPrivateKey"`
-	SenderPublicKey            *string  `json:"senderPublicKey"`
 	SenderBlockchainAddress    *string  `json:"senderBlockchainAddress"`
+	Passphrase                 *string  `json:"passphrase"`
 	RecipientBlockchainAddress *string  `json:"recipientBlockchainAddress"`
 	Value                      *float32 `json:"value"`
 }
+
+func (tr TransactionRequest) Validate() error {
+	if tr.SenderBlockchainAddress == nil ||
+		tr.Passphrase == nil ||
+		tr.RecipientBlockchainAddress == nil ||
+		tr.Value == nil {
+		return fmt.Errorf("%w: senderBlockchainAddress, passphrase, recipientBlockchainAddress and value are required", ErrMissingField)
+	}
+
+	return nil
+}
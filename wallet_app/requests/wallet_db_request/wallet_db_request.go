@@ -0,0 +1,34 @@
+package wallet_db_request
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrMissingField = errors.New("missing wallet database field")
+
+type WatchOnlyRequest struct {
+	Address *string `json:"address"`
+	Label   string  `json:"label"`
+}
+
+type ContactRequest struct {
+	Address *string `json:"address"`
+	Label   *string `json:"label"`
+}
+
+func (r WatchOnlyRequest) Validate() error {
+	if r.Address == nil {
+		return fmt.Errorf("%w: address is required", ErrMissingField)
+	}
+
+	return nil
+}
+
+func (r ContactRequest) Validate() error {
+	if r.Address == nil || r.Label == nil {
+		return fmt.Errorf("%w: address and label are required", ErrMissingField)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"../api_error"
+	"../block_chain"
+	"../chain_params"
+	"../wallet"
+	"./requests/staking_request"
+	"fmt"
+	"github.com/labstack/echo"
+)
+
+// HandleStake sends value from a keystore key to the stake address, making
+// the key a validator on a proof-of-stake node once it holds enough.
+func HandleStake(c echo.Context) error {
+	return submitStaking(c, false)
+}
+
+// HandleUnstake returns staked value to the key that staked it. The key
+// signs the transfer from the stake address.
+func HandleUnstake(c echo.Context) error {
+	return submitStaking(c, true)
+}
+
+func submitStaking(c echo.Context, unstake bool) error {
+	req := staking_request.StakingRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	address, err := wallet.CanonicalAddress(*req.Address, params)
+
+	if err != nil {
+		return fmt.Errorf("address: %w", err)
+	}
+
+	w, err := keys.Load(address, *req.Passphrase)
+
+	if err != nil {
+		return err
+	}
+
+	sender, recipient := address, chainparams.StakeAddress
+	if unstake {
+		sender, recipient = chainparams.StakeAddress, address
+	}
+
+	nonce, err := nextNonce(address)
+
+	if err != nil {
+		return err
+	}
+
+	t := wallet.NewTransaction(w.PrivateKey(), w.PublicKey(), sender, recipient, *req.Value, nonce)
+	sign, err := t.GenerateSignature()
+
+	if err != nil {
+		return err
+	}
+
+	signStr := sign.String()
+	scheme := w.Scheme().Name()
+	publicKeyStr := w.PublicKeyStr()
+
+	return broadcastTransaction(c, blockchain.TransactionRequest{
+		SenderBlockchainAddress:    &sender,
+		RecipientBlockchainAddress: &recipient,
+		Scheme:                     &scheme,
+		SenderPublicKey:            &publicKeyStr,
+		Value:                      req.Value,
+		Nonce:                      &nonce,
+		Signature:                  &signStr,
+	})
+}
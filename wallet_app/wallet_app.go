@@ -1,11 +1,16 @@
 package main
 
 import (
+	"../api_error"
 	"../block_chain"
-	"../utils"
+	"../chain_params"
+	"../config"
+	"../keystore"
 	"../wallet"
+	"../wallet_db"
 	"./requests/transaction_request"
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/labstack/echo"
@@ -15,6 +20,10 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 func init() {
@@ -29,19 +38,56 @@ func (t *Template) Render(w io.Writer, name string, data interface{}, c echo.Con
 	return t.templates.ExecuteTemplate(w, name, data)
 }
 
+var cfg = config.DefaultWalletApp()
+var params *chainparams.Params
+var keys *keystore.KeyStore
+var db *walletdb.DB
+
 func main() {
+	if err := config.Load("wallet_app", "WALLET_APP", cfg, os.Args[1:]); err != nil {
+		log.Fatalln(err)
+	}
+	cfg.NodeURL = strings.TrimRight(cfg.NodeURL, "/")
+	config.Print(cfg)
+
+	p, err := chainparams.ByName(cfg.Network)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+	params = p
+
+	ks, err := keystore.Open(cfg.KeystoreDir, params)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+	keys = ks
+
+	walletDB, err := walletdb.Open(cfg.DatabasePath, params)
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+	db = walletDB
+
 	fmt.Println("server run")
 
 	t := &Template{
-		templates: template.Must(template.ParseGlob("/Users/burak.olgun/projects/blockchain-examples/wallet_app/templates/*.html")),
+		templates: template.Must(template.ParseGlob(filepath.Join(cfg.TemplateDir, "*.html"))),
 	}
 
 	server := echo.New()
+	server.HTTPErrorHandler = apierror.HTTPErrorHandler
+
+	server.Use(sameOrigin)
 
-	server.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
-	}))
+	if len(cfg.AllowedOrigins) > 0 {
+		server.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+			AllowOrigins: cfg.AllowedOrigins,
+			AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
+		}))
+	}
 
 	server.Renderer = t
 
@@ -50,70 +96,237 @@ func main() {
 	server.GET("/balance/:walletAddress", func(c echo.Context) error {
 		walletAddress := c.Param("walletAddress")
 
-		res, err := http.Get("http://localhost:5001/wallet-balance/" + walletAddress)
+		res, err := http.Get(cfg.NodeURL + "/wallet-balance/" + walletAddress)
 
 		if err != nil {
-			panic(err)
+			return upstreamError(err)
 		}
+		defer res.Body.Close()
 
 		body, err := ioutil.ReadAll(res.Body)
 		if err != nil {
-			log.Fatalln(err)
+			return upstreamError(err)
 		}
 
-		return c.JSONBlob(http.StatusOK, body)
+		return c.JSONBlob(res.StatusCode, body)
 	})
 
+	server.GET("/addresses/:address", HandleCheckAddress)
 	server.POST("/send-transaction", HandleTransaction)
-	server.Logger.Fatal(server.Start(":5000"))
+	server.POST("/hd-wallets", HandleCreateHDWallet)
+	server.POST("/hd-wallets/addresses", HandleListHDAddresses)
+	server.POST("/hd-wallets/scan", HandleScanHDWallet)
+	server.POST("/multisig/addresses", HandleCreateMultisigAddress)
+	server.POST("/multisig/transactions", HandleCreateMultisigTransaction)
+	server.POST("/multisig/transactions/sign", HandleSignMultisigTransaction)
+	server.POST("/multisig/transactions/combine", HandleCombineMultisigTransactions)
+	server.POST("/multisig/transactions/broadcast", HandleBroadcastMultisigTransaction)
+	server.GET("/keys", HandleListKeys)
+	server.POST("/keys", HandleCreateKey)
+	server.POST("/keys/import", HandleImportKey)
+	server.POST("/keys/:address/passphrase", HandleChangePassphrase)
+	server.GET("/wallets", HandleListWallets)
+	server.POST("/wallets/watch-only", HandleAddWatchOnly)
+	server.DELETE("/wallets/watch-only/:address", HandleRemoveWatchOnly)
+	server.GET("/address-book", HandleListContacts)
+	server.POST("/address-book", HandleAddContact)
+	server.DELETE("/address-book/:address", HandleRemoveContact)
+	server.GET("/history/:walletAddress", HandleWalletHistory)
+	server.POST("/messages/sign", HandleSignMessage)
+	server.POST("/messages/verify", HandleVerifyMessage)
+	server.POST("/staking/stake", HandleStake)
+	server.POST("/staking/unstake", HandleUnstake)
+	server.Logger.Fatal(server.Start(cfg.HTTPAddress))
 }
 
 func WalletApp(c echo.Context) error {
-	return c.Render(http.StatusOK, "index.html", "test")
+	return c.Render(http.StatusOK, "index.html", struct {
+		NodeURL string
+	}{
+		NodeURL: cfg.NodeURL,
+	})
 }
 
+// HandleTransaction signs with a key from the keystore and forwards only
+// the signed transaction to the node.
 func HandleTransaction(c echo.Context) error {
 	req := transaction_request.TransactionRequest{}
 	err := c.Bind(&req)
 
 	if err != nil {
-		panic(err)
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	sender, err := wallet.CanonicalAddress(*req.SenderBlockchainAddress, params)
+
+	if err != nil {
+		return fmt.Errorf("sender: %w", err)
 	}
 
-	publicKey := utils.PublicKeyFromString(*req.SenderPublicKey)
-	privateKey := utils.PrivateKeyFromString(*req.SenderPrivateKey, publicKey)
+	if err := wallet.ValidateAddress(*req.RecipientBlockchainAddress, params); err != nil {
+		return fmt.Errorf("recipient: %w", err)
+	}
+
+	w, err := keys.Load(sender, *req.Passphrase)
 
-	fmt.Println(publicKey)
-	fmt.Println(privateKey)
+	if err != nil {
+		return err
+	}
+
+	nonce, err := nextNonce(sender)
+
+	if err != nil {
+		return err
+	}
 
-	t := wallet.NewTransaction(privateKey, publicKey, *req.SenderBlockchainAddress, *req.RecipientBlockchainAddress, *req.Value)
+	t := wallet.NewTransaction(w.PrivateKey(), w.PublicKey(), sender, *req.RecipientBlockchainAddress, *req.Value, nonce)
+
+	sign, err := t.GenerateSignature()
+
+	if err != nil {
+		return err
+	}
 
-	sign := t.GenerateSignature()
 	signStr := sign.String()
+	scheme := w.Scheme().Name()
+	publicKeyStr := w.PublicKeyStr()
 
-	bt := blockchain.TransactionRequest{
-		SenderBlockchainAddress:    req.SenderBlockchainAddress,
+	return broadcastTransaction(c, blockchain.TransactionRequest{
+		SenderBlockchainAddress:    &sender,
 		RecipientBlockchainAddress: req.RecipientBlockchainAddress,
-		SenderPublicKey:            req.SenderPublicKey,
+		Scheme:                     &scheme,
+		SenderPublicKey:            &publicKeyStr,
 		Value:                      req.Value,
+		Nonce:                      &nonce,
 		Signature:                  &signStr,
+	})
+}
+
+// nextNonce asks the node for a nonce address has not signed with yet.
+func nextNonce(address string) (uint64, error) {
+	res, err := http.Get(cfg.NodeURL + "/wallet-nonce/" + url.PathEscape(address))
+
+	if err != nil {
+		return 0, upstreamError(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, upstreamError(fmt.Errorf("node answered %d", res.StatusCode))
+	}
+
+	next := struct {
+		Nonce uint64 `json:"nonce"`
+	}{}
+
+	if err := json.NewDecoder(res.Body).Decode(&next); err != nil {
+		return 0, upstreamError(err)
 	}
 
+	return next.Nonce, nil
+}
+
+// broadcastTransaction hands a signed transaction to the node and passes on
+// its answer when the node refuses it.
+func broadcastTransaction(c echo.Context, bt blockchain.TransactionRequest) error {
 	m, err := json.Marshal(bt)
 
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	buf := bytes.NewBuffer(m)
 
-	res, err := http.Post("http://localhost:5001/transactions", "application/json", buf)
+	res, err := http.Post(cfg.NodeURL+"/transactions", "application/json", buf)
+
+	if err != nil {
+		return upstreamError(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		body, err := ioutil.ReadAll(res.Body)
+
+		if err != nil {
+			return upstreamError(err)
+		}
+
+		return c.JSONBlob(res.StatusCode, body)
+	}
+
+	return c.JSON(http.StatusOK, bt)
+}
+
+// sameOrigin refuses requests a browser sends from a page of another
+// origin unless cfg.AllowedOrigins lists it. The app creates mnemonics and
+// signs with keystore keys, so no other site may drive it, not even with
+// a form post that CORS would let through.
+func sameOrigin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		origin := c.Request().Header.Get(echo.HeaderOrigin)
+
+		if origin == "" || allowedOrigin(origin, c.Request().Host) {
+			return next(c)
+		}
+
+		return apierror.New(http.StatusForbidden, apierror.CodeForbidden, "origin is not allowed", origin)
+	}
+}
+
+func allowedOrigin(origin string, host string) bool {
+	if u, err := url.Parse(origin); err == nil && u.Host == host {
+		return true
+	}
+
+	for _, allowed := range cfg.AllowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HandleCheckAddress lets the page check a typed address before it signs
+// anything.
+func HandleCheckAddress(c echo.Context) error {
+	publicKeyHash, err := wallet.ParseAddress(c.Param("address"), params)
 
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	fmt.Println(res.StatusCode)
+	base58Address, err := wallet.CanonicalAddress(c.Param("address"), params)
+
+	if err != nil {
+		return err
+	}
+
+	bech32mAddress, err := wallet.Bech32mAddress(c.Param("address"), params)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, struct {
+		Address        string `json:"address"`
+		Network        string `json:"network"`
+		PublicKeyHash  string `json:"publicKeyHash"`
+		Base58Address  string `json:"base58Address"`
+		Bech32mAddress string `json:"bech32mAddress"`
+	}{
+		Address:        c.Param("address"),
+		Network:        params.Name,
+		PublicKeyHash:  hex.EncodeToString(publicKeyHash),
+		Base58Address:  base58Address,
+		Bech32mAddress: bech32mAddress,
+	})
+}
 
-	return c.JSON(http.StatusOK, req)
+func upstreamError(err error) error {
+	return apierror.New(http.StatusBadGateway, apierror.CodeUpstreamError, "blockchain node is not reachable", err.Error())
 }
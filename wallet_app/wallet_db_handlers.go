@@ -0,0 +1,161 @@
+package main
+
+import (
+	"../api_error"
+	"../wallet"
+	"../wallet_db"
+	"./requests/wallet_db_request"
+	"fmt"
+	"github.com/labstack/echo"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+)
+
+type walletResponse struct {
+	BlockchainAddress string `json:"blockchainAddress"`
+	Bech32mAddress    string `json:"bech32mAddress"`
+	Label             string `json:"label"`
+	WatchOnly         bool   `json:"watchOnly"`
+}
+
+// HandleListWallets lists the keystore keys, which can send, followed by
+// the watch-only addresses, which can only be watched.
+func HandleListWallets(c echo.Context) error {
+	addresses, err := keys.Addresses()
+
+	if err != nil {
+		return err
+	}
+
+	wallets := []walletResponse{}
+	for _, address := range addresses {
+		wallets = append(wallets, walletResponse{
+			BlockchainAddress: address,
+			Bech32mAddress:    bech32mOrEmpty(address),
+		})
+	}
+
+	for _, w := range db.WatchOnly() {
+		wallets = append(wallets, walletResponse{
+			BlockchainAddress: w.Address,
+			Bech32mAddress:    bech32mOrEmpty(w.Address),
+			Label:             w.Label,
+			WatchOnly:         true,
+		})
+	}
+
+	return c.JSON(http.StatusOK, struct {
+		Wallets []walletResponse `json:"wallets"`
+	}{
+		Wallets: wallets,
+	})
+}
+
+func HandleAddWatchOnly(c echo.Context) error {
+	req := wallet_db_request.WatchOnlyRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	address, err := wallet.CanonicalAddress(*req.Address, params)
+
+	if err != nil {
+		return err
+	}
+
+	if keys.Has(address) {
+		return fmt.Errorf("%w: %s is a keystore key", walletdb.ErrAlreadyExists, address)
+	}
+
+	entry, err := db.AddWatchOnly(address, req.Label)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, walletResponse{
+		BlockchainAddress: entry.Address,
+		Bech32mAddress:    bech32mOrEmpty(entry.Address),
+		Label:             entry.Label,
+		WatchOnly:         true,
+	})
+}
+
+func HandleRemoveWatchOnly(c echo.Context) error {
+	if err := db.RemoveWatchOnly(c.Param("address")); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func HandleListContacts(c echo.Context) error {
+	return c.JSON(http.StatusOK, struct {
+		AddressBook []walletdb.Contact `json:"addressBook"`
+	}{
+		AddressBook: db.AddressBook(),
+	})
+}
+
+func HandleAddContact(c echo.Context) error {
+	req := wallet_db_request.ContactRequest{}
+
+	if err := c.Bind(&req); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	if err := req.Validate(); err != nil {
+		return apierror.BadRequest(err.Error())
+	}
+
+	contact, err := db.AddContact(*req.Address, *req.Label)
+
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, contact)
+}
+
+func HandleRemoveContact(c echo.Context) error {
+	if err := db.RemoveContact(c.Param("address")); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// HandleWalletHistory forwards the node's confirmed history of an address,
+// keystore key or watch-only alike.
+func HandleWalletHistory(c echo.Context) error {
+	res, err := http.Get(cfg.NodeURL + "/wallet-history/" + url.PathEscape(c.Param("walletAddress")))
+
+	if err != nil {
+		return upstreamError(err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+
+	if err != nil {
+		return upstreamError(err)
+	}
+
+	return c.JSONBlob(res.StatusCode, body)
+}
+
+func bech32mOrEmpty(address string) string {
+	bech32mAddress, err := wallet.Bech32mAddress(address, params)
+
+	if err != nil {
+		return ""
+	}
+
+	return bech32mAddress
+}
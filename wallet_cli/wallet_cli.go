@@ -0,0 +1,512 @@
+package main
+
+import (
+	"../block_chain"
+	"../chain_params"
+	"../keystore"
+	"../offline_tx"
+	"../wallet"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"golang.org/x/term"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const usage = `usage: wallet_cli <command> [flags]
+
+commands:
+  create            build an unsigned transaction file (watch-only machine)
+  inspect           show what a transaction file commits to
+  sign              sign a transaction file with a keystore key (offline machine)
+  combine           merge the signatures of several copies of a transaction file
+  broadcast         send a signed transaction file to a node (online machine)
+  multisig-address  build an M-of-N policy file and print its address
+  public-key        print the public key of a keystore key
+
+Run wallet_cli <command> -h for the flags of a command.
+`
+
+func init() {
+	log.SetPrefix("Wallet CLI: ")
+	log.SetFlags(0)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	commands := map[string]func([]string) error{
+		"create":           create,
+		"inspect":          inspect,
+		"sign":             sign,
+		"combine":          combine,
+		"broadcast":        broadcast,
+		"multisig-address": multisigAddress,
+		"public-key":       publicKey,
+	}
+
+	command, ok := commands[os.Args[1]]
+
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	if err := command(os.Args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+		log.Fatalln(err)
+	}
+}
+
+func create(args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	network := fs.String("network", "mainnet", "network preset: mainnet, testnet or regtest")
+	from := fs.String("from", "", "sender address")
+	to := fs.String("to", "", "recipient address")
+	value := fs.Float64("value", 0, "amount to send")
+	nonce := fs.Uint64("nonce", 0, "sender nonce, used once; required without -node, which tells the next one")
+	nodeURL := fs.String("node", "", "optional node URL; binds the file to its genesis, checks the balance and picks the nonce")
+	policyFile := fs.String("policy", "", "multisig policy file, required when -from is a multisig address")
+	out := fs.String("out", "", "file to write")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return errors.New("-out is required")
+	}
+
+	params, err := chainparams.ByName(*network)
+
+	if err != nil {
+		return err
+	}
+
+	genesisHash := ""
+
+	if *nodeURL != "" {
+		info := blockchain.ChainInfo{}
+
+		if err := getJSON(*nodeURL+"/chain-info", &info); err != nil {
+			return err
+		}
+		genesisHash = info.GenesisHash
+
+		balance := struct {
+			Balance float32 `json:"balance"`
+		}{}
+
+		if err := getJSON(*nodeURL+"/wallet-balance/"+*from, &balance); err != nil {
+			return err
+		}
+
+		if balance.Balance < float32(*value) {
+			log.Printf("warning: %s has %.8g, the transaction needs %.8g\n", *from, balance.Balance, *value)
+		}
+
+		if *nonce == 0 {
+			next := struct {
+				Nonce uint64 `json:"nonce"`
+			}{}
+
+			if err := getJSON(*nodeURL+"/wallet-nonce/"+*from, &next); err != nil {
+				return err
+			}
+			*nonce = next.Nonce
+		}
+	}
+
+	if *nonce == 0 {
+		return errors.New("-nonce is required without -node")
+	}
+
+	var policy *wallet.MultisigPolicy
+
+	if *policyFile != "" {
+		policy, err = loadPolicy(*policyFile)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	f, err := offlinetx.New(*from, *to, float32(*value), *nonce, genesisHash, policy, params)
+
+	if err != nil {
+		return err
+	}
+
+	if err := f.Save(*out); err != nil {
+		return err
+	}
+
+	fmt.Print(f.Describe())
+	return nil
+}
+
+func inspect(args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: wallet_cli inspect <file>")
+	}
+
+	f, err := offlinetx.Load(fs.Arg(0))
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(f.Describe())
+
+	params, err := chainparams.ByName(f.Network)
+
+	if err != nil {
+		return err
+	}
+
+	if err := f.Check(params); err != nil {
+		fmt.Printf("check           FAILED: %v\n", err)
+		return nil
+	}
+
+	fmt.Println("check           ok")
+	return nil
+}
+
+// sign never touches the network, so it can run on an air-gapped machine
+// that only holds the keystore.
+func sign(args []string) error {
+	fs := flag.NewFlagSet("sign", flag.ContinueOnError)
+	keystoreDir := fs.String("keystore-dir", "keys", "directory holding encrypted key files")
+	in := fs.String("in", "", "unsigned transaction file")
+	out := fs.String("out", "", "signed transaction file to write")
+	key := fs.String("key", "", "address of the signing key; defaults to the sender, required for multisig")
+	yes := fs.Bool("yes", false, "sign without asking for confirmation")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *in == "" || *out == "" {
+		return errors.New("-in and -out are required")
+	}
+
+	f, err := offlinetx.Load(*in)
+
+	if err != nil {
+		return err
+	}
+
+	params, err := chainparams.ByName(f.Network)
+
+	if err != nil {
+		return err
+	}
+
+	if err := f.Check(params); err != nil {
+		return err
+	}
+
+	fmt.Print(f.Describe())
+
+	if !*yes && !confirm("sign this transaction?") {
+		return errors.New("not signed")
+	}
+
+	ks, err := keystore.Open(*keystoreDir, params)
+
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := readPassphrase()
+
+	if err != nil {
+		return err
+	}
+
+	signer := *key
+	if signer == "" {
+		signer = f.Transaction.SenderBlockchainAddress
+	}
+
+	w, err := ks.Load(signer, passphrase)
+
+	if err != nil {
+		return err
+	}
+
+	if err := f.Sign(w, params); err != nil {
+		return err
+	}
+
+	return f.Save(*out)
+}
+
+func combine(args []string) error {
+	fs := flag.NewFlagSet("combine", flag.ContinueOnError)
+	out := fs.String("out", "", "file to write")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *out == "" || fs.NArg() < 2 {
+		return errors.New("usage: wallet_cli combine -out <file> <file> <file>...")
+	}
+
+	f, err := offlinetx.Load(fs.Arg(0))
+
+	if err != nil {
+		return err
+	}
+
+	for _, path := range fs.Args()[1:] {
+		other, err := offlinetx.Load(path)
+
+		if err != nil {
+			return err
+		}
+
+		if err := f.Combine(other); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	params, err := chainparams.ByName(f.Network)
+
+	if err != nil {
+		return err
+	}
+
+	if err := f.Check(params); err != nil {
+		return err
+	}
+
+	fmt.Print(f.Describe())
+	return f.Save(*out)
+}
+
+func broadcast(args []string) error {
+	fs := flag.NewFlagSet("broadcast", flag.ContinueOnError)
+	nodeURL := fs.String("node", "http://localhost:5001", "base URL of the blockchain node")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: wallet_cli broadcast [-node URL] <file>")
+	}
+
+	f, err := offlinetx.Load(fs.Arg(0))
+
+	if err != nil {
+		return err
+	}
+
+	params, err := chainparams.ByName(f.Network)
+
+	if err != nil {
+		return err
+	}
+
+	if err := f.Check(params); err != nil {
+		return err
+	}
+
+	req, err := f.Request()
+
+	if err != nil {
+		return err
+	}
+
+	nodeBase := strings.TrimRight(*nodeURL, "/")
+
+	if f.GenesisHash != "" {
+		info := blockchain.ChainInfo{}
+
+		if err := getJSON(nodeBase+"/chain-info", &info); err != nil {
+			return err
+		}
+
+		if info.GenesisHash != f.GenesisHash {
+			return fmt.Errorf("%w: file is bound to %s, node runs %s", blockchain.ErrGenesisMismatch, f.GenesisHash, info.GenesisHash)
+		}
+	}
+
+	m, err := json.Marshal(req)
+
+	if err != nil {
+		return err
+	}
+
+	res, err := http.Post(nodeBase+"/transactions", "application/json", bytes.NewBuffer(m))
+
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("node rejected the transaction: %s %s", res.Status, body)
+	}
+
+	fmt.Println("transaction accepted")
+	return nil
+}
+
+// multisigAddress takes the public keys every party printed with
+// public-key, so no party has to reveal anything else.
+func multisigAddress(args []string) error {
+	fs := flag.NewFlagSet("multisig-address", flag.ContinueOnError)
+	network := fs.String("network", "mainnet", "network preset: mainnet, testnet or regtest")
+	threshold := fs.Int("threshold", 0, "signatures required to spend (M)")
+	keys := fs.String("keys", "", "comma-separated hex public keys (N)")
+	out := fs.String("out", "", "policy file to write")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	params, err := chainparams.ByName(*network)
+
+	if err != nil {
+		return err
+	}
+
+	policy, err := wallet.ParseMultisigPolicy(*threshold, strings.Split(*keys, ","))
+
+	if err != nil {
+		return err
+	}
+
+	bech32mAddress, err := policy.Bech32mAddress(params)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("address         %s\n", policy.Address(params))
+	fmt.Printf("bech32m         %s\n", bech32mAddress)
+	fmt.Printf("policy          %d of %d\n", policy.Threshold, len(policy.PublicKeys))
+
+	if *out == "" {
+		return nil
+	}
+
+	content, err := json.MarshalIndent(policy, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(*out, append(content, '\n'), 0644)
+}
+
+func publicKey(args []string) error {
+	fs := flag.NewFlagSet("public-key", flag.ContinueOnError)
+	network := fs.String("network", "mainnet", "network preset: mainnet, testnet or regtest")
+	keystoreDir := fs.String("keystore-dir", "keys", "directory holding encrypted key files")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: wallet_cli public-key [-keystore-dir dir] <address>")
+	}
+
+	params, err := chainparams.ByName(*network)
+
+	if err != nil {
+		return err
+	}
+
+	ks, err := keystore.Open(*keystoreDir, params)
+
+	if err != nil {
+		return err
+	}
+
+	key, err := ks.PublicKey(fs.Arg(0))
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(key)
+	return nil
+}
+
+func loadPolicy(path string) (*wallet.MultisigPolicy, error) {
+	content, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &wallet.MultisigPolicy{}
+
+	if err := json.Unmarshal(content, policy); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return policy, nil
+}
+
+func getJSON(url string, v interface{}) error {
+	res, err := http.Get(url)
+
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("GET %s: %s %s", url, res.Status, body)
+	}
+
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// readPassphrase prefers WALLET_CLI_PASSPHRASE so signing can be scripted,
+// and otherwise prompts without echo.
+func readPassphrase() (string, error) {
+	if p, ok := os.LookupEnv("WALLET_CLI_PASSPHRASE"); ok {
+		return p, nil
+	}
+
+	fmt.Fprint(os.Stderr, "passphrase: ")
+	p, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+
+	return string(p), err
+}
+
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+
+	return strings.EqualFold(strings.TrimSpace(answer), "y")
+}
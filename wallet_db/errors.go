@@ -0,0 +1,9 @@
+package walletdb
+
+import "errors"
+
+var (
+	ErrNotFound      = errors.New("entry not found in wallet database")
+	ErrAlreadyExists = errors.New("entry already exists in wallet database")
+	ErrInvalidLabel  = errors.New("invalid label")
+)
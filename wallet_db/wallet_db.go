@@ -0,0 +1,231 @@
+package walletdb
+
+import (
+	"../chain_params"
+	"../wallet"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// WatchOnly is an address the wallet app tracks without holding its key,
+// e.g. one printed by Wallet.BlockchainAddress on another machine.
+type WatchOnly struct {
+	Address   string `json:"address"`
+	Label     string `json:"label"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+// Contact is a labeled recipient of the address book.
+type Contact struct {
+	Address   string `json:"address"`
+	Label     string `json:"label"`
+	CreatedAt int64  `json:"createdAt"`
+}
+
+type content struct {
+	WatchOnly   []WatchOnly `json:"watchOnly"`
+	AddressBook []Contact   `json:"addressBook"`
+}
+
+// DB keeps the wallet app's own data in one JSON file. Addresses are
+// stored in canonical Base58Check form, so either spelling finds an entry.
+type DB struct {
+	path   string
+	params *chainparams.Params
+
+	lock sync.Mutex
+	data content
+}
+
+// Open reads path, or starts an empty database when it does not exist yet.
+func Open(path string, params *chainparams.Params) (*DB, error) {
+	db := &DB{path: path, params: params}
+
+	b, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return db, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(b, &db.data); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return db, nil
+}
+
+func (db *DB) WatchOnly() []WatchOnly {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	return append([]WatchOnly{}, db.data.WatchOnly...)
+}
+
+func (db *DB) IsWatchOnly(address string) bool {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	return db.findWatchOnly(db.canonical(address)) >= 0
+}
+
+func (db *DB) AddWatchOnly(address string, label string) (*WatchOnly, error) {
+	canonical, err := wallet.CanonicalAddress(address, db.params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.findWatchOnly(canonical) >= 0 {
+		return nil, fmt.Errorf("%w: watch-only %s", ErrAlreadyExists, canonical)
+	}
+
+	entry := WatchOnly{Address: canonical, Label: label, CreatedAt: time.Now().UnixMilli()}
+	db.data.WatchOnly = append(db.data.WatchOnly, entry)
+
+	if err := db.save(); err != nil {
+		db.data.WatchOnly = db.data.WatchOnly[:len(db.data.WatchOnly)-1]
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (db *DB) RemoveWatchOnly(address string) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	i := db.findWatchOnly(db.canonical(address))
+
+	if i < 0 {
+		return fmt.Errorf("%w: watch-only %s", ErrNotFound, address)
+	}
+
+	previous := db.data.WatchOnly
+	db.data.WatchOnly = append(append([]WatchOnly{}, previous[:i]...), previous[i+1:]...)
+
+	if err := db.save(); err != nil {
+		db.data.WatchOnly = previous
+		return err
+	}
+
+	return nil
+}
+
+func (db *DB) AddressBook() []Contact {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	return append([]Contact{}, db.data.AddressBook...)
+}
+
+// AddContact labels a recipient. Labels must be unique so they can stand
+// in for the address in the UI.
+func (db *DB) AddContact(address string, label string) (*Contact, error) {
+	if label == "" {
+		return nil, fmt.Errorf("%w: a contact needs a label", ErrInvalidLabel)
+	}
+
+	canonical, err := wallet.CanonicalAddress(address, db.params)
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	for _, c := range db.data.AddressBook {
+		if c.Address == canonical || c.Label == label {
+			return nil, fmt.Errorf("%w: contact %q, %s", ErrAlreadyExists, c.Label, c.Address)
+		}
+	}
+
+	entry := Contact{Address: canonical, Label: label, CreatedAt: time.Now().UnixMilli()}
+	db.data.AddressBook = append(db.data.AddressBook, entry)
+
+	if err := db.save(); err != nil {
+		db.data.AddressBook = db.data.AddressBook[:len(db.data.AddressBook)-1]
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (db *DB) RemoveContact(address string) error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	canonical := db.canonical(address)
+
+	for i, c := range db.data.AddressBook {
+		if c.Address != canonical {
+			continue
+		}
+
+		previous := db.data.AddressBook
+		db.data.AddressBook = append(append([]Contact{}, previous[:i]...), previous[i+1:]...)
+
+		if err := db.save(); err != nil {
+			db.data.AddressBook = previous
+			return err
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: contact %s", ErrNotFound, address)
+}
+
+func (db *DB) findWatchOnly(canonical string) int {
+	for i, w := range db.data.WatchOnly {
+		if w.Address == canonical {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (db *DB) canonical(address string) string {
+	if canonical, err := wallet.CanonicalAddress(address, db.params); err == nil {
+		return canonical
+	}
+
+	return address
+}
+
+// save writes through a temporary file, so a crash never leaves a
+// half-written database behind.
+func (db *DB) save() error {
+	b, err := json.MarshalIndent(db.data, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(db.path); dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+
+	tmp := db.path + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, db.path)
+}